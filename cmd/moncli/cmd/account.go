@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strconv"
 	"time"
@@ -12,6 +12,7 @@ import (
 	"github.com/glynternet/go-money/currency"
 	"github.com/glynternet/mon/internal/accountbalance"
 	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/reconcile"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/glynternet/mon/pkg/table"
 	"github.com/pkg/errors"
@@ -29,11 +30,16 @@ const (
 	keyLimit          = "limit"
 	keyOpeningBalance = "opening-balance"
 	keyClosingBalance = "closing-balance"
+
+	keyAdjustmentAccount = "adjustment-account"
+	keyAccess            = "access"
 )
 
 var (
 	accountOpened = date.Flag()
 	accountClosed = date.Flag()
+	balancesFrom  = date.Flag()
+	balancesTo    = date.Flag()
 )
 
 var accountCmd = &cobra.Command{
@@ -46,13 +52,21 @@ var accountCmd = &cobra.Command{
 			return errors.Wrap(err, "parsing account id")
 		}
 
+		tmpl, err := outputTemplate()
+		if err != nil {
+			return errors.Wrap(err, "preparing output template")
+		}
+
 		a, err := newClient().SelectAccount(uint(id))
 		if err != nil {
 			return errors.Wrap(err, "selecting account")
 		}
 
-		table.Accounts(storage.Accounts{*a}, os.Stdout)
-		return nil
+		if tmpl != nil {
+			return errors.Wrap(table.Template(tmpl, table.AccountRows(storage.Accounts{*a}), os.Stdout), "printing account with template")
+		}
+
+		return errors.Wrap(table.Accounts(storage.Accounts{*a}, os.Stdout, output), "printing accounts")
 	},
 }
 
@@ -66,20 +80,20 @@ var accountAddCmd = &cobra.Command{
 			return errors.Wrap(err, "creating new currency code")
 		}
 
-		opened := time.Now()
-		if accountOpened.Time != nil {
-			opened = *accountOpened.Time
+		opened := date.Today()
+		if accountOpened.Date != nil {
+			opened = *accountOpened.Date
 		}
 
 		var ops []account.Option
-		if accountClosed.Time != nil {
-			ops = append(ops, account.CloseTime(*accountClosed.Time))
+		if accountClosed.Date != nil {
+			ops = append(ops, account.CloseTime(accountClosed.Date.Time()))
 		}
 
 		a, err := account.New(
 			args[0],
 			*cc,
-			opened,
+			opened.Time(),
 			ops...,
 		)
 		if err != nil {
@@ -90,8 +104,7 @@ var accountAddCmd = &cobra.Command{
 		if err != nil {
 			return errors.Wrap(err, "inserting new account")
 		}
-		table.Accounts(storage.Accounts{*i}, os.Stdout)
-		return nil
+		return errors.Wrap(table.Accounts(storage.Accounts{*i}, os.Stdout, output), "printing accounts")
 	},
 }
 
@@ -105,12 +118,12 @@ var accountOpenCmd = &cobra.Command{
 			return errors.Wrap(err, "creating new currency code")
 		}
 
-		opened := time.Now()
-		if accountOpened.Time != nil {
-			opened = *accountOpened.Time
+		opened := date.Today()
+		if accountOpened.Date != nil {
+			opened = *accountOpened.Date
 		}
 
-		a, err := account.New(args[0], *cc, opened)
+		a, err := account.New(args[0], *cc, opened.Time())
 		if err != nil {
 			return errors.Wrap(err, "creating new account for insert")
 		}
@@ -130,11 +143,10 @@ var accountOpenCmd = &cobra.Command{
 			return errors.Wrap(err, "inserting balance")
 		}
 
-		table.AccountsWithBalance([]accountbalance.AccountBalance{{
+		return errors.Wrap(table.AccountsWithBalance([]accountbalance.AccountBalance{{
 			Account: *i,
 			Balance: b.Balance,
-		}}, os.Stdout)
-		return nil
+		}}, os.Stdout, output), "printing accounts with balance")
 	},
 }
 
@@ -167,9 +179,8 @@ var accountReopenCmd = &cobra.Command{
 			return errors.Wrap(err, "applying updates")
 		}
 
-		fmt.Println("Reopened:")
-		table.Accounts(storage.Accounts{*b}, os.Stdout)
-		return nil
+		infof("Reopened:\n")
+		return errors.Wrap(table.Accounts(storage.Accounts{*b}, os.Stdout, output), "printing accounts")
 	},
 }
 
@@ -195,8 +206,29 @@ var accountDeleteCmd = &cobra.Command{
 			return errors.Wrap(err, "deleting account")
 		}
 
-		fmt.Println("Deleted:")
-		table.Accounts(storage.Accounts{*a}, os.Stdout)
+		infof("Deleted:\n")
+		return errors.Wrap(table.Accounts(storage.Accounts{*a}, os.Stdout, output), "printing accounts")
+	},
+}
+
+var accountShareCmd = &cobra.Command{
+	Use:   "share [ID] [USER]",
+	Short: "share an account with another user",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseID(args[0])
+		if err != nil {
+			return errors.Wrap(err, "parsing account id")
+		}
+		access, err := storage.ParseAccess(viper.GetString(keyAccess))
+		if err != nil {
+			return errors.Wrap(err, "parsing access")
+		}
+		s, err := newClient().ShareAccount(uint(id), args[1], access)
+		if err != nil {
+			return errors.Wrap(err, "sharing account")
+		}
+		infof("Shared account %d with %s, who now has %s access\n", id, s.User, s.Access)
 		return nil
 	},
 }
@@ -206,9 +238,9 @@ var accountCloseCmd = &cobra.Command{
 	Short: "close an account with a balance",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		closed := time.Now()
-		if balanceDate.Time != nil {
-			closed = *balanceDate.Time
+		closed := date.Today()
+		if balanceDate.Date != nil {
+			closed = *balanceDate.Date
 		}
 
 		id, err := parseID(args[0])
@@ -224,7 +256,7 @@ var accountCloseCmd = &cobra.Command{
 		}
 
 		b, err := c.InsertBalance(*a, balance.Balance{
-			Date:   closed,
+			Date:   closed.Time(),
 			Amount: viper.GetInt(keyClosingBalance),
 		})
 		if err != nil {
@@ -246,11 +278,10 @@ var accountCloseCmd = &cobra.Command{
 			return errors.Wrap(err, "updating account")
 		}
 
-		table.AccountsWithBalance([]accountbalance.AccountBalance{{
+		return errors.Wrap(table.AccountsWithBalance([]accountbalance.AccountBalance{{
 			Account: *u,
 			Balance: b.Balance,
-		}}, os.Stdout)
-		return nil
+		}}, os.Stdout, output), "printing accounts with balance")
 	},
 }
 
@@ -273,14 +304,14 @@ the same as the original account`,
 			return errors.Wrap(err, "selecting account to update")
 		}
 
-		opened := time.Now()
-		if accountOpened.Time != nil {
-			opened = *accountOpened.Time
+		opened := date.Today()
+		if accountOpened.Date != nil {
+			opened = *accountOpened.Date
 		}
 
 		var ops []account.Option
-		if accountClosed.Time != nil {
-			ops = append(ops, account.CloseTime(*accountClosed.Time))
+		if accountClosed.Date != nil {
+			ops = append(ops, account.CloseTime(accountClosed.Date.Time()))
 		}
 
 		cc, err := currency.NewCode(viper.GetString(keyCurrency))
@@ -288,7 +319,7 @@ the same as the original account`,
 			return errors.Wrap(err, "creating new currency code")
 		}
 
-		us, err := account.New(viper.GetString(keyName), *cc, opened, ops...)
+		us, err := account.New(viper.GetString(keyName), *cc, opened.Time(), ops...)
 		if err != nil {
 			return errors.Wrap(err, "creating account for update")
 		}
@@ -298,12 +329,13 @@ the same as the original account`,
 			return errors.Wrap(err, "updating account")
 		}
 
-		fmt.Println("ORIGINAL")
-		table.Accounts(storage.Accounts{*a}, os.Stdout)
+		fmt.Fprintln(contextWriter(), "ORIGINAL")
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
 
-		fmt.Println("UPDATED")
-		table.Accounts(storage.Accounts{*u}, os.Stdout)
-		return nil
+		fmt.Fprintln(contextWriter(), "UPDATED")
+		return errors.Wrap(table.Accounts(storage.Accounts{*u}, os.Stdout, output), "printing accounts")
 	},
 }
 
@@ -343,12 +375,13 @@ var accountRenameCmd = &cobra.Command{
 			return errors.Wrap(err, "updating account")
 		}
 
-		fmt.Println("ORIGINAL")
-		table.Accounts(storage.Accounts{*a}, os.Stdout)
+		fmt.Fprintln(contextWriter(), "ORIGINAL")
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
 
-		fmt.Println("UPDATED")
-		table.Accounts(storage.Accounts{*u}, os.Stdout)
-		return nil
+		fmt.Fprintln(contextWriter(), "UPDATED")
+		return errors.Wrap(table.Accounts(storage.Accounts{*u}, os.Stdout, output), "printing accounts")
 	},
 }
 
@@ -361,29 +394,45 @@ var accountBalancesCmd = &cobra.Command{
 			return errors.Wrap(err, "parsing account id")
 		}
 
+		tmpl, err := outputTemplate()
+		if err != nil {
+			return errors.Wrap(err, "preparing output template")
+		}
+
 		c := newClient()
 		a, err := c.SelectAccount(uint(id))
 		if err != nil {
 			return errors.Wrap(err, "selecting account")
 		}
 
-		table.Accounts(storage.Accounts{*a}, os.Stdout)
+		// a template is given the account with each balance instead
+		if tmpl == nil {
+			if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+				return errors.Wrap(err, "printing accounts")
+			}
+		}
 
-		bs, err := c.SelectAccountBalances(*a)
+		// the latest balances are selected when limited, and then put back into
+		// chronological order
+		o := storage.BalancesOptions{
+			From:       balancesFrom.Date,
+			To:         balancesTo.Date,
+			Limit:      uint(viper.GetInt(keyLimit)),
+			Descending: true,
+		}
+		bs, err := c.SelectAccountBalancesWithOptions(*a, o)
 		if err != nil {
 			return errors.Wrap(err, "selecting account balances")
 		}
-
-		limit := viper.GetInt(keyLimit)
-		if limit > len(*bs) {
-			limit = len(*bs)
+		for i, j := 0, len(*bs)-1; i < j; i, j = i+1, j-1 {
+			(*bs)[i], (*bs)[j] = (*bs)[j], (*bs)[i]
 		}
-		if limit != 0 {
-			*bs = (*bs)[len(*bs)-limit:]
+
+		if tmpl != nil {
+			return errors.Wrap(table.Template(tmpl, table.BalanceRows(*a, *bs), os.Stdout), "printing balances with template")
 		}
 
-		table.Balances(*bs, os.Stdout)
-		return nil
+		return errors.Wrap(table.Balances(*bs, os.Stdout, output), "printing balances")
 	},
 }
 
@@ -403,25 +452,120 @@ var accountBalanceInsertCmd = &cobra.Command{
 			return errors.Wrap(err, "selecting account")
 		}
 
-		t := time.Now()
-		if balanceDate.Time != nil {
-			t = *balanceDate.Time
+		d := date.Today()
+		if balanceDate.Date != nil {
+			d = *balanceDate.Date
 		}
 
 		b, err := c.InsertBalance(*a, balance.Balance{
-			Date:   t,
+			Date:   d.Time(),
 			Amount: viper.GetInt(keyAmount),
 		})
 		if err != nil {
 			return errors.Wrap(err, "inserting balance")
 		}
 
-		table.Accounts(storage.Accounts{*a}, os.Stdout)
-		table.Balances(storage.Balances{*b}, os.Stdout)
-		return nil
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
+		return errors.Wrap(table.Balances(storage.Balances{*b}, os.Stdout, output), "printing balances")
 	},
 }
 
+var accountBalanceUpdateCmd = &cobra.Command{
+	Use:   "balance-update [ID] [BALANCE_ID]",
+	Short: "update a balance of an account",
+	Long: `update a balance of an account.
+Any details that are not provided will remain the same as the original balance`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		a, b, err := selectAccountBalanceByArgs(c, args)
+		if err != nil {
+			return err
+		}
+
+		us := b.Balance
+		if balanceDate.Date != nil {
+			us.Date = balanceDate.Date.Time()
+		}
+		if cmd.Flags().Changed(keyAmount) {
+			us.Amount = viper.GetInt(keyAmount)
+		}
+
+		u, err := c.UpdateBalance(*a, b, us)
+		if err != nil {
+			return errors.Wrap(err, "updating balance")
+		}
+
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
+
+		fmt.Fprintln(contextWriter(), "ORIGINAL")
+		if err := table.Balances(storage.Balances{*b}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing balances")
+		}
+
+		fmt.Fprintln(contextWriter(), "UPDATED")
+		return errors.Wrap(table.Balances(storage.Balances{*u}, os.Stdout, output), "printing balances")
+	},
+}
+
+var accountBalanceDeleteCmd = &cobra.Command{
+	Use:   "balance-delete [ID] [BALANCE_ID]",
+	Short: "delete a balance of an account",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		a, b, err := selectAccountBalanceByArgs(c, args)
+		if err != nil {
+			return err
+		}
+
+		err = c.DeleteBalance(*a, b)
+		if err != nil {
+			return errors.Wrap(err, "deleting balance")
+		}
+
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
+
+		infof("Deleted:\n")
+		return errors.Wrap(table.Balances(storage.Balances{*b}, os.Stdout, output), "printing balances")
+	},
+}
+
+// selectAccountBalanceByArgs selects the account and balance identified by
+// the first and second arguments respectively.
+func selectAccountBalanceByArgs(store storage.Storage, args []string) (*storage.Account, *storage.Balance, error) {
+	id, err := parseID(args[0])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing account id")
+	}
+	balanceID, err := parseID(args[1])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing balance id")
+	}
+
+	a, err := store.SelectAccount(uint(id))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "selecting account")
+	}
+
+	bs, err := store.SelectAccountBalances(*a)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "selecting account balances")
+	}
+	for _, b := range *bs {
+		if b.ID == uint(balanceID) {
+			return a, &b, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no balance with id %d for account with id %d", balanceID, a.ID)
+}
+
 var accountBalanceCmd = &cobra.Command{
 	Use:  "balance [ID]",
 	Args: cobra.ExactArgs(1),
@@ -437,20 +581,100 @@ var accountBalanceCmd = &cobra.Command{
 			return errors.Wrap(err, "selecting account")
 		}
 
-		t := time.Now()
-		if balanceDate.Time != nil {
-			t = *balanceDate.Time
+		d := date.Today()
+		if balanceDate.Date != nil {
+			d = *balanceDate.Date
 		}
 
-		b, err := accountBalanceAtTime(c, *a, t)
+		b, err := accountBalanceAtTime(c, *a, d.Time())
 		if err != nil {
-			return errors.Wrapf(err, "getting balance at time:%+v for account:%+v", t, a)
+			return errors.Wrapf(err, "getting balance at date:%s for account:%+v", d, a)
 		}
 		fmt.Println(b.Amount)
 		return nil
 	},
 }
 
+var accountReconcileCmd = &cobra.Command{
+	Use:   "reconcile [ID]",
+	Short: "reconcile the balances of an account with its transactions",
+	Long: `reconcile walks the balances of an account in order, comparing the recorded
+change between each pair of consecutive balances with the change that is
+expected from the transactions of the account. Any interval with a difference
+that is not explained by the transactions is flagged.
+If an adjustment account is given, a transaction between the account and the
+adjustment account is recorded for each flagged interval to explain the
+difference.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := parseID(args[0])
+		if err != nil {
+			return errors.Wrap(err, "parsing account id")
+		}
+
+		c := newClient()
+		a, err := c.SelectAccount(uint(id))
+		if err != nil {
+			return errors.Wrap(err, "selecting account")
+		}
+
+		is, err := reconcileAccount(c, *a)
+		if err != nil {
+			return errors.Wrap(err, "reconciling account")
+		}
+
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
+		// the recorded adjustments are the result when an adjustment account
+		// is given, so the reconciliation only gives context to them
+		adjust := cmd.Flags().Changed(keyAdjustmentAccount)
+		w := io.Writer(os.Stdout)
+		if adjust {
+			w = contextWriter()
+		}
+		if err := table.Reconciliation(is, w, output); err != nil {
+			return errors.Wrap(err, "printing reconciliation")
+		}
+
+		unreconciled := reconcile.Unreconciled(is)
+		infof("%d of %d intervals have unexplained differences\n", len(unreconciled), len(is))
+		if !adjust {
+			return nil
+		}
+
+		adjustmentID := uint(viper.GetInt(keyAdjustmentAccount))
+		adjustments, unadjusted := reconcile.Adjustments(a.ID, adjustmentID, is)
+		for _, i := range unadjusted {
+			infof("The change of %d between the balances on %s cannot be adjusted, as no balance was recorded before that date\n", i.Unexplained(), i.ToDate())
+		}
+		var inserted storage.Transactions
+		for _, t := range adjustments {
+			i, err := c.InsertTransaction(t)
+			if err != nil {
+				return errors.Wrapf(err, "inserting adjustment transaction %+v", t)
+			}
+			inserted = append(inserted, *i)
+		}
+		infof("Adjustments:\n")
+		return errors.Wrap(table.Transactions(inserted, os.Stdout, output), "printing transactions")
+	},
+}
+
+// reconcileAccount reconciles the balances of an Account with the
+// transactions of the Account.
+func reconcileAccount(store storage.Storage, a storage.Account) ([]reconcile.Interval, error) {
+	bs, err := store.SelectAccountBalances(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting account balances")
+	}
+	ts, err := store.SelectAccountTransactions(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting account transactions")
+	}
+	return reconcile.Account(a.ID, *bs, *ts), nil
+}
+
 func accountBalanceAtTime(store storage.Storage, a storage.Account, at time.Time) (balance.Balance, error) {
 	bs, err := store.SelectAccountBalances(a)
 	if err != nil {
@@ -458,10 +682,10 @@ func accountBalanceAtTime(store storage.Storage, a storage.Account, at time.Time
 	}
 	bbs := bs.InnerBalances()
 	if len(*bs) == 0 {
-		return balance.Balance{}, errors.Wrapf(err, "no balances for account:%+v", a)
+		return balance.Balance{}, errors.Errorf("no balances for account:%+v", a)
 	}
 	b, err := bbs.AtTime(at)
-	return b, errors.Wrapf(err, "getting balance at time from returned balances", at, a)
+	return b, errors.Wrapf(err, "getting balance at time:%+v from returned balances", at)
 }
 
 func init() {
@@ -470,10 +694,6 @@ func init() {
 	// TODO: retrieve them. The issue doesn't happen with custom flags that are
 	// TODO: retrieved using a global variable
 	accountCmd.PersistentFlags().String(keyCurrency, "", "account currency")
-	err := viper.BindPFlags(accountCmd.PersistentFlags())
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "binding pflags"))
-	}
 	rootCmd.AddCommand(accountCmd)
 
 	accountAddCmd.Flags().VarP(accountOpened, keyOpened, "o", "account opened date")
@@ -489,7 +709,9 @@ func init() {
 	accountUpdateCmd.Flags().VarP(accountOpened, keyOpened, "o", "account opened date")
 	accountUpdateCmd.Flags().VarP(accountClosed, keyClosed, "c", "account closed date")
 
-	accountBalancesCmd.Flags().UintP(keyLimit, "l", 0, "limit results")
+	accountBalancesCmd.Flags().UintP(keyLimit, "l", 0, "limit results to the latest balances")
+	accountBalancesCmd.Flags().Var(balancesFrom, keyFrom, "show only balances on or after this date")
+	accountBalancesCmd.Flags().Var(balancesTo, keyTo, "show only balances on or before this date")
 
 	// TODO: Stop multiple usage of the flag like in this article: http://blog.ralch.com/tutorial/golang-custom-flags/
 	accountBalanceInsertCmd.Flags().VarP(balanceDate, keyDate, "d", "date of balance to insert")
@@ -497,6 +719,13 @@ func init() {
 
 	accountBalanceCmd.Flags().VarP(balanceDate, keyDate, "d", "date at which to retrieve balance")
 
+	accountReconcileCmd.Flags().Uint(keyAdjustmentAccount, 0, "record adjustments against the account with this id")
+
+	accountShareCmd.Flags().String(keyAccess, string(storage.AccessRead), "access to give the user, one of read, write or owner")
+
+	accountBalanceUpdateCmd.Flags().VarP(balanceDate, keyDate, "d", "updated date of balance")
+	accountBalanceUpdateCmd.Flags().IntP(keyAmount, "a", 0, "updated amount of balance")
+
 	for _, c := range []*cobra.Command{
 		accountAddCmd,
 		accountOpenCmd,
@@ -508,11 +737,11 @@ func init() {
 		accountBalancesCmd,
 		accountBalanceInsertCmd,
 		accountBalanceCmd,
+		accountBalanceUpdateCmd,
+		accountBalanceDeleteCmd,
+		accountReconcileCmd,
+		accountShareCmd,
 	} {
-		err := viper.BindPFlags(c.Flags())
-		if err != nil {
-			log.Fatal(errors.Wrap(err, "binding pflags"))
-		}
 		accountCmd.AddCommand(c)
 	}
 }
@@ -2,11 +2,10 @@ package cmd
 
 import (
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/glynternet/go-accounting/balance"
 	"github.com/glynternet/go-money/currency"
@@ -14,7 +13,6 @@ import (
 	"github.com/glynternet/mon/internal/client"
 	"github.com/glynternet/mon/internal/sort"
 	"github.com/glynternet/mon/pkg/date"
-	"github.com/glynternet/mon/pkg/filter"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/glynternet/mon/pkg/table"
 	"github.com/pkg/errors"
@@ -30,6 +28,7 @@ const (
 	keyQuiet      = "quiet"
 	keyAtDate     = "at-date"
 	keySortBy     = "sort-by"
+	keyIn         = "in"
 )
 
 var (
@@ -43,12 +42,17 @@ var accountsCmd = &cobra.Command{
 	Use:  "accounts",
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if atDate.Time == nil {
-			now := time.Now()
-			atDate.Time = &now
+		tmpl, err := outputTemplate()
+		if err != nil {
+			return errors.Wrap(err, "preparing output template")
+		}
+
+		if atDate.Date == nil {
+			today := date.Today()
+			atDate.Date = &today
 		}
 
-		c := client.Client(viper.GetString(keyServerHost))
+		c := newClient()
 		as, err := accounts(c)
 		if err != nil {
 			return errors.Wrap(err, "getting accounts")
@@ -61,8 +65,11 @@ var accountsCmd = &cobra.Command{
 			return nil
 		}
 
-		table.Accounts(as, os.Stdout)
-		return nil
+		if tmpl != nil {
+			return errors.Wrap(table.Template(tmpl, table.AccountRows(as), os.Stdout), "printing accounts with template")
+		}
+
+		return errors.Wrap(table.Accounts(as, os.Stdout, output), "printing accounts")
 	},
 }
 
@@ -70,23 +77,36 @@ var accountsBalancesCmd = &cobra.Command{
 	Use:  "balances",
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if atDate.Time == nil {
-			now := time.Now()
-			atDate.Time = &now
+		tmpl, err := outputTemplate()
+		if err != nil {
+			return errors.Wrap(err, "preparing output template")
 		}
 
-		c := client.Client(viper.GetString(keyServerHost))
-		as, err := accounts(c)
-		if err != nil {
-			return errors.Wrap(err, "getting accounts")
+		if atDate.Date == nil {
+			today := date.Today()
+			atDate.Date = &today
 		}
 
-		abs, err := accountsBalances(c, as, *atDate.Time)
+		c := newClient()
+		abs, err := accountsBalances(c, *atDate.Date)
 		if err != nil {
 			return errors.Wrap(err, "getting balances for all accounts")
 		}
 
-		table.AccountsWithBalance(abs, os.Stdout)
+		if tmpl != nil {
+			return errors.Wrap(table.Template(tmpl, table.AccountsWithBalanceRows(abs), os.Stdout), "printing accounts with balance with template")
+		}
+
+		// the converted balances are the result when a currency is given, so
+		// the balances only give context to them
+		in := strings.ToUpper(viper.GetString(keyIn))
+		w := io.Writer(os.Stdout)
+		if in != "" {
+			w = contextWriter()
+		}
+		if err := table.AccountsWithBalance(abs, w, output); err != nil {
+			return errors.Wrap(err, "printing accounts with balance")
+		}
 
 		cbs := currencyBalances(abs)
 		if len(cbs) == 0 {
@@ -97,42 +117,77 @@ var accountsBalancesCmd = &cobra.Command{
 		for crncy, bs := range cbs {
 			totals = append(totals, []string{crncy.String(), strconv.Itoa(bs.Sum())})
 		}
-		return errors.Wrap(table.Basic(totals, os.Stdout), "printing basic table for totals")
+		err = table.Basic(totals, contextWriter(), output)
+		if err != nil {
+			return errors.Wrap(err, "printing basic table for totals")
+		}
+
+		if in == "" {
+			return nil
+		}
+		rs, err := c.SelectRates()
+		if err != nil {
+			return errors.Wrap(err, "selecting rates")
+		}
+		converted, err := convertedBalances(abs, *rs, in, *atDate.Date)
+		if err != nil {
+			return errors.Wrapf(err, "converting balances into %s", in)
+		}
+		return errors.Wrap(table.Basic(converted, os.Stdout, output), "printing basic table for converted balances")
 	},
 }
 
-func accounts(store storage.Storage) (storage.Accounts, error) {
-	as, err := store.SelectAccounts()
-	if err != nil {
-		return nil, errors.Wrap(err, "selecting accounts")
+// convertedBalances returns a row for each AccountBalance with its amount
+// converted into the given currency using the Rates on the given date,
+// followed by a row holding the total of the converted amounts.
+func convertedBalances(abs []accountbalance.AccountBalance, rs storage.Rates, to string, at date.Date) ([][]string, error) {
+	rows := [][]string{{"ID", "Name", "Currency", "Amount", "Rate", "Amount (" + to + ")"}}
+	var total int
+	for _, ab := range abs {
+		from := ab.Account.Account.CurrencyCode().String()
+		r, err := rs.At(from, to, at)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting rate for account with id %d", ab.Account.ID)
+		}
+		converted, err := rs.Convert(ab.Amount, from, to, at)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting balance for account with id %d", ab.Account.ID)
+		}
+		total += converted
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(ab.Account.ID), 10),
+			ab.Account.Account.Name(),
+			from,
+			strconv.Itoa(ab.Amount),
+			strconv.FormatFloat(r, 'f', -1, 64),
+			strconv.Itoa(converted),
+		})
 	}
+	return append(rows, []string{"", "Total", "", "", "", strconv.Itoa(total)}), nil
+}
 
-	ac, err := prepareAccountCondition()
+func accounts(c client.Client) (storage.Accounts, error) {
+	o, err := accountsOptions()
 	if err != nil {
-		return nil, errors.Wrap(err, "preparing conditions")
+		return nil, errors.Wrap(err, "preparing options")
 	}
 
-	*as = ac.Filter(*as)
-
-	if s, ok := sort.AccountSorts()[sortBy.String()]; ok {
-		s(*as)
+	as, err := c.SelectAccountsWithOptions(o)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting accounts")
 	}
-
 	return *as, nil
 }
 
-func accountsBalances(s storage.Storage, as storage.Accounts, at time.Time) ([]accountbalance.AccountBalance, error) {
-	var abs []accountbalance.AccountBalance
-	for _, a := range as {
-		b, err := accountBalanceAtTime(s, a, at)
-		if err != nil {
-			log.Println(errors.Wrapf(err, "getting balance at time:%+v for account:%+v", at, a))
-			continue
-		}
-		abs = append(abs, accountbalance.AccountBalance{
-			Account: a,
-			Balance: b,
-		})
+func accountsBalances(c client.Client, at date.Date) ([]accountbalance.AccountBalance, error) {
+	o, err := accountsOptions()
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing options")
+	}
+
+	abs, err := c.SelectAccountsBalances(at, o)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting accounts balances")
 	}
 
 	if s, ok := sort.AccountbalanceSorts()[sortBy.String()]; ok {
@@ -154,53 +209,32 @@ func currencyBalances(abs []accountbalance.AccountBalance) map[currency.Code]bal
 	return cbs
 }
 
-func prepareAccountCondition() (filter.AccountCondition, error) {
-	cs := filter.AccountConditions{
-		filter.Existed(*atDate.Time),
+// accountsOptions returns the client.AccountsOptions for the filtering and
+// sorting flags. Accounts are sorted by the server unless the sort key is for
+// balances, which are sorted once the balances have been retrieved.
+func accountsOptions() (client.AccountsOptions, error) {
+	o := client.AccountsOptions{
+		ExistedAt: atDate.Date,
+		IDs:       ids,
+		NotIDs:    notIDs,
 	}
 
 	if viper.GetBool(keyOpen) {
-		cs = append(cs, filter.OpenAt(*atDate.Time))
-	}
-
-	if len(ids) > 0 {
-		cs = append(cs, idsCondition(ids))
-	}
-
-	if len(notIDs) > 0 {
-		cs = append(cs, filter.Not(idsCondition(notIDs)))
+		o.OpenAt = atDate.Date
 	}
 
 	if len(currencies) > 0 {
-		ccs, err := currenciesCondition(currencies)
+		ccs, err := currencyStringsToCodes(currencies...)
 		if err != nil {
-			return nil, errors.Wrap(err, "creating currencies condition")
+			return client.AccountsOptions{}, errors.Wrap(err, "converting currency strings to currency codes")
 		}
-		cs = append(cs, ccs)
+		o.Currencies = ccs
 	}
 
-	// Account must meet all AccountConditions
-	return cs.And, nil
-}
-
-func idsCondition(ids []uint) filter.AccountCondition {
-	var cs filter.AccountConditions
-	for _, id := range ids {
-		cs = append(cs, filter.ID(id))
-	}
-	return cs.Or
-}
-
-func currenciesCondition(cs []string) (filter.AccountCondition, error) {
-	ccs, err := currencyStringsToCodes(cs...)
-	if err != nil {
-		return nil, errors.Wrap(err, "converting currency string to currency codes")
-	}
-	var acs filter.AccountConditions
-	for _, c := range ccs {
-		acs = append(acs, filter.Currency(c))
+	if _, ok := sort.AccountSorts()[sortBy.String()]; ok {
+		o.Sort = sortBy.String()
 	}
-	return acs.Or, nil
+	return o, nil
 }
 
 // TODO: this should be handled as a flags type perhaps?
@@ -227,24 +261,7 @@ func init() {
 	sortByKeys := strings.Join(sort.AllKeys(), ",")
 	accountsCmd.PersistentFlags().Var(sortBy, keySortBy, fmt.Sprintf("sort by one of %s", sortByKeys))
 
-	accountsCmd.AddCommand(accountsBalancesCmd)
+	accountsBalancesCmd.Flags().String(keyIn, "", "also show the balances converted into this currency, with their total")
 
-	for _, cc := range []*cobra.Command{
-		accountsCmd, accountsBalancesCmd,
-	} {
-		err := bindAllFlags(cc)
-		if err != nil {
-			log.Fatal(errors.Wrapf(err, "binding command:[%s] flags", cc.Use))
-		}
-	}
-}
-
-func bindAllFlags(c *cobra.Command) error {
-	if err := viper.BindPFlags(c.Flags()); err != nil {
-		return errors.Wrapf(err, "binding local pflags")
-	}
-	if err := viper.BindPFlags(c.PersistentFlags()); err != nil {
-		return errors.Wrapf(err, "binding persistent pflags")
-	}
-	return nil
+	accountsCmd.AddCommand(accountsBalancesCmd)
 }
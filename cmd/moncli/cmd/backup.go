@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/glynternet/mon/pkg/plaintext"
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyFile   = "file"
+	keyFormat = "format"
+
+	exportFormatJSON      = "json"
+	exportFormatBeancount = "beancount"
+	exportFormatLedger    = "ledger"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export every account, balance, transaction and rate",
+	Long: `export the whole of the server's storage. The default json format is a
+versioned document, including deleted accounts, that can be restored into an
+empty server with the restore command. The beancount and ledger formats write
+the accounts that have not been deleted, with their balances as balance
+assertions, as a beancount file or an hledger and ledger journal. The export
+is written to stdout unless a file is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := viper.GetString(keyFile)
+		format := viper.GetString(keyFormat)
+		decimalPlaces := viper.GetInt(keyDecimalPlaces)
+		d, err := newClient().Export()
+		if err != nil {
+			return errors.Wrap(err, "exporting")
+		}
+		data, err := formatExport(*d, format, decimalPlaces)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			_, err = os.Stdout.Write(data)
+			return errors.Wrap(err, "writing document")
+		}
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			return errors.Wrap(err, "writing document")
+		}
+		return errors.Wrap(table.BackupSummary(d.Summary(), os.Stdout, output), "printing backup summary")
+	},
+}
+
+// formatExport returns the Document written in the given format
+func formatExport(d backup.Document, format string, decimalPlaces int) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case exportFormatJSON:
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling document")
+		}
+		return append(data, '\n'), nil
+	case exportFormatBeancount:
+		err := plaintext.Beancount(&buf, d, decimalPlaces)
+		return buf.Bytes(), errors.Wrap(err, "writing beancount")
+	case exportFormatLedger:
+		err := plaintext.Ledger(&buf, d, decimalPlaces)
+		return buf.Bytes(), errors.Wrap(err, "writing ledger journal")
+	}
+	return nil, fmt.Errorf("unsupported format %q, must be one of %s, %s or %s", format, exportFormatJSON, exportFormatBeancount, exportFormatLedger)
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [FILE]",
+	Short: "restore an exported json document into an empty server",
+	Long: `restore a json document that was written by the export command into a
+server that holds no accounts or rates. Use - as FILE to read from stdin.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return errors.Wrap(err, "opening document")
+			}
+			defer func() {
+				if cErr := f.Close(); cErr != nil {
+					fmt.Fprintln(os.Stderr, errors.Wrap(cErr, "closing document"))
+				}
+			}()
+			r = f
+		}
+		var d backup.Document
+		if err := json.NewDecoder(r).Decode(&d); err != nil {
+			return errors.Wrap(err, "decoding document")
+		}
+		s, err := newClient().Restore(d)
+		if err != nil {
+			return errors.Wrap(err, "restoring")
+		}
+		return errors.Wrap(table.BackupSummary(*s, os.Stdout, output), "printing backup summary")
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringP(keyFile, "f", "", "file to write the export to")
+	exportCmd.Flags().String(keyFormat, exportFormatJSON, "export format, one of json, beancount or ledger")
+	exportCmd.Flags().Int(keyDecimalPlaces, 2, "number of decimal places of the currencies, for the beancount and ledger formats")
+	rootCmd.AddCommand(exportCmd, restoreCmd)
+}
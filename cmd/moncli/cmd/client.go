@@ -1,10 +1,82 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/glynternet/mon/internal/client"
+	"github.com/glynternet/mon/internal/tlsconfig"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
+// httpClient is the http.Client that requests are sent with, which is set
+// from the timeout and TLS flags before any command is run.
+var httpClient *http.Client
+
+// initHTTPClient sets httpClient from the timeout and TLS flags. The client
+// uses a copy of http.DefaultTransport so that proxies from the environment,
+// the default dial and handshake timeouts and HTTP/2 are kept when TLS is
+// configured.
+func initHTTPClient() error {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	ca, cert, key := viper.GetString(keyTLSCA), viper.GetString(keyTLSCert), viper.GetString(keyTLSKey)
+	if ca != "" || cert != "" || key != "" {
+		c, err := tlsconfig.Client(ca, cert, key)
+		if err != nil {
+			return err
+		}
+		t.TLSClientConfig = c
+	}
+	httpClient = &http.Client{
+		Transport: t,
+		Timeout:   viper.GetDuration(keyTimeout),
+	}
+	return nil
+}
+
 func newClient() client.Client {
-	return client.Client(viper.GetString(keyServerHost))
+	return client.Client{
+		Host:       viper.GetString(keyServerHost),
+		Token:      viper.GetString(keyToken),
+		HTTPClient: httpClient,
+	}
+}
+
+// errorMessage returns the message to print for an error that stopped a
+// command. An error response from the mon server is described along with any
+// fields that failed validation and the ID of the request.
+func errorMessage(err error) string {
+	var kind string
+	var re *client.ResponseError
+	switch e := errors.Cause(err).(type) {
+	case client.NotFoundError:
+		kind, re = "not found", e.ResponseError
+	case client.ValidationError:
+		kind, re = "invalid request", e.ResponseError
+	case client.UnauthorizedError:
+		kind, re = "unauthorized", e.ResponseError
+	case client.ForbiddenError:
+		kind, re = "forbidden", e.ResponseError
+	case client.UnavailableError:
+		kind, re = "server unavailable", e.ResponseError
+	case *client.ResponseError:
+		kind, re = "server error", e
+	default:
+		return err.Error()
+	}
+	msg := re.Message
+	if msg == "" {
+		msg = http.StatusText(re.StatusCode)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s", kind, msg)
+	for _, f := range re.Fields {
+		fmt.Fprintf(&b, "\n  - %s", f)
+	}
+	if re.RequestID != "" {
+		fmt.Fprintf(&b, "\nrequest ID: %s", re.RequestID)
+	}
+	return b.String()
 }
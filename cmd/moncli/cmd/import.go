@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/importer"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyDateColumn       = "date-column"
+	keyAmountColumn     = "amount-column"
+	keyHeader           = "header"
+	keyDelimiter        = "delimiter"
+	keyDateFormat       = "date-format"
+	keyDecimalSeparator = "decimal-separator"
+	keyDecimalPlaces    = "decimal-places"
+	keyDryRun           = "dry-run"
+	keyCreate           = "create"
+	keyMappingFile      = "mapping-file"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import balances from bank statements",
+}
+
+var importCSVCmd = &cobra.Command{
+	Use:   "csv [FILE]",
+	Short: "import balances from a CSV statement",
+	Long: `import the balances of a CSV statement into an account.
+Each row of the statement gives a balance. The columns holding the date and
+amount of each balance are given either by their names in the header row or
+by their zero-based index. Balances with the same date and amount as a balance
+that is already recorded for the account are skipped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		m, err := csvMapping()
+		if err != nil {
+			return err
+		}
+
+		c := newClient()
+		a, err := importAccount(c)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return errors.Wrap(err, "opening statement")
+		}
+		defer func() {
+			if cErr := f.Close(); cErr != nil {
+				fmt.Fprintln(os.Stderr, errors.Wrap(cErr, "closing statement"))
+			}
+		}()
+		bs, err := importer.CSV(f, m)
+		if err != nil {
+			return errors.Wrap(err, "reading statement")
+		}
+		return importBalances(c, *a, bs, viper.GetBool(keyDryRun))
+	},
+}
+
+var importOFXCmd = &cobra.Command{
+	Use:   "ofx [FILE]",
+	Short: "import ledger balances from an OFX or QFX statement",
+	Long: `import the ledger balance of each statement in an OFX or QFX file.
+The account of each statement is mapped to an account by a mapping file. An
+account can be mapped by giving it with --account, or an account can be
+created with the name and currency of the statement with --create. Once
+mapped, the mapping is saved so that later statements of the same account are
+imported into the same account.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		decimalPlaces := viper.GetInt(keyDecimalPlaces)
+		dryRun := viper.GetBool(keyDryRun)
+		create := viper.GetBool(keyCreate)
+		name := viper.GetString(keyName)
+		mappingFile, err := ofxMappingFile()
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(args[0])
+		if err != nil {
+			return errors.Wrap(err, "opening statement")
+		}
+		defer func() {
+			if cErr := f.Close(); cErr != nil {
+				fmt.Fprintln(os.Stderr, errors.Wrap(cErr, "closing statement"))
+			}
+		}()
+		ss, err := importer.OFX(f, decimalPlaces)
+		if err != nil {
+			return errors.Wrap(err, "reading statement")
+		}
+
+		m, err := importer.LoadAccountMapping(mappingFile)
+		if err != nil {
+			return err
+		}
+
+		c := newClient()
+		var mapped *storage.Account
+		if cmd.Flags().Changed(keyAccount) {
+			if len(ss) > 1 {
+				return fmt.Errorf("the file holds %d statements, so accounts must be mapped with the mapping file or created with --%s", len(ss), keyCreate)
+			}
+			if mapped, err = importAccount(c); err != nil {
+				return err
+			}
+		}
+		if create && name != "" && mapped == nil {
+			if n := len(unmappedAccountKeys(ss, m)); n > 1 {
+				return fmt.Errorf("the file holds %d statement accounts that are not mapped, so --%s cannot name them all", n, keyName)
+			}
+		}
+
+		for _, s := range ss {
+			key := s.AccountKey()
+			a := mapped
+			if id, ok := m[key]; ok && a == nil {
+				if a, err = c.SelectAccount(id); err != nil {
+					return errors.Wrapf(err, "selecting account %d mapped to statement account %s", id, key)
+				}
+			}
+			if a == nil {
+				if !create {
+					return fmt.Errorf("no account is mapped to statement account %s, use --%s or --%s", key, keyAccount, keyCreate)
+				}
+				accountName := name
+				if accountName == "" {
+					accountName = key
+				}
+				if dryRun {
+					infof("Would create %s account %q for statement account %s and insert:\n", s.Currency, accountName, key)
+					if err := table.Balances(unstoredBalances([]balance.Balance{s.Balance}), os.Stdout, output); err != nil {
+						return errors.Wrap(err, "printing balances")
+					}
+					continue
+				}
+				if a, err = createStatementAccount(c, accountName, s); err != nil {
+					return errors.Wrapf(err, "creating account for statement account %s", key)
+				}
+			}
+			if a.Account.CurrencyCode().String() != s.Currency {
+				return fmt.Errorf("statement account %s is in %s but account %d is in %s", key, s.Currency, a.ID, a.Account.CurrencyCode())
+			}
+			if !dryRun && m[key] != a.ID {
+				m[key] = a.ID
+				if err := m.Save(mappingFile); err != nil {
+					return errors.Wrap(err, "saving account mapping")
+				}
+			}
+			if err := importBalances(c, *a, []balance.Balance{s.Balance}, dryRun); err != nil {
+				return errors.Wrapf(err, "importing statement account %s", key)
+			}
+		}
+		return nil
+	},
+}
+
+// unmappedAccountKeys returns the distinct account keys of the statements
+// that are not in the mapping.
+func unmappedAccountKeys(ss []importer.OFXStatement, m importer.AccountMapping) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, s := range ss {
+		key := s.AccountKey()
+		if _, ok := m[key]; ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ofxMappingFile returns the path of the account mapping file, which is
+// within the home directory of the user if not given by the mapping-file flag.
+func ofxMappingFile() (string, error) {
+	if path := viper.GetString(keyMappingFile); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrapf(err, "finding home directory for default mapping file, use --%s instead", keyMappingFile)
+	}
+	return filepath.Join(home, "."+appName, "ofx-accounts.json"), nil
+}
+
+// createStatementAccount inserts an Account with the given name in the
+// currency of the statement, opened on the date of the ledger balance.
+func createStatementAccount(store storage.Storage, name string, s importer.OFXStatement) (*storage.Account, error) {
+	cc, err := currency.NewCode(s.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating currency code")
+	}
+	a, err := account.New(name, *cc, s.Balance.Date)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating new account for insert")
+	}
+	i, err := store.InsertAccount(*a)
+	return i, errors.Wrap(err, "inserting new account")
+}
+
+// csvMapping returns the importer.CSVMapping given by the flags of the
+// command.
+func csvMapping() (importer.CSVMapping, error) {
+	m := importer.CSVMapping{
+		DateColumn:    viper.GetString(keyDateColumn),
+		AmountColumn:  viper.GetString(keyAmountColumn),
+		Header:        viper.GetBool(keyHeader),
+		DateFormat:    viper.GetString(keyDateFormat),
+		DecimalPlaces: viper.GetInt(keyDecimalPlaces),
+	}
+	var err error
+	if m.Delimiter, err = runeFlag(keyDelimiter); err != nil {
+		return m, err
+	}
+	if m.DecimalSeparator, err = runeFlag(keyDecimalSeparator); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// runeFlag returns the value of a string flag that must hold a single
+// character.
+func runeFlag(name string) (rune, error) {
+	s := viper.GetString(name)
+	if s == `\t` {
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, fmt.Errorf("%s must be a single character but was %q", name, s)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+// importAccount returns the Account given by the account flag of the command,
+// which may hold either the id of the Account or its name. An error is
+// returned if a name is given that does not belong to exactly one Account.
+func importAccount(store storage.Storage) (*storage.Account, error) {
+	ref := viper.GetString(keyAccount)
+	if ref == "" {
+		return nil, errors.New("an account must be given by id or name")
+	}
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		a, err := store.SelectAccount(uint(id))
+		return a, errors.Wrap(err, "selecting account")
+	}
+	as, err := store.SelectAccounts()
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting accounts")
+	}
+	var matches storage.Accounts
+	for _, a := range *as {
+		if a.Account.Name() == ref {
+			matches = append(matches, a)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no account with name %q", ref)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("%d accounts have the name %q, use the account id instead", len(matches), ref)
+}
+
+// importBalances inserts the imported Balances that are not duplicates of
+// those already recorded for the Account. Every Balance is validated against
+// the Account before any are inserted. If dryRun is true, the Balances that
+// would be inserted are printed without inserting them.
+func importBalances(store storage.Storage, a storage.Account, imported []balance.Balance, dryRun bool) error {
+	existing, err := store.SelectAccountBalances(a)
+	if err != nil {
+		return errors.Wrap(err, "selecting account balances")
+	}
+	inserts, duplicates := importer.NewBalances(*existing, imported)
+	for _, b := range inserts {
+		if err := a.Account.ValidateBalance(b); err != nil {
+			return errors.Wrapf(err, "validating balance of %d on %s", b.Amount, date.FromTime(b.Date))
+		}
+	}
+
+	if err := table.Accounts(storage.Accounts{a}, contextWriter(), output); err != nil {
+		return errors.Wrap(err, "printing accounts")
+	}
+	if len(duplicates) > 0 {
+		infof("Skipping %d duplicate balance(s):\n", len(duplicates))
+		if err := table.Balances(unstoredBalances(duplicates), contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing balances")
+		}
+	}
+	if dryRun {
+		infof("Would insert %d balance(s):\n", len(inserts))
+		return errors.Wrap(table.Balances(unstoredBalances(inserts), os.Stdout, output), "printing balances")
+	}
+
+	var inserted storage.Balances
+	for _, b := range inserts {
+		sb, err := store.InsertBalance(a, b)
+		if err != nil {
+			return errors.Wrapf(err, "inserting balance of %d on %s after inserting %d balance(s)", b.Amount, date.FromTime(b.Date), len(inserted))
+		}
+		inserted = append(inserted, *sb)
+	}
+	infof("Inserted %d balance(s):\n", len(inserted))
+	return errors.Wrap(table.Balances(inserted, os.Stdout, output), "printing balances")
+}
+
+// unstoredBalances wraps Balances that have not been stored so that they can
+// be printed as a table.
+func unstoredBalances(bs []balance.Balance) storage.Balances {
+	sbs := make(storage.Balances, len(bs))
+	for i, b := range bs {
+		sbs[i] = storage.Balance{Balance: b}
+	}
+	return sbs
+}
+
+func init() {
+	importCSVCmd.Flags().String(keyAccount, "", "id or name of the account to import into")
+	importCSVCmd.Flags().String(keyDateColumn, "Date", "name or zero-based index of the date column")
+	importCSVCmd.Flags().String(keyAmountColumn, "Balance", "name or zero-based index of the amount column")
+	importCSVCmd.Flags().Bool(keyHeader, true, "first row of the statement is a header row")
+	importCSVCmd.Flags().String(keyDelimiter, ",", `field delimiter, \t for tab`)
+	importCSVCmd.Flags().String(keyDateFormat, "2006-01-02", "layout of dates, as used by go's time.Parse")
+	importCSVCmd.Flags().String(keyDecimalSeparator, ".", "decimal separator of amounts, either . or ,")
+	importCSVCmd.Flags().Int(keyDecimalPlaces, 2, "number of decimal places of the currency, 0 for amounts already in minor units")
+	importCSVCmd.Flags().Bool(keyDryRun, false, "show the balances that would be imported without inserting them")
+
+	importOFXCmd.Flags().String(keyAccount, "", "id or name of the account to map the statement account to")
+	importOFXCmd.Flags().Bool(keyCreate, false, "create an account for any statement account that is not mapped")
+	importOFXCmd.Flags().String(keyName, "", "name of the created account when a single statement account is not mapped, defaults to the statement account")
+	importOFXCmd.Flags().String(keyMappingFile, "", "account mapping file, defaults to ~/."+appName+"/ofx-accounts.json")
+	importOFXCmd.Flags().Int(keyDecimalPlaces, 2, "number of decimal places of the currency")
+	importOFXCmd.Flags().Bool(keyDryRun, false, "show the balances that would be imported without inserting them or changing the mapping")
+
+	importCmd.AddCommand(importCSVCmd, importOFXCmd)
+	rootCmd.AddCommand(importCmd)
+}
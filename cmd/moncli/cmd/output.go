@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyTemplate     = "template"
+	keyTemplateFile = "template-file"
+)
+
+// infoWriter returns the io.Writer that informational messages are written
+// to. Messages are written to stderr for the machine readable output formats
+// so that stdout only holds the formatted output.
+func infoWriter() io.Writer {
+	if output == table.Table {
+		return os.Stdout
+	}
+	return os.Stderr
+}
+
+// infof writes an informational message
+func infof(format string, a ...interface{}) {
+	fmt.Fprintf(infoWriter(), format, a...)
+}
+
+// contextWriter returns the io.Writer that tables which only give context to
+// the result of a command are written to, such as the Account that listed
+// Balances belong to. Context is discarded for the machine readable output
+// formats so that stdout only holds the result of the command.
+func contextWriter() io.Writer {
+	if output == table.Table {
+		return os.Stdout
+	}
+	return ioutil.Discard
+}
+
+// outputTemplate returns the template given to a command with the template or
+// template-file flags, or nil when neither flag has been given.
+func outputTemplate() (*template.Template, error) {
+	text, file := viper.GetString(keyTemplate), viper.GetString(keyTemplateFile)
+	switch {
+	case text != "" && file != "":
+		return nil, fmt.Errorf("only one of %s and %s can be given", keyTemplate, keyTemplateFile)
+	case file != "":
+		bs, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading template file %s", file)
+		}
+		text = strings.TrimSuffix(string(bs), "\n")
+	case text == "":
+		return nil, nil
+	}
+	return table.NewTemplate(text)
+}
+
+func init() {
+	for _, cc := range []*cobra.Command{
+		accountsCmd, accountsBalancesCmd, accountCmd, accountBalancesCmd,
+	} {
+		cc.Flags().String(keyTemplate, "", "write each result with a Go template, such as '{{.ID}} {{.Account.Name}}', instead of a table")
+		cc.Flags().String(keyTemplateFile, "", "write each result with the Go template in this file, instead of a table")
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var rateDate = date.Flag()
+
+var rateCmd = &cobra.Command{
+	Use:   "rate",
+	Short: "record and list exchange rates between currencies",
+}
+
+var rateAddCmd = &cobra.Command{
+	Use:   "add [FROM] [TO] [RATE]",
+	Short: "record an exchange rate",
+	Long: `record the exchange rate from one currency to another on a date.
+An amount of the FROM currency multiplied by RATE gives the equivalent amount
+of the TO currency.`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rate, err := strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			return errors.Wrap(err, "parsing rate")
+		}
+
+		d := date.Today()
+		if rateDate.Date != nil {
+			d = *rateDate.Date
+		}
+
+		r, err := newClient().InsertRate(storage.Rate{
+			Date: d,
+			From: strings.ToUpper(args[0]),
+			To:   strings.ToUpper(args[1]),
+			Rate: rate,
+		})
+		if err != nil {
+			return errors.Wrap(err, "inserting rate")
+		}
+		return errors.Wrap(table.Rates(storage.Rates{*r}, os.Stdout, output), "printing rates")
+	},
+}
+
+var rateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list exchange rates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rs, err := newClient().SelectRates()
+		if err != nil {
+			return errors.Wrap(err, "selecting rates")
+		}
+		return errors.Wrap(table.Rates(*rs, os.Stdout, output), "printing rates")
+	},
+}
+
+func init() {
+	rateAddCmd.Flags().VarP(rateDate, keyDate, "d", "date of rate")
+
+	rateCmd.AddCommand(rateAddCmd, rateListCmd)
+	rootCmd.AddCommand(rateCmd)
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/report"
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyFrom     = "from"
+	keyTo       = "to"
+	keyInterval = "interval"
+)
+
+var (
+	reportFrom = date.Flag()
+	reportTo   = date.Flag()
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "generate reports over all accounts",
+}
+
+var reportNetWorthCmd = &cobra.Command{
+	Use:   "networth",
+	Short: "show the total balance of each currency over a range of dates",
+	Long: `networth shows the total balance of all accounts of each currency at each date
+from the from date up to and including the to date, separated by the interval.
+Monthly dates fall on the same day of the month as the from date, or on the
+last day of the month for months that are too short.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reportFrom.Date == nil {
+			return errors.New("from date must be provided")
+		}
+		to := date.Today()
+		if reportTo.Date != nil {
+			to = *reportTo.Date
+		}
+		i, err := report.ParseInterval(viper.GetString(keyInterval))
+		if err != nil {
+			return errors.Wrap(err, "parsing interval")
+		}
+
+		ss, err := newClient().NetWorth(*reportFrom.Date, to, i)
+		if err != nil {
+			return errors.Wrap(err, "getting net worth report")
+		}
+		return errors.Wrap(table.NetWorth(ss, os.Stdout, output), "printing net worth")
+	},
+}
+
+func init() {
+	reportNetWorthCmd.Flags().Var(reportFrom, keyFrom, "first date of the report")
+	reportNetWorthCmd.Flags().Var(reportTo, keyTo, "last date of the report, today by default")
+	reportNetWorthCmd.Flags().String(keyInterval, string(report.Monthly), "interval between dates, one of daily, weekly or monthly")
+
+	reportCmd.AddCommand(reportNetWorthCmd)
+	rootCmd.AddCommand(reportCmd)
+}
@@ -5,7 +5,9 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/glynternet/mon/pkg/table"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -15,16 +17,42 @@ const (
 	appName = "moncli"
 
 	keyServerHost = "server-host"
+	keyOutput     = "output"
+	keyToken      = "token"
+	keyTimeout    = "timeout"
+	keyTLSCA      = "tls-ca"
+	keyTLSCert    = "tls-cert"
+	keyTLSKey     = "tls-key"
 )
 
+// output is the format that the output of a command is written in, which is
+// set from the output flag before any command is run.
+var output = table.Table
+
 var rootCmd = &cobra.Command{
 	Use: appName,
+	// errors are printed by Execute, using errorMessage
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Only the flags of the command that is run are bound, as commands
+		// share keys, such as amount and date, and viper only reads the flag
+		// that was bound last for each key.
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return errors.Wrap(err, "binding flags")
+		}
+		f, err := table.ParseFormat(viper.GetString(keyOutput))
+		if err != nil {
+			return err
+		}
+		output = f
+		return errors.Wrap(initHTTPClient(), "configuring TLS")
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, errorMessage(err))
 		os.Exit(1)
 	}
 }
@@ -32,9 +60,22 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringP(keyServerHost, "H", "", "server host")
-	err := viper.BindPFlags(rootCmd.PersistentFlags())
-	if err != nil {
-		log.Fatal(errors.Wrap(err, "binding root command flags"))
+	rootCmd.PersistentFlags().String(keyOutput, string(table.Table), "output format, one of table, json, csv, yaml or tsv")
+	rootCmd.PersistentFlags().String(keyToken, "", "API token to authenticate with, also read from MONCLI_TOKEN")
+	rootCmd.PersistentFlags().Duration(keyTimeout, 30*time.Second, "time limit for each request to the server, 0 for no limit")
+	rootCmd.PersistentFlags().String(keyTLSCA, "", "PEM file of the CA that the server certificate is signed by, if it is not trusted by the system, also read from MONCLI_TLS_CA")
+	rootCmd.PersistentFlags().String(keyTLSCert, "", "PEM file of the client certificate to present to servers that use mutual TLS, also read from MONCLI_TLS_CERT")
+	rootCmd.PersistentFlags().String(keyTLSKey, "", "PEM file of the key of the client certificate, also read from MONCLI_TLS_KEY")
+	for key, env := range map[string]string{
+		keyToken:   "MONCLI_TOKEN",
+		keyTLSCA:   "MONCLI_TLS_CA",
+		keyTLSCert: "MONCLI_TLS_CERT",
+		keyTLSKey:  "MONCLI_TLS_KEY",
+	} {
+		err := viper.BindEnv(key, env)
+		if err != nil {
+			log.Fatal(errors.Wrapf(err, "binding %s environment variable", key))
+		}
 	}
 }
 
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show whether the server is ready",
+	Long: `show the result of each of the readiness checks of the server, such as
+whether its storage is available and responds in time. The command fails when
+the server is not ready.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := newClient().Status()
+		if err != nil {
+			return errors.Wrap(err, "getting server status")
+		}
+		infof("server is %s\n", s.Status)
+		err = table.Status(*s, os.Stdout, output)
+		if err != nil {
+			return errors.Wrap(err, "printing status")
+		}
+		if s.Status != router.StatusOK {
+			return errors.New("server is not ready")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
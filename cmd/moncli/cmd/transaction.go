@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/reconcile"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/table"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyDescription = "description"
+	keyAccount     = "account"
+)
+
+var transactionDate = date.Flag()
+
+var transactionCmd = &cobra.Command{
+	Use:   "transaction",
+	Short: "record and list transactions between accounts",
+}
+
+var transactionAddCmd = &cobra.Command{
+	Use:   "add [SOURCE_ID] [DESTINATION_ID]",
+	Short: "record a transaction from one account to another",
+	Long: `record a transaction of an amount from a source account to a destination account.
+The amount is taken from the source account and added to the destination account.
+Once recorded, the change that the transaction makes to each account is checked
+against the balances that are recorded either side of it.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourceID, err := parseID(args[0])
+		if err != nil {
+			return errors.Wrap(err, "parsing source account id")
+		}
+		destinationID, err := parseID(args[1])
+		if err != nil {
+			return errors.Wrap(err, "parsing destination account id")
+		}
+		amount := viper.GetInt(keyAmount)
+		description := viper.GetString(keyDescription)
+
+		d := date.Today()
+		if transactionDate.Date != nil {
+			d = *transactionDate.Date
+		}
+
+		c := newClient()
+		source, err := c.SelectAccount(uint(sourceID))
+		if err != nil {
+			return errors.Wrap(err, "selecting source account")
+		}
+		destination, err := c.SelectAccount(uint(destinationID))
+		if err != nil {
+			return errors.Wrap(err, "selecting destination account")
+		}
+
+		inserted, err := c.InsertCheckedTransaction(storage.Transaction{
+			Date:          d,
+			Amount:        amount,
+			Description:   description,
+			SourceID:      source.ID,
+			DestinationID: destination.ID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "inserting transaction")
+		}
+
+		if err := table.Accounts(storage.Accounts{*source, *destination}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
+		if err := table.Transactions(storage.Transactions{inserted.Transaction}, os.Stdout, output); err != nil {
+			return errors.Wrap(err, "printing transactions")
+		}
+		return printTransactionChecks(inserted.Checks)
+	},
+}
+
+var transactionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list transactions, optionally only those of a single account",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := newClient()
+		if !cmd.Flags().Changed(keyAccount) {
+			ts, err := c.SelectTransactions()
+			if err != nil {
+				return errors.Wrap(err, "selecting transactions")
+			}
+			return errors.Wrap(table.Transactions(*ts, os.Stdout, output), "printing transactions")
+		}
+
+		a, err := c.SelectAccount(uint(viper.GetInt(keyAccount)))
+		if err != nil {
+			return errors.Wrap(err, "selecting account")
+		}
+		ts, err := c.SelectAccountTransactions(*a)
+		if err != nil {
+			return errors.Wrap(err, "selecting account transactions")
+		}
+		if err := table.Accounts(storage.Accounts{*a}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing accounts")
+		}
+		return errors.Wrap(table.Transactions(*ts, os.Stdout, output), "printing transactions")
+	},
+}
+
+// printTransactionChecks prints the reconciliation of each check of an
+// inserted Transaction, and warns of any Account whose recorded Balances
+// either side of the Transaction are not explained by its Transactions.
+func printTransactionChecks(cs []router.TransactionCheck) error {
+	for _, c := range cs {
+		if c.Interval == nil {
+			fmt.Fprintf(contextWriter(), "account %d has no balances either side of the transaction to check against\n", c.AccountID)
+			continue
+		}
+		fmt.Fprintf(contextWriter(), "account %d:\n", c.AccountID)
+		if err := table.Reconciliation([]reconcile.Interval{*c.Interval}, contextWriter(), output); err != nil {
+			return errors.Wrap(err, "printing reconciliation")
+		}
+		if !c.Interval.Reconciled() {
+			fmt.Fprintf(os.Stderr, "warning: account %d has a change of %d between its balances on %s and %s that its transactions do not explain\n",
+				c.AccountID, c.Interval.Unexplained(), c.Interval.FromDate(), c.Interval.ToDate())
+		}
+	}
+	return nil
+}
+
+func init() {
+	transactionAddCmd.Flags().VarP(transactionDate, keyDate, "d", "date of transaction")
+	transactionAddCmd.Flags().IntP(keyAmount, "a", 0, "amount of transaction")
+	transactionAddCmd.Flags().StringP(keyDescription, "m", "", "description of transaction")
+
+	transactionListCmd.Flags().Uint(keyAccount, 0, "list only the transactions of the account with this id")
+
+	transactionCmd.AddCommand(transactionAddCmd, transactionListCmd)
+	rootCmd.AddCommand(transactionCmd)
+}
@@ -5,12 +5,16 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/internal/tlsconfig"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/glynternet/mon/pkg/storage/postgres"
+	"github.com/glynternet/mon/pkg/storage/sqlite"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -18,27 +22,61 @@ const (
 	appName = "monserve"
 
 	// viper keys
-	keyPort      = "port"
-	keyDBHost    = "db-host"
-	keyDBUser    = "db-user"
-	keyDBName    = "db-name"
-	keyDBSSLMode = "db-sslmode"
+	keyPort              = "port"
+	keyBackend           = "backend"
+	keyDBHost            = "db-host"
+	keyDBUser            = "db-user"
+	keyDBName            = "db-name"
+	keyDBSSLMode         = "db-sslmode"
+	keySQLitePath        = "sqlite-path"
+	keyAuth              = "auth"
+	keyTLSCert           = "tls-cert"
+	keyTLSKey            = "tls-key"
+	keyTLSClientCA       = "tls-client-ca"
+	keyReadTimeout       = "read-timeout"
+	keyWriteTimeout      = "write-timeout"
+	keyIdleTimeout       = "idle-timeout"
+	keyShutdownTimeout   = "shutdown-timeout"
+	keyMaxStorageLatency = "max-storage-latency"
+
+	// storage backends
+	backendPostgres = "postgres"
+	backendSQLite   = "sqlite"
 )
 
 func main() {
-	log.Fatal(cmdDBServe.Execute())
+	err := cmdDBServe.Execute()
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 	cmdDBServe.Flags().String(keyPort, "80", "server listening port")
-	cmdDBServe.Flags().String(keyDBHost, "", "host address of the DB backend")
-	cmdDBServe.Flags().String(keyDBName, "", "name of the DB set to use")
-	cmdDBServe.Flags().String(keyDBUser, "", "DB user to authenticate with")
-	cmdDBServe.Flags().String(keyDBSSLMode, "", "DB SSL mode to use")
-	err := viper.BindPFlags(cmdDBServe.Flags())
-	if err != nil {
-		log.Printf("unable to BindPFlags: %v", err)
+	cmdDBServe.PersistentFlags().String(keyBackend, backendPostgres, fmt.Sprintf("storage backend to use, one of %s or %s, where %s is only available in a binary built with cgo", backendPostgres, backendSQLite, backendSQLite))
+	cmdDBServe.PersistentFlags().String(keyDBHost, "", "host address of the DB backend")
+	cmdDBServe.PersistentFlags().String(keyDBName, "", "name of the DB set to use")
+	cmdDBServe.PersistentFlags().String(keyDBUser, "", "DB user to authenticate with")
+	cmdDBServe.PersistentFlags().String(keyDBSSLMode, "", "DB SSL mode to use")
+	cmdDBServe.PersistentFlags().String(keySQLitePath, "mon.db", "path of the sqlite DB file, created if it does not exist")
+	cmdDBServe.Flags().Bool(keyAuth, false, "require requests to be authenticated with a token created by the token command")
+	cmdDBServe.Flags().String(keyTLSCert, "", "PEM file of the server certificate, serving over TLS when given along with --tls-key")
+	cmdDBServe.Flags().String(keyTLSKey, "", "PEM file of the key of the server certificate")
+	cmdDBServe.Flags().String(keyTLSClientCA, "", "PEM file of the CA that client certificates must be signed by, requiring clients to present one (mutual TLS)")
+	cmdDBServe.Flags().Duration(keyReadTimeout, 30*time.Second, "maximum duration for reading a whole request, including its body, 0 for no limit")
+	cmdDBServe.Flags().Duration(keyWriteTimeout, time.Minute, "maximum duration from the end of reading a request's headers to the end of writing its response, 0 for no limit")
+	cmdDBServe.Flags().Duration(keyIdleTimeout, 2*time.Minute, "maximum duration to wait for the next request on a keep-alive connection, 0 to use the read timeout")
+	cmdDBServe.Flags().Duration(keyShutdownTimeout, 30*time.Second, "maximum duration to wait for in-flight requests to complete after SIGINT or SIGTERM, 0 for no limit")
+	cmdDBServe.Flags().Duration(keyMaxStorageLatency, time.Second, "longest that the storage can take to respond for the server to be reported as ready at /readyz")
+	for _, fs := range []*pflag.FlagSet{
+		cmdDBServe.Flags(),
+		cmdDBServe.PersistentFlags(),
+	} {
+		err := viper.BindPFlags(fs)
+		if err != nil {
+			log.Printf("unable to BindPFlags: %v", err)
+		}
 	}
 }
 
@@ -47,30 +85,107 @@ func initConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 }
 
-func newStorage(host, user, dbname, sslmode string) (storage.Storage, error) {
-	cs, err := postgres.NewConnectionString(host, user, dbname, sslmode)
+// validateBackend returns an error if the storage backend is unknown or
+// cannot be used by this binary.
+func validateBackend(backend string) error {
+	switch backend {
+	case backendPostgres:
+		return nil
+	case backendSQLite:
+		if !sqlite.Supported {
+			return fmt.Errorf("the %s backend requires monserve to be built with cgo, e.g. make monserve-binary CGO_ENABLED=1", backendSQLite)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported storage backend: %q", backend)
+	}
+}
+
+func newStorage(backend string) (storage.Storage, error) {
+	switch backend {
+	case backendPostgres:
+		return newPostgresStorage()
+	case backendSQLite:
+		return sqlite.New(viper.GetString(keySQLitePath))
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %q", backend)
+	}
+}
+
+// newPostgresStorage returns a postgres Storage, refusing to return one that
+// has a schema that is not at the latest version.
+func newPostgresStorage() (storage.Storage, error) {
+	cs, err := postgresConnectionString()
 	if err != nil {
-		return nil, fmt.Errorf("unable to create connection string: %v", err)
+		return nil, err
 	}
-	return postgres.New(cs)
+	pg, err := postgres.New(cs)
+	if err != nil {
+		return nil, err
+	}
+	err = pg.CheckSchemaVersion()
+	if err != nil {
+		_ = pg.Close()
+		return nil, errors.Wrap(err, "checking schema version")
+	}
+	return pg, nil
+}
+
+func postgresConnectionString() (string, error) {
+	cs, err := postgres.NewConnectionString(
+		viper.GetString(keyDBHost),
+		viper.GetString(keyDBUser),
+		viper.GetString(keyDBName),
+		viper.GetString(keyDBSSLMode),
+	)
+	if err != nil {
+		return "", fmt.Errorf("unable to create connection string: %v", err)
+	}
+	return cs, nil
 }
 
 var cmdDBServe = &cobra.Command{
 	Use: appName,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateBackend(viper.GetString(keyBackend))
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		store, err := newStorage(
-			viper.GetString(keyDBHost),
-			viper.GetString(keyDBUser),
-			viper.GetString(keyDBName),
-			viper.GetString(keyDBSSLMode),
-		)
+		store, err := newStorage(viper.GetString(keyBackend))
 		if err != nil {
 			return errors.Wrap(err, "error creating storage")
 		}
-		r, err := router.New(store)
+		defer nonReturningCloseStorage(store)
+		opts := []router.Option{router.WithMaxStorageLatency(viper.GetDuration(keyMaxStorageLatency))}
+		if viper.GetBool(keyAuth) {
+			ts, ok := store.(storage.TokenStorage)
+			if !ok {
+				return fmt.Errorf("%s backend cannot store tokens for authentication", viper.GetString(keyBackend))
+			}
+			opts = append(opts, router.WithTokenAuth(ts))
+		}
+		r, err := router.New(store, opts...)
 		if err != nil {
 			return errors.Wrap(err, "error creating new server")
 		}
-		return http.ListenAndServe(":"+viper.GetString(keyPort), r)
+		srv := &http.Server{
+			Addr:         ":" + viper.GetString(keyPort),
+			Handler:      r,
+			ReadTimeout:  viper.GetDuration(keyReadTimeout),
+			WriteTimeout: viper.GetDuration(keyWriteTimeout),
+			IdleTimeout:  viper.GetDuration(keyIdleTimeout),
+		}
+		listen := srv.ListenAndServe
+		cert, key, clientCA := viper.GetString(keyTLSCert), viper.GetString(keyTLSKey), viper.GetString(keyTLSClientCA)
+		if cert != "" || key != "" || clientCA != "" {
+			srv.TLSConfig, err = tlsconfig.Server(cert, key, clientCA)
+			if err != nil {
+				return errors.Wrap(err, "configuring TLS")
+			}
+			listen = func() error {
+				// the certificate is already held by the TLSConfig
+				return srv.ListenAndServeTLS("", "")
+			}
+		}
+		return serve(srv, listen, viper.GetDuration(keyShutdownTimeout))
 	},
 }
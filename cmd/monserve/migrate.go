@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/glynternet/mon/pkg/storage/postgres"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const keyTo = "to"
+
+var cmdMigrate = &cobra.Command{
+	Use:   "migrate",
+	Short: "manage the schema version of the postgres backend",
+}
+
+var cmdMigrateStatus = &cobra.Command{
+	Use:   "status",
+	Short: "show the schema version and the status of each migration",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pg, err := newPostgresMigrator()
+		if err != nil {
+			return err
+		}
+		defer nonReturningClose(pg)
+
+		ss, err := pg.MigrationStatuses()
+		if err != nil {
+			return errors.Wrap(err, "getting migration statuses")
+		}
+		v, err := pg.SchemaVersion()
+		if err != nil {
+			return errors.Wrap(err, "getting schema version")
+		}
+		fmt.Printf("schema version: %d (latest: %d)\n", v, postgres.LatestSchemaVersion())
+		for _, s := range ss {
+			status := "pending"
+			if s.Applied {
+				status = "applied"
+			}
+			fmt.Printf("%4d  %-8s %s\n", s.Version, status, s.Description)
+		}
+		return nil
+	},
+}
+
+var cmdMigrateUp = &cobra.Command{
+	Use:   "up",
+	Short: "apply migrations, up to the latest version by default",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pg, err := newPostgresMigrator()
+		if err != nil {
+			return err
+		}
+		defer nonReturningClose(pg)
+
+		to := postgres.LatestSchemaVersion()
+		if cmd.Flags().Changed(keyTo) {
+			to, err = cmd.Flags().GetInt(keyTo)
+			if err != nil {
+				return errors.Wrap(err, "getting version flag")
+			}
+		}
+		err = pg.MigrateUp(to)
+		if err != nil {
+			return errors.Wrap(err, "migrating up")
+		}
+		fmt.Printf("migrated up to version %d\n", to)
+		return nil
+	},
+}
+
+var cmdMigrateDown = &cobra.Command{
+	Use:   "down",
+	Short: "revert migrations, reverting only the latest applied migration by default",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pg, err := newPostgresMigrator()
+		if err != nil {
+			return err
+		}
+		defer nonReturningClose(pg)
+
+		v, err := pg.SchemaVersion()
+		if err != nil {
+			return errors.Wrap(err, "getting schema version")
+		}
+		if v == 0 {
+			return errors.New("no migrations have been applied")
+		}
+		to := v - 1
+		if cmd.Flags().Changed(keyTo) {
+			to, err = cmd.Flags().GetInt(keyTo)
+			if err != nil {
+				return errors.Wrap(err, "getting version flag")
+			}
+		}
+		err = pg.MigrateDown(to)
+		if err != nil {
+			return errors.Wrap(err, "migrating down")
+		}
+		fmt.Printf("migrated down to version %d\n", to)
+		return nil
+	},
+}
+
+// migrator is the subset of the methods of a postgres Storage that are used
+// to manage its schema.
+type migrator interface {
+	SchemaVersion() (int, error)
+	MigrationStatuses() ([]postgres.MigrationStatus, error)
+	MigrateUp(to int) error
+	MigrateDown(to int) error
+	Close() error
+}
+
+// newPostgresMigrator returns a connection to the postgres backend without
+// checking that its schema is at the latest version.
+func newPostgresMigrator() (migrator, error) {
+	cs, err := postgresConnectionString()
+	if err != nil {
+		return nil, err
+	}
+	pg, err := postgres.New(cs)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening connection to postgres")
+	}
+	return pg, nil
+}
+
+func nonReturningClose(m migrator) {
+	err := m.Close()
+	if err != nil {
+		log.Printf("error closing postgres connection: %v", err)
+	}
+}
+
+func init() {
+	cmdMigrateUp.Flags().Int(keyTo, 0, "version to migrate up to")
+	cmdMigrateDown.Flags().Int(keyTo, 0, "version to migrate down to")
+	cmdMigrate.AddCommand(cmdMigrateStatus, cmdMigrateUp, cmdMigrateDown)
+	cmdDBServe.AddCommand(cmdMigrate)
+}
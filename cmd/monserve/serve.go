@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// serve runs listen, which should be one of the ListenAndServe methods of
+// srv, until it fails or until the process receives SIGINT or SIGTERM.
+// After a signal, the server stops accepting connections and in-flight
+// requests are given up to timeout to complete, after which their connections
+// are closed. A timeout of 0 waits for them indefinitely.
+func serve(srv *http.Server, listen func() error, timeout time.Duration) error {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	return serveUntilSignal(srv, listen, timeout, sigs)
+}
+
+// serveUntilSignal is the same as serve but shuts down the server once a
+// signal is received from sigs.
+func serveUntilSignal(srv *http.Server, listen func() error, timeout time.Duration, sigs <-chan os.Signal) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- listen()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case sig := <-sigs:
+		log.Printf("received %s, shutting down", sig)
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		// close the connections of the requests that are still in flight, so
+		// that none of them are using the storage once this returns
+		if cErr := srv.Close(); cErr != nil {
+			log.Print(errors.Wrap(cErr, "closing server"))
+		}
+		return errors.Wrap(err, "draining in-flight requests")
+	}
+	// listen returns http.ErrServerClosed as soon as Shutdown is called
+	err = <-errc
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingServer returns a server with a handler that signals started once it
+// has received a request and then blocks until release is closed, along with
+// a listener for the server.
+func blockingServer(t *testing.T, started chan<- struct{}, release <-chan struct{}) (*http.Server, net.Listener) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	common.FatalIfError(t, err, "listening")
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})}
+	return srv, ln
+}
+
+// get sends a request to the listener, sending the status code of the
+// response, or 0 if the request fails, to codes.
+func get(ln net.Listener, codes chan<- int) {
+	res, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		codes <- 0
+		return
+	}
+	_ = res.Body.Close()
+	codes <- res.StatusCode
+}
+
+func TestServeUntilSignal(t *testing.T) {
+	t.Run("in-flight request completes", func(t *testing.T) {
+		started, release := make(chan struct{}, 1), make(chan struct{})
+		srv, ln := blockingServer(t, started, release)
+		sigs := make(chan os.Signal, 1)
+		served := make(chan error, 1)
+		go func() {
+			served <- serveUntilSignal(srv, func() error { return srv.Serve(ln) }, time.Second, sigs)
+		}()
+
+		codes := make(chan int, 1)
+		go get(ln, codes)
+		<-started
+		sigs <- syscall.SIGTERM
+
+		refused := false
+		for deadline := time.Now().Add(time.Second); !refused && time.Now().Before(deadline); {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				refused = true
+				break
+			}
+			_ = conn.Close()
+			time.Sleep(5 * time.Millisecond)
+		}
+		assert.True(t, refused, "new connections should be refused after the signal")
+
+		close(release)
+		assert.Equal(t, http.StatusOK, <-codes)
+		assert.NoError(t, <-served)
+	})
+
+	t.Run("shutdown timeout", func(t *testing.T) {
+		started, release := make(chan struct{}, 1), make(chan struct{})
+		defer close(release)
+		srv, ln := blockingServer(t, started, release)
+		sigs := make(chan os.Signal, 1)
+		served := make(chan error, 1)
+		go func() {
+			served <- serveUntilSignal(srv, func() error { return srv.Serve(ln) }, 10*time.Millisecond, sigs)
+		}()
+
+		codes := make(chan int, 1)
+		go get(ln, codes)
+		<-started
+		sigs <- syscall.SIGTERM
+
+		select {
+		case err := <-served:
+			assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+		case <-time.After(time.Second):
+			t.Fatal("serve did not return after the shutdown timeout")
+		}
+		assert.Equal(t, 0, <-codes, "the connection of the in-flight request should be closed")
+	})
+
+	t.Run("listen error", func(t *testing.T) {
+		expected := errors.New("listen error")
+		err := serveUntilSignal(&http.Server{}, func() error { return expected }, time.Second, nil)
+		assert.Equal(t, expected, err)
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cmdToken = &cobra.Command{
+	Use:   "token",
+	Short: "manage the API tokens that authenticate requests when --auth is set",
+}
+
+var cmdTokenCreate = &cobra.Command{
+	Use:   "create [NAME]",
+	Short: "create a token and print its secret, which cannot be shown again",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ts, err := newTokenStorage()
+		if err != nil {
+			return err
+		}
+		defer nonReturningCloseStorage(ts)
+
+		var userID uint
+		if name := viper.GetString(keyUser); name != "" {
+			us, ok := ts.(storage.UserStorage)
+			if !ok {
+				return fmt.Errorf("%s backend cannot store users", viper.GetString(keyBackend))
+			}
+			u, err := us.SelectUserByName(name)
+			if err != nil {
+				return errors.Wrapf(err, "selecting user %q", name)
+			}
+			userID = u.ID
+		}
+		secret, err := storage.NewTokenSecret()
+		if err != nil {
+			return errors.Wrap(err, "generating token secret")
+		}
+		t, err := ts.InsertToken(args[0], storage.HashTokenSecret(secret), userID)
+		if err != nil {
+			return errors.Wrap(err, "inserting token")
+		}
+		fmt.Fprintf(os.Stderr, "created token %d (%s), its secret is only shown once:\n", t.ID, t.Name)
+		fmt.Println(secret)
+		return nil
+	},
+}
+
+var cmdTokenList = &cobra.Command{
+	Use:   "list",
+	Short: "list every token, including revoked tokens",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ts, err := newTokenStorage()
+		if err != nil {
+			return err
+		}
+		defer nonReturningCloseStorage(ts)
+
+		tokens, err := ts.SelectTokens()
+		if err != nil {
+			return errors.Wrap(err, "selecting tokens")
+		}
+		names := make(map[uint]string)
+		if us, ok := ts.(storage.UserStorage); ok {
+			users, err := us.SelectUsers()
+			if err != nil {
+				return errors.Wrap(err, "selecting users")
+			}
+			for _, u := range *users {
+				names[u.ID] = u.Name
+			}
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tUSER\tCREATED\tREVOKED")
+		for _, t := range *tokens {
+			user := "-"
+			if t.UserID != 0 {
+				user = names[t.UserID]
+			}
+			revoked := "-"
+			if t.Revoked != nil {
+				revoked = t.Revoked.Format(timeFormat)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", t.ID, t.Name, user, t.Created.Format(timeFormat), revoked)
+		}
+		return errors.Wrap(w.Flush(), "writing tokens")
+	},
+}
+
+var cmdTokenRevoke = &cobra.Command{
+	Use:   "revoke [ID]",
+	Short: "revoke a token so that it can no longer be used",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "parsing token id")
+		}
+		ts, err := newTokenStorage()
+		if err != nil {
+			return err
+		}
+		defer nonReturningCloseStorage(ts)
+
+		err = ts.RevokeToken(uint(id))
+		if err != nil {
+			return errors.Wrapf(err, "revoking token %d", id)
+		}
+		fmt.Printf("revoked token %d\n", id)
+		return nil
+	},
+}
+
+const (
+	keyUser    = "user"
+	timeFormat = "2006-01-02 15:04:05"
+)
+
+// newTokenStorage returns the storage of the configured backend, which must
+// be able to store Tokens.
+func newTokenStorage() (storage.TokenStorage, error) {
+	s, err := newStorage(viper.GetString(keyBackend))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating storage")
+	}
+	ts, ok := s.(storage.TokenStorage)
+	if !ok {
+		nonReturningCloseStorage(s)
+		return nil, fmt.Errorf("%s backend cannot store tokens", viper.GetString(keyBackend))
+	}
+	return ts, nil
+}
+
+func nonReturningCloseStorage(s storage.Storage) {
+	err := s.Close()
+	if err != nil {
+		log.Printf("error closing storage: %v", err)
+	}
+}
+
+func init() {
+	cmdTokenCreate.Flags().String(keyUser, "", "name of the user that the token authenticates, leave empty for a token that can see every account")
+	err := viper.BindPFlags(cmdTokenCreate.Flags())
+	if err != nil {
+		log.Printf("unable to BindPFlags: %v", err)
+	}
+	cmdToken.AddCommand(cmdTokenCreate, cmdTokenList, cmdTokenRevoke)
+	cmdDBServe.AddCommand(cmdToken)
+}
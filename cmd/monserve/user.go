@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cmdUser = &cobra.Command{
+	Use:   "user",
+	Short: "manage the users that share the server",
+}
+
+var cmdUserCreate = &cobra.Command{
+	Use:   "create [NAME]",
+	Short: "create a user",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		us, err := newUserStorage()
+		if err != nil {
+			return err
+		}
+		defer nonReturningCloseStorage(us)
+
+		u, err := us.InsertUser(args[0])
+		if err != nil {
+			return errors.Wrap(err, "inserting user")
+		}
+		fmt.Printf("created user %d (%s)\n", u.ID, u.Name)
+		return nil
+	},
+}
+
+var cmdUserList = &cobra.Command{
+	Use:   "list",
+	Short: "list every user",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		us, err := newUserStorage()
+		if err != nil {
+			return err
+		}
+		defer nonReturningCloseStorage(us)
+
+		users, err := us.SelectUsers()
+		if err != nil {
+			return errors.Wrap(err, "selecting users")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME")
+		for _, u := range *users {
+			fmt.Fprintf(w, "%d\t%s\n", u.ID, u.Name)
+		}
+		return errors.Wrap(w.Flush(), "writing users")
+	},
+}
+
+// newUserStorage returns the storage of the configured backend, which must
+// be able to store Users.
+func newUserStorage() (storage.UserStorage, error) {
+	ts, err := newTokenStorage()
+	if err != nil {
+		return nil, err
+	}
+	us, ok := ts.(storage.UserStorage)
+	if !ok {
+		nonReturningCloseStorage(ts)
+		return nil, fmt.Errorf("%s backend cannot store users", viper.GetString(keyBackend))
+	}
+	return us, nil
+}
+
+func init() {
+	cmdUser.AddCommand(cmdUserCreate, cmdUserList)
+	cmdDBServe.AddCommand(cmdUser)
+}
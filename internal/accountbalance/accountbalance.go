@@ -1,8 +1,12 @@
 package accountbalance
 
 import (
+	"encoding/json"
+
 	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
 )
 
 // AccountBalance represents the state of a storage.Account at a given moment,
@@ -11,3 +15,39 @@ type AccountBalance struct {
 	storage.Account
 	balance.Balance
 }
+
+type balanceJSON struct {
+	Date   date.Date
+	Amount int
+}
+
+// MarshalJSON marshals an AccountBalance into a json blob holding the Account
+// and the Balance, with the date of the Balance held as a date. Without it,
+// the MarshalJSON method of the embedded storage.Account would be used, which
+// would leave out the Balance.
+func (ab AccountBalance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		Account storage.Account
+		Balance balanceJSON
+	}{
+		Account: ab.Account,
+		Balance: balanceJSON{Date: date.FromTime(ab.Date), Amount: ab.Amount},
+	})
+}
+
+// UnmarshalJSON unmarshals a json blob that has been produced by MarshalJSON
+// into an AccountBalance.
+func (ab *AccountBalance) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Account storage.Account
+		Balance balanceJSON
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return errors.Wrap(err, "unmarshalling into auxiliary account balance struct")
+	}
+	*ab = AccountBalance{
+		Account: aux.Account,
+		Balance: balance.Balance{Date: aux.Balance.Date.Time(), Amount: aux.Balance.Amount},
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package accountbalance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountBalance_JSON(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	ab := AccountBalance{
+		Account: storage.Account{ID: 3, Account: *accountingtest.NewAccount(t, "current", accountingtest.NewCurrencyCode(t, "GBP"), opened)},
+		Balance: balance.Balance{Date: opened.AddDate(0, 0, 1), Amount: -123},
+	}
+	bs, err := json.Marshal(ab)
+	common.FatalIfError(t, err, "marshalling")
+	assert.Contains(t, string(bs), `"Balance":{"Date":"2018-05-05","Amount":-123}`)
+
+	var unmarshalled AccountBalance
+	common.FatalIfError(t, json.Unmarshal(bs, &unmarshalled), "unmarshalling")
+	equal, err := ab.Account.Equal(unmarshalled.Account)
+	common.FatalIfError(t, err, "comparing accounts")
+	assert.True(t, equal)
+	assert.True(t, ab.Balance.Equal(unmarshalled.Balance))
+}
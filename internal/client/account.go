@@ -1,23 +1,121 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/internal/accountbalance"
 	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/pkg/errors"
 )
 
 // SelectAccounts is used to retrieve accounts from the mon server
 func (c Client) SelectAccounts() (*storage.Accounts, error) {
-	return c.getAccountsFromEndpoint(router.EndpointAccounts)
+	return c.SelectAccountsContext(context.Background())
 }
 
-func (c Client) getAccountsFromEndpoint(e string) (*storage.Accounts, error) {
-	bod, err := c.getBodyFromEndpoint(e)
+// SelectAccountsContext is the same as SelectAccounts but its request can be
+// cancelled with the given context.Context.
+func (c Client) SelectAccountsContext(ctx context.Context) (*storage.Accounts, error) {
+	return c.getAccountsFromEndpoint(ctx, router.EndpointAccounts)
+}
+
+// AccountsOptions filters and sorts the Accounts retrieved with
+// SelectAccountsWithOptions. Options that are left as their zero value do not
+// filter the Accounts.
+type AccountsOptions struct {
+	// OpenAt includes only Accounts that are open at the date
+	OpenAt *date.Date
+	// ExistedAt includes only Accounts that were opened by the date
+	ExistedAt *date.Date
+	// IDs includes only Accounts with one of the IDs
+	IDs []uint
+	// NotIDs excludes Accounts with any of the IDs
+	NotIDs []uint
+	// Currencies includes only Accounts with one of the currency.Codes
+	Currencies []currency.Code
+	// Sort is the key to sort the Accounts by, one of the keys of
+	// sort.AccountSorts. The Accounts are not sorted when Sort is empty.
+	Sort string
+	// Descending sorts the Accounts in descending order
+	Descending bool
+}
+
+func (o AccountsOptions) query() url.Values {
+	q := url.Values{}
+	if o.OpenAt != nil {
+		q.Set(router.QueryKeyOpenAt, o.OpenAt.String())
+	}
+	if o.ExistedAt != nil {
+		q.Set(router.QueryKeyExistedAt, o.ExistedAt.String())
+	}
+	for _, id := range o.IDs {
+		q.Add(router.QueryKeyIDs, strconv.FormatUint(uint64(id), 10))
+	}
+	for _, id := range o.NotIDs {
+		q.Add(router.QueryKeyNotIDs, strconv.FormatUint(uint64(id), 10))
+	}
+	for _, c := range o.Currencies {
+		q.Add(router.QueryKeyCurrency, c.String())
+	}
+	if o.Sort != "" {
+		q.Set(router.QueryKeySort, o.Sort)
+	}
+	if o.Descending {
+		q.Set(router.QueryKeyOrder, router.OrderDescending)
+	}
+	return q
+}
+
+// SelectAccountsWithOptions is used to retrieve accounts from the mon server,
+// filtered and sorted by the server using the given AccountsOptions
+func (c Client) SelectAccountsWithOptions(o AccountsOptions) (*storage.Accounts, error) {
+	return c.SelectAccountsWithOptionsContext(context.Background(), o)
+}
+
+// SelectAccountsWithOptionsContext is the same as SelectAccountsWithOptions but
+// its request can be cancelled with the given context.Context.
+func (c Client) SelectAccountsWithOptionsContext(ctx context.Context, o AccountsOptions) (*storage.Accounts, error) {
+	e := router.EndpointAccounts
+	if q := o.query(); len(q) > 0 {
+		e += "?" + q.Encode()
+	}
+	return c.getAccountsFromEndpoint(ctx, e)
+}
+
+// SelectAccountsBalances retrieves the Balance at the given date of each of
+// the Accounts that match the given AccountsOptions in a single request.
+// Accounts that have no Balance at the date are left out.
+func (c Client) SelectAccountsBalances(at date.Date, o AccountsOptions) ([]accountbalance.AccountBalance, error) {
+	return c.SelectAccountsBalancesContext(context.Background(), at, o)
+}
+
+// SelectAccountsBalancesContext is the same as SelectAccountsBalances but its
+// request can be cancelled with the given context.Context.
+func (c Client) SelectAccountsBalancesContext(ctx context.Context, at date.Date, o AccountsOptions) ([]accountbalance.AccountBalance, error) {
+	q := o.query()
+	q.Set(router.QueryKeyAt, at.String())
+	bod, err := c.getBodyFromEndpoint(ctx, router.EndpointAccountsBalances+"?"+q.Encode())
+	if err != nil {
+		return nil, errors.Wrap(err, "getting body from endpoint")
+	}
+	var abs []accountbalance.AccountBalance
+	err = errors.Wrap(json.Unmarshal(bod, &abs), "unmarshalling response")
+	if err != nil {
+		abs = nil
+	}
+	return abs, err
+}
+
+func (c Client) getAccountsFromEndpoint(ctx context.Context, e string) (*storage.Accounts, error) {
+	bod, err := c.getBodyFromEndpoint(ctx, e)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting body from endpoint")
 	}
@@ -31,11 +129,17 @@ func (c Client) getAccountsFromEndpoint(e string) (*storage.Accounts, error) {
 
 // SelectAccount retrieves an account from the mon server by a given ID
 func (c Client) SelectAccount(ID uint) (*storage.Account, error) {
-	return c.getAccountFromEndpoint(fmt.Sprintf(router.EndpointFmtAccount, ID))
+	return c.SelectAccountContext(context.Background(), ID)
 }
 
-func (c Client) getAccountFromEndpoint(e string) (*storage.Account, error) {
-	bod, err := c.getBodyFromEndpoint(e)
+// SelectAccountContext is the same as SelectAccount but its request can be
+// cancelled with the given context.Context.
+func (c Client) SelectAccountContext(ctx context.Context, ID uint) (*storage.Account, error) {
+	return c.getAccountFromEndpoint(ctx, fmt.Sprintf(router.EndpointFmtAccount, ID))
+}
+
+func (c Client) getAccountFromEndpoint(ctx context.Context, e string) (*storage.Account, error) {
+	bod, err := c.getBodyFromEndpoint(ctx, e)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting body from endpoint")
 	}
@@ -44,7 +148,13 @@ func (c Client) getAccountFromEndpoint(e string) (*storage.Account, error) {
 
 // InsertAccount will attempt to insert an account by calling the mon server and return the stored Account
 func (c Client) InsertAccount(a account.Account) (*storage.Account, error) {
-	bs, err := c.postAccountToEndpoint(router.EndpointAccountInsert, a)
+	return c.InsertAccountContext(context.Background(), a)
+}
+
+// InsertAccountContext is the same as InsertAccount but its request can be
+// cancelled with the given context.Context.
+func (c Client) InsertAccountContext(ctx context.Context, a account.Account) (*storage.Account, error) {
+	bs, err := c.postAccountToEndpoint(ctx, router.EndpointAccountInsert, a)
 	if err != nil {
 		return nil, errors.Wrapf(err, "posting account to endpoint %s", router.EndpointAccountInsert)
 	}
@@ -53,8 +163,14 @@ func (c Client) InsertAccount(a account.Account) (*storage.Account, error) {
 
 // UpdateAccount will updated a currently stored account with updates provided by another account
 func (c Client) UpdateAccount(account *storage.Account, updates *account.Account) (*storage.Account, error) {
+	return c.UpdateAccountContext(context.Background(), account, updates)
+}
+
+// UpdateAccountContext is the same as UpdateAccount but its request can be
+// cancelled with the given context.Context.
+func (c Client) UpdateAccountContext(ctx context.Context, account *storage.Account, updates *account.Account) (*storage.Account, error) {
 	endpoint := fmt.Sprintf(router.EndpointFmtAccountUpdate, account.ID)
-	bs, err := c.postAccountToEndpoint(endpoint, *updates)
+	bs, err := c.postAccountToEndpoint(ctx, endpoint, *updates)
 	if err != nil {
 		return nil, errors.Wrapf(err, "posting account to endpoint %s", endpoint)
 	}
@@ -63,19 +179,47 @@ func (c Client) UpdateAccount(account *storage.Account, updates *account.Account
 
 // DeleteAccount will attempt to delete an account through the mon server by the given id
 func (c Client) DeleteAccount(id uint) error {
+	return c.DeleteAccountContext(context.Background(), id)
+}
+
+// DeleteAccountContext is the same as DeleteAccount but its request can be
+// cancelled with the given context.Context.
+func (c Client) DeleteAccountContext(ctx context.Context, id uint) error {
 	endpoint := fmt.Sprintf(router.EndpointFmtAccount, id)
-	r, err := c.deleteToEndpoint(endpoint)
+	r, err := c.deleteToEndpoint(ctx, endpoint)
 	if err != nil {
 		return errors.Wrapf(err, "deleting account to endpoint %s", endpoint)
 	}
-	if r.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code %d (%s)", r.StatusCode, http.StatusText(r.StatusCode))
+	_, err = processResponseForBody(r)
+	return errors.Wrap(err, "processing response")
+}
+
+// ShareAccount gives the User with the given name an Access to an Account
+// through the mon server, replacing any Access that the User already has to
+// it. Only the owner of an Account can share it.
+func (c Client) ShareAccount(id uint, user string, access storage.Access) (*router.AccountShare, error) {
+	return c.ShareAccountContext(context.Background(), id, user, access)
+}
+
+// ShareAccountContext is the same as ShareAccount but its request can be
+// cancelled with the given context.Context.
+func (c Client) ShareAccountContext(ctx context.Context, id uint, user string, access storage.Access) (*router.AccountShare, error) {
+	endpoint := fmt.Sprintf(router.EndpointFmtAccountShare, id)
+	res, err := c.postAsJSONToEndpoint(ctx, endpoint, router.AccountShare{User: user, Access: access})
+	if err != nil {
+		return nil, errors.Wrapf(err, "posting share to endpoint %s", endpoint)
+	}
+	bs, err := processResponseForBody(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "processing response")
 	}
-	return nil
+	var s router.AccountShare
+	err = json.Unmarshal(bs, &s)
+	return &s, errors.Wrapf(err, "json unmarshalling into account share. bytes as string: %s", bs)
 }
 
-func (c Client) postAccountToEndpoint(e string, a account.Account) ([]byte, error) {
-	res, err := c.postAsJSONToEndpoint(e, a)
+func (c Client) postAccountToEndpoint(ctx context.Context, e string, a account.Account) ([]byte, error) {
+	res, err := c.postAsJSONToEndpoint(ctx, e, a)
 	if err != nil {
 		return nil, errors.Wrap(err, "posting as JSON")
 	}
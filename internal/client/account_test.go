@@ -1,20 +1,31 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetAccountsFromEndpoint(t *testing.T) {
 	t.Run("get body error", func(t *testing.T) {
-		c := Client("bloopybloop")
-		as, err := c.getAccountsFromEndpoint("")
+		c := Client{Host: "bloopybloop"}
+		as, err := c.getAccountsFromEndpoint(context.Background(), "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "getting from endpoint")
 		assert.Nil(t, as)
@@ -26,8 +37,8 @@ func TestGetAccountsFromEndpoint(t *testing.T) {
 			http.StatusOK,
 		)
 		defer srv.Close()
-		c := Client(srv.URL)
-		as, err := c.getAccountsFromEndpoint("")
+		c := Client{Host: srv.URL}
+		as, err := c.getAccountsFromEndpoint(context.Background(), "")
 		if assert.Error(t, err) {
 			assert.IsType(t, &json.UnmarshalTypeError{}, errors.Cause(err))
 		}
@@ -37,8 +48,8 @@ func TestGetAccountsFromEndpoint(t *testing.T) {
 
 func TestGetAccountFromEndpoint(t *testing.T) {
 	t.Run("get body error", func(t *testing.T) {
-		c := Client("bloopybleep")
-		a, err := c.getAccountFromEndpoint("")
+		c := Client{Host: "bloopybleep"}
+		a, err := c.getAccountFromEndpoint(context.Background(), "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "getting from endpoint")
 		assert.Nil(t, a)
@@ -50,8 +61,8 @@ func TestGetAccountFromEndpoint(t *testing.T) {
 			http.StatusOK,
 		)
 		defer srv.Close()
-		c := Client(srv.URL)
-		as, err := c.getAccountFromEndpoint("")
+		c := Client{Host: srv.URL}
+		as, err := c.getAccountFromEndpoint(context.Background(), "")
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "json unmarshalling into account")
 		}
@@ -63,7 +74,7 @@ func TestPostAccountToEndpoint(t *testing.T) {
 	// TODO: this error can probably be caused by a timeout when timeouts are
 	// implemented in the repo
 	//t.Run("post as json error", func(t *testing.T) {
-	//	bod, err := Client("BLOOOOP").postAccountToEndpoint("", nil)
+	//	bod, err := Client{Host: "BLOOOOP"}.postAccountToEndpoint(context.Background(), "", nil)
 	//	if assert.Error(t, err) {
 	//		assert.Contains(t, err.Error(), "posting as JSON")
 	//	}
@@ -74,10 +85,111 @@ func TestPostAccountToEndpoint(t *testing.T) {
 		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 		}))
-		bod, err := Client(srv.URL).postAccountToEndpoint("", account.Account{})
+		bod, err := Client{Host: srv.URL}.postAccountToEndpoint(context.Background(), "", account.Account{})
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "server returned unexpected code ")
 		}
 		assert.Empty(t, bod)
 	})
 }
+
+func TestSelectAccountsWithOptions(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	newAccount := func(id uint, name, code string, os ...account.Option) storage.Account {
+		return storage.Account{
+			ID:      id,
+			Account: *accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, code), opened, os...),
+		}
+	}
+	r, err := router.New(&storagetest.Storage{Accounts: &storage.Accounts{
+		newAccount(1, "current", "GBP"),
+		newAccount(2, "savings", "EUR", account.CloseTime(opened.AddDate(0, 1, 0))),
+		newAccount(3, "bonds", "GBP"),
+		newAccount(4, "joint", "GBP"),
+	}})
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	openAt := date.New(2018, time.July, 1)
+	as, err := Client{Host: srv.URL}.SelectAccountsWithOptions(AccountsOptions{
+		OpenAt:     &openAt,
+		NotIDs:     []uint{4},
+		Currencies: []currency.Code{accountingtest.NewCurrencyCode(t, "GBP"), accountingtest.NewCurrencyCode(t, "EUR")},
+		Sort:       "name",
+		Descending: true,
+	})
+	common.FatalIfError(t, err, "selecting accounts")
+	var ids []uint
+	for _, a := range *as {
+		ids = append(ids, a.ID)
+	}
+	assert.Equal(t, []uint{1, 3}, ids)
+
+	_, err = Client{Host: srv.URL}.SelectAccountsWithOptions(AccountsOptions{Sort: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestSelectAccountsBalances(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	store := memory.New()
+	insertAccount := func(name string) *storage.Account {
+		a, err := store.InsertAccount(*accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), opened))
+		common.FatalIfError(t, err, "inserting account")
+		return a
+	}
+	current, savings := insertAccount("current"), insertAccount("savings")
+	for _, b := range []struct {
+		*storage.Account
+		days, amount int
+	}{
+		{Account: current, days: 0, amount: 10},
+		{Account: current, days: 3, amount: 20},
+		{Account: savings, days: 1, amount: 30},
+		{Account: savings, days: 4, amount: 40},
+	} {
+		_, err := store.InsertBalance(*b.Account, balance.Balance{Date: opened.AddDate(0, 0, b.days), Amount: b.amount})
+		common.FatalIfError(t, err, "inserting balance")
+	}
+	r, err := router.New(store)
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	abs, err := Client{Host: srv.URL}.SelectAccountsBalances(date.New(2018, time.May, 7), AccountsOptions{Sort: "name", Descending: true})
+	common.FatalIfError(t, err, "selecting accounts balances")
+	if assert.Len(t, abs, 2) {
+		assert.Equal(t, savings.ID, abs[0].Account.ID)
+		assert.Equal(t, 30, abs[0].Amount)
+		assert.True(t, opened.AddDate(0, 0, 1).Equal(abs[0].Date))
+		assert.Equal(t, current.ID, abs[1].Account.ID)
+		assert.Equal(t, 20, abs[1].Amount)
+	}
+}
+
+func TestClient_ShareAccount(t *testing.T) {
+	store := memory.New()
+	alice, err := store.InsertUser("alice")
+	common.FatalIfError(t, err, "inserting user")
+	_, err = store.InsertUser("bob")
+	common.FatalIfError(t, err, "inserting user")
+	secret, err := storage.NewTokenSecret()
+	common.FatalIfError(t, err, "generating secret")
+	_, err = store.InsertToken("alice", storage.HashTokenSecret(secret), alice.ID)
+	common.FatalIfError(t, err, "inserting token")
+	r, err := router.New(store, router.WithTokenAuth(store))
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	c := Client{Host: srv.URL, Token: secret}
+
+	a, err := c.InsertAccount(*accountingtest.NewAccount(t, "joint", accountingtest.NewCurrencyCode(t, "GBP"), time.Date(2018, time.May, 1, 0, 0, 0, 0, time.UTC)))
+	common.FatalIfError(t, err, "inserting account")
+	s, err := c.ShareAccount(a.ID, "bob", storage.AccessWrite)
+	common.FatalIfError(t, err, "sharing account")
+	assert.Equal(t, &router.AccountShare{User: "bob", Access: storage.AccessWrite}, s)
+
+	_, err = c.ShareAccount(a.ID, "carol", storage.AccessRead)
+	_, ok := errors.Cause(err).(NotFoundError)
+	assert.True(t, ok, "%T", errors.Cause(err))
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/pkg/errors"
+)
+
+// Export retrieves a backup.Document holding the whole of the server's storage
+func (c Client) Export() (*backup.Document, error) {
+	return c.ExportContext(context.Background())
+}
+
+// ExportContext is the same as Export but its request can be cancelled with
+// the given context.Context.
+func (c Client) ExportContext(ctx context.Context) (*backup.Document, error) {
+	bod, err := c.getBodyFromEndpoint(ctx, router.EndpointExport)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting body from endpoint")
+	}
+	d := &backup.Document{}
+	err = errors.Wrap(json.Unmarshal(bod, d), "unmarshalling response")
+	if err != nil {
+		d = nil
+	}
+	return d, err
+}
+
+// Restore restores a backup.Document into the server's storage, which must be
+// empty, returning a summary of what was restored.
+func (c Client) Restore(d backup.Document) (*backup.Summary, error) {
+	return c.RestoreContext(context.Background(), d)
+}
+
+// RestoreContext is the same as Restore but its request can be cancelled with
+// the given context.Context.
+func (c Client) RestoreContext(ctx context.Context, d backup.Document) (*backup.Summary, error) {
+	res, err := c.postAsJSONToEndpoint(ctx, router.EndpointRestore, d)
+	if err != nil {
+		return nil, errors.Wrapf(err, "posting Document to endpoint %s", router.EndpointRestore)
+	}
+	bod, err := processResponseForBody(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "processing response")
+	}
+	s := &backup.Summary{}
+	err = errors.Wrapf(json.Unmarshal(bod, s), "json unmarshalling into summary. bytes as string: %s", bod)
+	if err != nil {
+		s = nil
+	}
+	return s, err
+}
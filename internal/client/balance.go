@@ -1,9 +1,11 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
-
 	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/glynternet/go-accounting/balance"
 	"github.com/glynternet/mon/internal/router"
@@ -13,11 +15,56 @@ import (
 
 // SelectAccountBalances will select the Balances that are stored for a given Account
 func (c Client) SelectAccountBalances(a storage.Account) (*storage.Balances, error) {
-	return c.getBalancesFromEndpoint(fmt.Sprintf(router.EndpointFmtAccountBalances, a.ID))
+	return c.SelectAccountBalancesContext(context.Background(), a)
+}
+
+// SelectAccountBalancesContext is the same as SelectAccountBalances but its
+// request can be cancelled with the given context.Context.
+func (c Client) SelectAccountBalancesContext(ctx context.Context, a storage.Account) (*storage.Balances, error) {
+	return c.getBalancesFromEndpoint(ctx, fmt.Sprintf(router.EndpointFmtAccountBalances, a.ID))
+}
+
+// SelectAccountBalancesWithOptions will select the Balances that are stored for
+// a given Account and that match the given BalancesOptions, in their order and
+// up to their limit. The next page of Balances can be selected by using the
+// Cursor of the last Balance of a page as the After option.
+func (c Client) SelectAccountBalancesWithOptions(a storage.Account, o storage.BalancesOptions) (*storage.Balances, error) {
+	return c.SelectAccountBalancesWithOptionsContext(context.Background(), a, o)
+}
+
+// SelectAccountBalancesWithOptionsContext is the same as
+// SelectAccountBalancesWithOptions but its request can be cancelled with the
+// given context.Context.
+func (c Client) SelectAccountBalancesWithOptionsContext(ctx context.Context, a storage.Account, o storage.BalancesOptions) (*storage.Balances, error) {
+	e := fmt.Sprintf(router.EndpointFmtAccountBalances, a.ID)
+	if q := balancesQuery(o); len(q) > 0 {
+		e += "?" + q.Encode()
+	}
+	return c.getBalancesFromEndpoint(ctx, e)
 }
 
-func (c Client) getBalancesFromEndpoint(e string) (*storage.Balances, error) {
-	bod, err := c.getBodyFromEndpoint(e)
+func balancesQuery(o storage.BalancesOptions) url.Values {
+	q := url.Values{}
+	if o.From != nil {
+		q.Set(router.QueryKeyFrom, o.From.String())
+	}
+	if o.To != nil {
+		q.Set(router.QueryKeyTo, o.To.String())
+	}
+	if o.After != nil {
+		q.Set(router.QueryKeyCursor, o.After.String())
+	}
+	if o.Limit != 0 {
+		q.Set(router.QueryKeyLimit, strconv.FormatUint(uint64(o.Limit), 10))
+	}
+	if o.Descending {
+		q.Set(router.QueryKeyOrder, router.OrderDescending)
+	}
+	return q
+}
+
+func (c Client) getBalancesFromEndpoint(ctx context.Context, e string) (*storage.Balances, error) {
+	bod, err := c.getBodyFromEndpoint(ctx, e)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting body from endpoint")
 	}
@@ -31,9 +78,15 @@ func (c Client) getBalancesFromEndpoint(e string) (*storage.Balances, error) {
 
 // InsertBalance will insert a balance for a given Account
 func (c Client) InsertBalance(a storage.Account, b balance.Balance) (*storage.Balance, error) {
+	return c.InsertBalanceContext(context.Background(), a, b)
+}
+
+// InsertBalanceContext is the same as InsertBalance but its request can be
+// cancelled with the given context.Context.
+func (c Client) InsertBalanceContext(ctx context.Context, a storage.Account, b balance.Balance) (*storage.Balance, error) {
 	endpoint := fmt.Sprintf(router.EndpointFmtAccountBalanceInsert, a.ID)
 	bs, err := c.postBalanceToEndpoint(
-		endpoint, b,
+		ctx, endpoint, b,
 	)
 	if err != nil {
 		return nil, errors.Wrapf(err, "posting Balance to endpoint %s", endpoint)
@@ -41,8 +94,43 @@ func (c Client) InsertBalance(a storage.Account, b balance.Balance) (*storage.Ba
 	return unmarshalJSONToBalance(bs)
 }
 
-func (c Client) postBalanceToEndpoint(e string, b balance.Balance) ([]byte, error) {
-	res, err := c.postAsJSONToEndpoint(e, b)
+// UpdateBalance will update a Balance of a given Account to reflect the details
+// of some other balance data
+func (c Client) UpdateBalance(a storage.Account, b *storage.Balance, us balance.Balance) (*storage.Balance, error) {
+	return c.UpdateBalanceContext(context.Background(), a, b, us)
+}
+
+// UpdateBalanceContext is the same as UpdateBalance but its request can be
+// cancelled with the given context.Context.
+func (c Client) UpdateBalanceContext(ctx context.Context, a storage.Account, b *storage.Balance, us balance.Balance) (*storage.Balance, error) {
+	endpoint := fmt.Sprintf(router.EndpointFmtAccountBalanceUpdate, a.ID, b.ID)
+	bs, err := c.postBalanceToEndpoint(ctx, endpoint, us)
+	if err != nil {
+		return nil, errors.Wrapf(err, "posting Balance to endpoint %s", endpoint)
+	}
+	return unmarshalJSONToBalance(bs)
+}
+
+// DeleteBalance will attempt to delete a Balance of a given Account through
+// the mon server
+func (c Client) DeleteBalance(a storage.Account, b *storage.Balance) error {
+	return c.DeleteBalanceContext(context.Background(), a, b)
+}
+
+// DeleteBalanceContext is the same as DeleteBalance but its request can be
+// cancelled with the given context.Context.
+func (c Client) DeleteBalanceContext(ctx context.Context, a storage.Account, b *storage.Balance) error {
+	endpoint := fmt.Sprintf(router.EndpointFmtAccountBalance, a.ID, b.ID)
+	r, err := c.deleteToEndpoint(ctx, endpoint)
+	if err != nil {
+		return errors.Wrapf(err, "deleting balance to endpoint %s", endpoint)
+	}
+	_, err = processResponseForBody(r)
+	return errors.Wrap(err, "processing response")
+}
+
+func (c Client) postBalanceToEndpoint(ctx context.Context, e string, b balance.Balance) ([]byte, error) {
+	res, err := c.postAsJSONToEndpoint(ctx, e, b)
 	if err != nil {
 		return nil, errors.Wrap(err, "posting as JSON")
 	}
@@ -1,20 +1,28 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/glynternet/go-accounting/accountingtest"
 	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetBalancesFromEndpoint(t *testing.T) {
 	t.Run("get body error", func(t *testing.T) {
-		c := Client("bloopybloop")
-		as, err := c.getBalancesFromEndpoint("")
+		c := Client{Host: "bloopybloop"}
+		as, err := c.getBalancesFromEndpoint(context.Background(), "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "getting from endpoint")
 		assert.Nil(t, as)
@@ -26,8 +34,8 @@ func TestGetBalancesFromEndpoint(t *testing.T) {
 			http.StatusOK,
 		)
 		defer srv.Close()
-		c := Client(srv.URL)
-		bs, err := c.getBalancesFromEndpoint("")
+		c := Client{Host: srv.URL}
+		bs, err := c.getBalancesFromEndpoint(context.Background(), "")
 		if assert.Error(t, err) {
 			assert.IsType(t, &json.UnmarshalTypeError{}, errors.Cause(err))
 		}
@@ -37,7 +45,7 @@ func TestGetBalancesFromEndpoint(t *testing.T) {
 
 func TestClient_postBalanceToEndpoint(t *testing.T) {
 	t.Run("post as json error", func(t *testing.T) {
-		bod, err := Client("BLOOOOP").postBalanceToEndpoint("", balance.Balance{})
+		bod, err := Client{Host: "BLOOOOP"}.postBalanceToEndpoint(context.Background(), "", balance.Balance{})
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "posting as JSON")
 		}
@@ -48,10 +56,44 @@ func TestClient_postBalanceToEndpoint(t *testing.T) {
 		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusBadRequest)
 		}))
-		bod, err := Client(srv.URL).postBalanceToEndpoint("", balance.Balance{})
+		bod, err := Client{Host: srv.URL}.postBalanceToEndpoint(context.Background(), "", balance.Balance{})
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "server returned unexpected code ")
 		}
 		assert.Empty(t, bod)
 	})
 }
+
+func TestSelectAccountBalancesWithOptions(t *testing.T) {
+	opened := date.New(2018, time.May, 4)
+	store := memory.New()
+	a, err := store.InsertAccount(*accountingtest.NewAccount(t, "current", accountingtest.NewCurrencyCode(t, "GBP"), opened.Time()))
+	common.FatalIfError(t, err, "inserting account")
+	for days := 0; days < 5; days++ {
+		_, err := store.InsertBalance(*a, balance.Balance{Date: opened.AddDays(days).Time(), Amount: days})
+		common.FatalIfError(t, err, "inserting balance")
+	}
+	r, err := router.New(store)
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	c := Client{Host: srv.URL}
+
+	// pages of 2 Balances, latest first, from the second date onwards
+	from := opened.AddDays(1)
+	o := storage.BalancesOptions{From: &from, Limit: 2, Descending: true}
+	var amounts []int
+	for {
+		bs, err := c.SelectAccountBalancesWithOptions(*a, o)
+		common.FatalIfError(t, err, "selecting balances")
+		if len(*bs) == 0 {
+			break
+		}
+		for _, b := range *bs {
+			amounts = append(amounts, b.Amount)
+		}
+		after := (*bs)[len(*bs)-1].Cursor()
+		o.After = &after
+	}
+	assert.Equal(t, []int{4, 3, 2, 1}, amounts)
+}
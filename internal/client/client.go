@@ -2,8 +2,8 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
@@ -14,7 +14,26 @@ import (
 )
 
 // Client is a client to retrieve accounting items over http using REST
-type Client string
+type Client struct {
+	// Host is the URL of the mon server, e.g. http://localhost:80
+	Host string
+	// Token is the secret of the Token that requests are authenticated with.
+	// Requests are not authenticated when it is empty.
+	Token string
+	// HTTPClient sends the requests of the Client, such as one whose
+	// transport uses a TLS configuration from tlsconfig.Client. A default
+	// http.Client is used when it is nil.
+	HTTPClient *http.Client
+}
+
+// httpClient returns the http.Client of the Client, or the given default when
+// the Client does not have one.
+func (c Client) httpClient(def *http.Client) *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return def
+}
 
 // newClient provides the client that should be used to make any calls against
 // the mon server
@@ -22,27 +41,53 @@ func newClient() *http.Client {
 	return &http.Client{Timeout: 5 * time.Second}
 }
 
-func (c Client) getFromEndpoint(endpoint string) (*http.Response, error) {
-	return http.Get(string(c) + endpoint)
+// newRequest creates a request for an endpoint of the mon server, which is
+// authenticated with the Token of the Client when it has one.
+func (c Client) newRequest(ctx context.Context, method, endpoint string, body io.Reader) (*http.Request, error) {
+	r, err := http.NewRequestWithContext(ctx, method, c.Host+endpoint, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating new request")
+	}
+	if c.Token != "" {
+		r.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return r, nil
+}
+
+func (c Client) getFromEndpoint(ctx context.Context, endpoint string) (*http.Response, error) {
+	r, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	return do(c.httpClient(http.DefaultClient), r)
 }
 
-func (c Client) postToEndpoint(endpoint string, contentType string, body io.Reader) (*http.Response, error) {
-	return http.Post(string(c)+endpoint, contentType, body)
+func (c Client) postToEndpoint(ctx context.Context, endpoint string, contentType string, body io.Reader) (*http.Response, error) {
+	r, err := c.newRequest(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	r.Header.Set("Content-Type", contentType)
+	return do(c.httpClient(http.DefaultClient), r)
 }
 
-func (c Client) deleteToEndpoint(endpoint string) (*http.Response, error) {
-	r, err := http.NewRequest(http.MethodDelete, string(c)+endpoint, nil)
+func (c Client) deleteToEndpoint(ctx context.Context, endpoint string) (*http.Response, error) {
+	r, err := c.newRequest(ctx, http.MethodDelete, endpoint, nil)
 	if err != nil {
-		return nil, errors.Wrap(err, "creating new request")
+		return nil, err
 	}
-	return newClient().Do(r)
+	return do(c.httpClient(newClient()), r)
 }
 
-// Available reports whether the mon server is available using the Client
-func (c Client) Available() bool {
-	// TODO: Deprecate Available in favour of something that returns more information
-	_, err := c.SelectAccounts()
-	return err == nil
+// do sends a request with the given http.Client. If the request fails because
+// its context.Context is done, the error of the context.Context is returned so
+// that it can be found as the cause of the error.
+func do(c *http.Client, r *http.Request) (*http.Response, error) {
+	res, err := c.Do(r)
+	if err != nil && r.Context().Err() != nil {
+		return nil, r.Context().Err()
+	}
+	return res, err
 }
 
 // Close is a noop closer as there is not behaviour required to close this client
@@ -50,8 +95,8 @@ func (c Client) Close() error {
 	return nil
 }
 
-func (c Client) getBodyFromEndpoint(e string) ([]byte, error) {
-	res, err := c.getFromEndpoint(e)
+func (c Client) getBodyFromEndpoint(ctx context.Context, e string) ([]byte, error) {
+	res, err := c.getFromEndpoint(ctx, e)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting from endpoint")
 	}
@@ -60,7 +105,7 @@ func (c Client) getBodyFromEndpoint(e string) ([]byte, error) {
 
 func processResponseForBody(r *http.Response) ([]byte, error) {
 	if r.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned unexpected code %d (%s)", r.StatusCode, r.Status)
+		return nil, newResponseError(r)
 	}
 	bod, err := ioutil.ReadAll(r.Body)
 
@@ -75,11 +120,11 @@ func processResponseForBody(r *http.Response) ([]byte, error) {
 	return bod, errors.Wrap(err, "reading response body")
 }
 
-func (c Client) postAsJSONToEndpoint(e string, thing interface{}) (*http.Response, error) {
+func (c Client) postAsJSONToEndpoint(ctx context.Context, e string, thing interface{}) (*http.Response, error) {
 	bs, err := json.Marshal(thing)
 	if err != nil {
 		return nil, errors.Wrap(err, "marshalling json")
 	}
-	res, err := c.postToEndpoint(e, `application/json; charset=UTF-8`, bytes.NewReader(bs))
+	res, err := c.postToEndpoint(ctx, e, `application/json; charset=UTF-8`, bytes.NewReader(bs))
 	return res, errors.Wrap(err, "posting to endpoint")
 }
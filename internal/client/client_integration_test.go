@@ -10,7 +10,9 @@ import (
 	"github.com/glynternet/go-accounting/balance"
 	"github.com/glynternet/go-money/common"
 	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
 	"github.com/glynternet/mon/pkg/storage/storagetest"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +27,7 @@ func TestClient_SelectAccounts(t *testing.T) {
 					t,
 					"test-0",
 					accountingtest.NewCurrencyCode(t, "EUR"),
-					time.Now().UTC().Truncate(time.Nanosecond),
+					date.Today().Time(),
 				),
 			},
 			{
@@ -34,8 +36,7 @@ func TestClient_SelectAccounts(t *testing.T) {
 					t,
 					"test-1",
 					accountingtest.NewCurrencyCode(t, "GBP"),
-					// TODO: Revert this test to not have the UTC() and test when the timezone of the machine running is not UTC.
-					time.Now().UTC().Add(time.Hour*123).Truncate(time.Nanosecond),
+					date.Today().AddDays(5).Time(),
 				),
 			},
 		},
@@ -69,7 +70,7 @@ func TestClient_SelectAccount(t *testing.T) {
 				t,
 				"test",
 				accountingtest.NewCurrencyCode(t, "EUR"),
-				time.Now().UTC().Truncate(time.Nanosecond),
+				date.Today().Time(),
 			),
 		},
 	}
@@ -192,6 +193,19 @@ func TestClient_InsertBalance(t *testing.T) {
 	common.FatalIfError(t, <-errCh, "received error")
 }
 
+func TestClient_memoryStorage(t *testing.T) {
+	router, listener, client := newTestComponents(t, memory.New())
+
+	go func() {
+		_ = http.Serve(listener, router)
+	}()
+	defer func() {
+		common.FatalIfError(t, listener.Close(), "closing listener")
+	}()
+
+	storagetest.Test(t, client)
+}
+
 func newTestComponents(t *testing.T, s storage.Storage) (*mux.Router, net.Listener, Client) {
 	r := newTestRouter(t, s)
 	l := newTestNetListener(t)
@@ -218,5 +232,5 @@ func newTestNetListener(t *testing.T) net.Listener {
 }
 
 func newTestClient(l net.Listener) Client {
-	return Client("http://" + l.Addr().String())
+	return Client{Host: "http://" + l.Addr().String()}
 }
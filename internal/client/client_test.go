@@ -1,24 +1,39 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/internal/tlsconfig"
+	"github.com/glynternet/mon/internal/tlsconfig/tlsconfigtest"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 // ensure that a Client can be used as a storage.Storage
-var _ storage.Storage = Client("")
+var _ storage.Storage = Client{}
+
+// ensure that a Client can be used as a storage.ContextStorage
+var _ storage.ContextStorage = Client{}
 
 func Test_getBodyFromEndpoint(t *testing.T) {
 	t.Run("get error", func(t *testing.T) {
-		c := Client("bloopybloop")
-		bod, err := c.getBodyFromEndpoint("")
+		c := Client{Host: "bloopybloop"}
+		bod, err := c.getBodyFromEndpoint(context.Background(), "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "getting from endpoint")
 		assert.Nil(t, bod)
@@ -27,14 +42,185 @@ func Test_getBodyFromEndpoint(t *testing.T) {
 	t.Run("unexpected status", func(t *testing.T) {
 		srv := newJSONTestServer(nil, http.StatusTeapot)
 		defer srv.Close()
-		c := Client(srv.URL)
-		as, err := c.getBodyFromEndpoint("")
+		c := Client{Host: srv.URL}
+		as, err := c.getBodyFromEndpoint(context.Background(), "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "server returned unexpected code")
 		assert.Nil(t, as)
 	})
 }
 
+func TestClient_Context(t *testing.T) {
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+	c := Client{Host: srv.URL}
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		as, err := c.SelectAccountsContext(ctx)
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+		assert.Nil(t, as)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := c.DeleteAccountContext(ctx, 1)
+		assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	})
+}
+
+func TestClient_Token(t *testing.T) {
+	store := memory.New()
+	secret, err := storage.NewTokenSecret()
+	common.FatalIfError(t, err, "generating secret")
+	_, err = store.InsertToken("test", storage.HashTokenSecret(secret), 0)
+	common.FatalIfError(t, err, "inserting token")
+	r, err := router.New(store, router.WithTokenAuth(store))
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	t.Run("without token", func(t *testing.T) {
+		_, err := Client{Host: srv.URL}.SelectAccounts()
+		e, ok := errors.Cause(err).(UnauthorizedError)
+		if assert.True(t, ok, "%T", errors.Cause(err)) {
+			assert.Equal(t, http.StatusUnauthorized, e.StatusCode)
+		}
+	})
+
+	t.Run("with token", func(t *testing.T) {
+		as, err := Client{Host: srv.URL, Token: secret}.SelectAccounts()
+		assert.NoError(t, err)
+		assert.NotNil(t, as)
+	})
+}
+
+func TestClient_Status(t *testing.T) {
+	store := memory.New()
+	r, err := router.New(store, router.WithTokenAuth(store))
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	c := Client{Host: srv.URL}
+
+	t.Run("ready", func(t *testing.T) {
+		s, err := c.Status()
+		common.FatalIfError(t, err, "getting status")
+		assert.Equal(t, router.StatusOK, s.Status)
+		assert.NotEmpty(t, s.Checks)
+		assert.True(t, c.Available())
+	})
+
+	t.Run("not ready", func(t *testing.T) {
+		common.FatalIfError(t, store.Close(), "closing storage")
+		s, err := c.Status()
+		common.FatalIfError(t, err, "getting status")
+		assert.Equal(t, router.StatusUnavailable, s.Status)
+		assert.False(t, c.Available())
+	})
+
+	t.Run("no server", func(t *testing.T) {
+		_, err := Client{Host: "http://localhost:0"}.Status()
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_InsertCheckedTransaction(t *testing.T) {
+	store := memory.New()
+	r, err := router.New(store)
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	c := Client{Host: srv.URL}
+
+	opened := date.New(2018, time.May, 4)
+	var as []storage.Account
+	for _, name := range []string{"source", "destination"} {
+		a, err := store.InsertAccount(*accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), opened.Time()))
+		common.FatalIfError(t, err, "inserting account")
+		for _, b := range []balance.Balance{
+			{Date: opened.Time(), Amount: 100},
+			{Date: opened.AddDays(2).Time(), Amount: 90},
+		} {
+			_, err := store.InsertBalance(*a, b)
+			common.FatalIfError(t, err, "inserting balance")
+		}
+		as = append(as, *a)
+	}
+
+	inserted, err := c.InsertCheckedTransaction(storage.Transaction{
+		Date:          opened.AddDays(1),
+		Amount:        10,
+		SourceID:      as[0].ID,
+		DestinationID: as[1].ID,
+	})
+	common.FatalIfError(t, err, "inserting transaction")
+	assert.NotZero(t, inserted.ID)
+	if assert.Len(t, inserted.Checks, 2) {
+		for i, a := range as {
+			check := inserted.Checks[i]
+			assert.Equal(t, a.ID, check.AccountID)
+			if assert.NotNil(t, check.Interval) {
+				assert.True(t, check.Interval.FromDate().Equal(opened))
+				assert.True(t, check.Interval.ToDate().Equal(opened.AddDays(2)))
+			}
+		}
+		assert.True(t, inserted.Checks[0].Interval.Reconciled())
+		assert.Equal(t, -20, inserted.Checks[1].Interval.Unexplained())
+	}
+}
+
+func TestClient_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mon-client")
+	common.FatalIfError(t, err, "creating temp dir")
+	defer func() {
+		common.FatalIfError(t, os.RemoveAll(dir), "removing temp dir")
+	}()
+	fs := tlsconfigtest.Generate(t, dir)
+
+	r, err := router.New(memory.New())
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewUnstartedServer(r)
+	srv.TLS, err = tlsconfig.Server(fs.ServerCert, fs.ServerKey, fs.CA)
+	common.FatalIfError(t, err, "creating server config")
+	srv.StartTLS()
+	defer srv.Close()
+
+	newClient := func(t *testing.T, cert, key string) Client {
+		c, err := tlsconfig.Client(fs.CA, cert, key)
+		common.FatalIfError(t, err, "creating client config")
+		return Client{
+			Host:       srv.URL,
+			HTTPClient: &http.Client{Transport: &http.Transport{TLSClientConfig: c}},
+		}
+	}
+
+	t.Run("with client certificate", func(t *testing.T) {
+		as, err := newClient(t, fs.ClientCert, fs.ClientKey).SelectAccounts()
+		assert.NoError(t, err)
+		assert.NotNil(t, as)
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		_, err := newClient(t, "", "").SelectAccounts()
+		assert.Error(t, err)
+	})
+
+	t.Run("default http client", func(t *testing.T) {
+		_, err := Client{Host: srv.URL}.SelectAccounts()
+		assert.Error(t, err, "the test CA should not be trusted by default")
+	})
+}
+
 type stubMarshal struct {
 	err error
 }
@@ -45,11 +231,11 @@ func (m stubMarshal) MarshalJSON() ([]byte, error) {
 
 func Test_postAsJSONToEndpoint(t *testing.T) {
 	t.Run("marshal error", func(t *testing.T) {
-		c := Client("bloopybloop")
+		c := Client{Host: "bloopybloop"}
 		obj := stubMarshal{
 			err: errors.New("can't unmarshal me"),
 		}
-		res, err := c.postAsJSONToEndpoint("", obj)
+		res, err := c.postAsJSONToEndpoint(context.Background(), "", obj)
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "can't unmarshal me")
 		}
@@ -57,8 +243,8 @@ func Test_postAsJSONToEndpoint(t *testing.T) {
 	})
 
 	t.Run("post to endpoint error", func(t *testing.T) {
-		c := Client("bloopybleep")
-		res, err := c.postAsJSONToEndpoint("", nil)
+		c := Client{Host: "bloopybleep"}
+		res, err := c.postAsJSONToEndpoint(context.Background(), "", nil)
 		if assert.Error(t, err) {
 			assert.Contains(t, err.Error(), "posting to endpoint")
 		}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/pkg/errors"
+)
+
+// ResponseError is an error response returned by the mon server. An error
+// response is returned as a NotFoundError, ValidationError, UnauthorizedError,
+// ForbiddenError or UnavailableError when its status is one of theirs, which
+// can be found with errors.Cause.
+type ResponseError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Message describes what went wrong
+	Message string
+	// Fields holds a description of each field that failed validation
+	Fields []string
+	// RequestID is the ID that the server gave the request
+	RequestID string
+}
+
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("server returned unexpected code %d (%s)", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// NotFoundError is returned when the mon server could not find what a request
+// was for.
+type NotFoundError struct{ *ResponseError }
+
+// ValidationError is returned when the mon server rejected a request as being
+// invalid, such as when it holds an invalid Account.
+type ValidationError struct{ *ResponseError }
+
+// UnauthorizedError is returned when the mon server requires requests to be
+// authenticated and the Token of the Client is missing, unknown or revoked.
+type UnauthorizedError struct{ *ResponseError }
+
+// ForbiddenError is returned when the User of the Token of the Client does not
+// have the access to an Account that a request requires.
+type ForbiddenError struct{ *ResponseError }
+
+// UnavailableError is returned when the mon server was unable to use its
+// storage.
+type UnavailableError struct{ *ResponseError }
+
+// newResponseError creates the error for a response with an unexpected
+// status, using the router.ErrorResponse in its body when it has one.
+func newResponseError(r *http.Response) error {
+	e := &ResponseError{
+		StatusCode: r.StatusCode,
+		RequestID:  r.Header.Get(router.HeaderRequestID),
+	}
+	bod, err := ioutil.ReadAll(r.Body)
+	if cErr := r.Body.Close(); cErr != nil {
+		log.Print(errors.Wrap(cErr, "closing response body"))
+	}
+	if err != nil {
+		log.Print(errors.Wrap(err, "reading error response body"))
+	}
+	var res router.ErrorResponse
+	if err == nil && json.Unmarshal(bod, &res) == nil && res.Code != 0 {
+		e.Message = res.Message
+		e.Fields = res.Fields
+		if res.RequestID != "" {
+			e.RequestID = res.RequestID
+		}
+	} else if err == nil {
+		e.Message = strings.TrimSpace(string(bod))
+	}
+
+	switch r.StatusCode {
+	case http.StatusNotFound:
+		return NotFoundError{e}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ValidationError{e}
+	case http.StatusUnauthorized:
+		return UnauthorizedError{e}
+	case http.StatusForbidden:
+		return ForbiddenError{e}
+	case http.StatusServiceUnavailable:
+		return UnavailableError{e}
+	}
+	return e
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResponseError(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		code  int
+		check func(t *testing.T, err error) *ResponseError
+	}{
+		{
+			name: "not found",
+			code: http.StatusNotFound,
+			check: func(t *testing.T, err error) *ResponseError {
+				e, ok := err.(NotFoundError)
+				assert.True(t, ok)
+				return e.ResponseError
+			},
+		},
+		{
+			name: "validation",
+			code: http.StatusBadRequest,
+			check: func(t *testing.T, err error) *ResponseError {
+				e, ok := err.(ValidationError)
+				assert.True(t, ok)
+				return e.ResponseError
+			},
+		},
+		{
+			name: "unauthorized",
+			code: http.StatusUnauthorized,
+			check: func(t *testing.T, err error) *ResponseError {
+				e, ok := err.(UnauthorizedError)
+				assert.True(t, ok)
+				return e.ResponseError
+			},
+		},
+		{
+			name: "forbidden",
+			code: http.StatusForbidden,
+			check: func(t *testing.T, err error) *ResponseError {
+				e, ok := err.(ForbiddenError)
+				assert.True(t, ok)
+				return e.ResponseError
+			},
+		},
+		{
+			name: "unavailable",
+			code: http.StatusServiceUnavailable,
+			check: func(t *testing.T, err error) *ResponseError {
+				e, ok := err.(UnavailableError)
+				assert.True(t, ok)
+				return e.ResponseError
+			},
+		},
+		{
+			name: "other",
+			code: http.StatusTeapot,
+			check: func(t *testing.T, err error) *ResponseError {
+				e, ok := err.(*ResponseError)
+				assert.True(t, ok)
+				return e
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newJSONTestServer(router.ErrorResponse{
+				Code:      test.code,
+				Message:   "something went wrong",
+				Fields:    []string{"empty name"},
+				RequestID: "abc123",
+			}, test.code)
+			defer srv.Close()
+			_, err := Client{Host: srv.URL}.getBodyFromEndpoint(context.Background(), "")
+			e := test.check(t, errors.Cause(err))
+			if assert.NotNil(t, e) {
+				assert.Equal(t, &ResponseError{
+					StatusCode: test.code,
+					Message:    "something went wrong",
+					Fields:     []string{"empty name"},
+					RequestID:  "abc123",
+				}, e)
+			}
+		})
+	}
+
+	t.Run("plain text body", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "gone away", http.StatusBadGateway)
+		}))
+		defer srv.Close()
+		_, err := Client{Host: srv.URL}.getBodyFromEndpoint(context.Background(), "")
+		assert.Equal(t, &ResponseError{StatusCode: http.StatusBadGateway, Message: "gone away"}, errors.Cause(err))
+		assert.EqualError(t, errors.Cause(err), "server returned unexpected code 502 (Bad Gateway): gone away")
+	})
+}
+
+func TestClient_errors(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	store := memory.New()
+	a, err := store.InsertAccount(*accountingtest.NewAccount(t, "current", accountingtest.NewCurrencyCode(t, "GBP"), opened))
+	common.FatalIfError(t, err, "inserting account")
+	r, err := router.New(store)
+	common.FatalIfError(t, err, "creating router")
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	c := Client{Host: srv.URL}
+
+	_, err = c.SelectAccount(a.ID + 1)
+	if nf, ok := errors.Cause(err).(NotFoundError); assert.True(t, ok, "%T", errors.Cause(err)) {
+		assert.Equal(t, http.StatusNotFound, nf.StatusCode)
+		assert.NotEmpty(t, nf.Message)
+		assert.NotEmpty(t, nf.RequestID)
+	}
+
+	unknown := storage.Account{ID: a.ID + 1, Account: a.Account}
+	_, err = c.SelectAccountBalances(unknown)
+	_, ok := errors.Cause(err).(NotFoundError)
+	assert.True(t, ok, "%T", errors.Cause(err))
+	_, err = c.InsertBalance(unknown, balance.Balance{Date: opened})
+	_, ok = errors.Cause(err).(NotFoundError)
+	assert.True(t, ok, "%T", errors.Cause(err))
+
+	_, err = c.InsertBalance(*a, balance.Balance{Date: opened.AddDate(0, 0, -1)})
+	if v, ok := errors.Cause(err).(ValidationError); assert.True(t, ok, "%T", errors.Cause(err)) {
+		assert.Equal(t, http.StatusBadRequest, v.StatusCode)
+		assert.Contains(t, v.Message, "inserting balance")
+	}
+
+	err = c.DeleteAccount(a.ID + 1)
+	_, ok = errors.Cause(err).(NotFoundError)
+	assert.True(t, ok, "%T", errors.Cause(err))
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// InsertRate will insert a Rate
+func (c Client) InsertRate(r storage.Rate) (*storage.Rate, error) {
+	return c.InsertRateContext(context.Background(), r)
+}
+
+// InsertRateContext is the same as InsertRate but its request can be cancelled
+// with the given context.Context.
+func (c Client) InsertRateContext(ctx context.Context, r storage.Rate) (*storage.Rate, error) {
+	res, err := c.postAsJSONToEndpoint(ctx, router.EndpointRateInsert, r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "posting Rate to endpoint %s", router.EndpointRateInsert)
+	}
+	bod, err := processResponseForBody(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "processing response")
+	}
+	inserted := &storage.Rate{}
+	err = errors.Wrapf(json.Unmarshal(bod, inserted), "json unmarshalling into rate. bytes as string: %s", bod)
+	if err != nil {
+		inserted = nil
+	}
+	return inserted, err
+}
+
+// SelectRates will select all of the Rates that are stored
+func (c Client) SelectRates() (*storage.Rates, error) {
+	return c.SelectRatesContext(context.Background())
+}
+
+// SelectRatesContext is the same as SelectRates but its request can be
+// cancelled with the given context.Context.
+func (c Client) SelectRatesContext(ctx context.Context) (*storage.Rates, error) {
+	bod, err := c.getBodyFromEndpoint(ctx, router.EndpointRates)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting body from endpoint")
+	}
+	rs := &storage.Rates{}
+	err = errors.Wrap(json.Unmarshal(bod, rs), "unmarshalling response")
+	if err != nil {
+		rs = nil
+	}
+	return rs, err
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/report"
+	"github.com/pkg/errors"
+)
+
+// NetWorth will retrieve the net worth report of the mon server, holding the
+// total amount of each currency at each date from the from date up to and
+// including the to date, separated by the given interval
+func (c Client) NetWorth(from, to date.Date, i report.Interval) ([]report.Series, error) {
+	return c.NetWorthContext(context.Background(), from, to, i)
+}
+
+// NetWorthContext is the same as NetWorth but its request can be cancelled
+// with the given context.Context.
+func (c Client) NetWorthContext(ctx context.Context, from, to date.Date, i report.Interval) ([]report.Series, error) {
+	q := url.Values{}
+	q.Set(router.QueryKeyFrom, from.String())
+	q.Set(router.QueryKeyTo, to.String())
+	q.Set(router.QueryKeyInterval, string(i))
+	bod, err := c.getBodyFromEndpoint(ctx, router.EndpointReportNetWorth+"?"+q.Encode())
+	if err != nil {
+		return nil, errors.Wrap(err, "getting body from endpoint")
+	}
+	var ss []report.Series
+	err = errors.Wrap(json.Unmarshal(bod, &ss), "unmarshalling response")
+	if err != nil {
+		ss = nil
+	}
+	return ss, err
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/pkg/errors"
+)
+
+// Available reports whether the mon server is ready, with each of its
+// readiness checks passing. Status gives the result of each check.
+func (c Client) Available() bool {
+	return c.AvailableContext(context.Background())
+}
+
+// AvailableContext is the same as Available but its request can be cancelled
+// with the given context.Context.
+func (c Client) AvailableContext(ctx context.Context) bool {
+	s, err := c.StatusContext(ctx)
+	return err == nil && s.Status == router.StatusOK
+}
+
+// Status retrieves the result of the readiness checks of the mon server. A
+// server that is not ready still replies with a Status, so an error is only
+// returned when no Status could be retrieved.
+func (c Client) Status() (*router.Status, error) {
+	return c.StatusContext(context.Background())
+}
+
+// StatusContext is the same as Status but its request can be cancelled with
+// the given context.Context.
+func (c Client) StatusContext(ctx context.Context) (*router.Status, error) {
+	res, err := c.getFromEndpoint(ctx, router.EndpointReady)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting from endpoint")
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusServiceUnavailable {
+		return nil, newResponseError(res)
+	}
+	bod, err := ioutil.ReadAll(res.Body)
+	if cErr := res.Body.Close(); cErr != nil {
+		log.Print(errors.Wrap(cErr, "closing response body"))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+	var s router.Status
+	err = json.Unmarshal(bod, &s)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshalling response")
+	}
+	return &s, nil
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// InsertTransaction will insert a Transaction between the source and
+// destination Accounts that it refers to
+func (c Client) InsertTransaction(t storage.Transaction) (*storage.Transaction, error) {
+	return c.InsertTransactionContext(context.Background(), t)
+}
+
+// InsertTransactionContext is the same as InsertTransaction but its request can
+// be cancelled with the given context.Context.
+func (c Client) InsertTransactionContext(ctx context.Context, t storage.Transaction) (*storage.Transaction, error) {
+	inserted, err := c.InsertCheckedTransactionContext(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	return &inserted.Transaction, nil
+}
+
+// InsertCheckedTransaction inserts a Transaction, returning it along with the
+// check that the server makes of it against the Balances of each of its
+// Accounts.
+func (c Client) InsertCheckedTransaction(t storage.Transaction) (*router.InsertedTransaction, error) {
+	return c.InsertCheckedTransactionContext(context.Background(), t)
+}
+
+// InsertCheckedTransactionContext is the same as InsertCheckedTransaction but
+// its request can be cancelled with the given context.Context.
+func (c Client) InsertCheckedTransactionContext(ctx context.Context, t storage.Transaction) (*router.InsertedTransaction, error) {
+	res, err := c.postAsJSONToEndpoint(ctx, router.EndpointTransactionInsert, t)
+	if err != nil {
+		return nil, errors.Wrapf(err, "posting Transaction to endpoint %s", router.EndpointTransactionInsert)
+	}
+	bod, err := processResponseForBody(res)
+	if err != nil {
+		return nil, errors.Wrap(err, "processing response")
+	}
+	inserted := &router.InsertedTransaction{}
+	err = errors.Wrapf(json.Unmarshal(bod, inserted), "json unmarshalling into transaction. bytes as string: %s", bod)
+	if err != nil {
+		inserted = nil
+	}
+	return inserted, err
+}
+
+// SelectTransactions will select all of the Transactions that are stored
+func (c Client) SelectTransactions() (*storage.Transactions, error) {
+	return c.SelectTransactionsContext(context.Background())
+}
+
+// SelectTransactionsContext is the same as SelectTransactions but its request
+// can be cancelled with the given context.Context.
+func (c Client) SelectTransactionsContext(ctx context.Context) (*storage.Transactions, error) {
+	return c.getTransactionsFromEndpoint(ctx, router.EndpointTransactions)
+}
+
+// SelectAccountTransactions will select the Transactions that a given Account
+// is either the source or destination of
+func (c Client) SelectAccountTransactions(a storage.Account) (*storage.Transactions, error) {
+	return c.SelectAccountTransactionsContext(context.Background(), a)
+}
+
+// SelectAccountTransactionsContext is the same as SelectAccountTransactions
+// but its request can be cancelled with the given context.Context.
+func (c Client) SelectAccountTransactionsContext(ctx context.Context, a storage.Account) (*storage.Transactions, error) {
+	return c.getTransactionsFromEndpoint(ctx, fmt.Sprintf(router.EndpointFmtAccountTransactions, a.ID))
+}
+
+func (c Client) getTransactionsFromEndpoint(ctx context.Context, e string) (*storage.Transactions, error) {
+	bod, err := c.getBodyFromEndpoint(ctx, e)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting body from endpoint")
+	}
+	ts := &storage.Transactions{}
+	err = errors.Wrap(json.Unmarshal(bod, ts), "unmarshalling response")
+	if err != nil {
+		ts = nil
+	}
+	return ts, err
+}
@@ -1,12 +1,19 @@
 package router
 
 import (
-	"io/ioutil"
-	"log"
+	"context"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/internal/accountbalance"
+	"github.com/glynternet/mon/internal/sort"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/filter"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
@@ -14,54 +21,250 @@ import (
 
 // TODO: redesign these so that they don't need to take a request? There could
 // TODO: be multiple handler types either take a request or don't take a request
-func (env *environment) handlerSelectAccounts(_ *http.Request) (int, interface{}, error) {
-	as, err := env.storage.SelectAccounts()
+// accountsQuery holds the filtering and sorting of a request for Accounts
+type accountsQuery struct {
+	conditions filter.AccountConditions
+	sort       func(storage.Accounts)
+	descending bool
+}
+
+func (env *environment) muxAccountsHandlerFunc(r *http.Request) (int, interface{}, error) {
+	q, err := parseAccountsQuery(r.URL.Query())
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing accounts query")
+	}
+	return env.handlerSelectAccounts(r.Context(), q)
+}
+
+func (env *environment) handlerSelectAccounts(ctx context.Context, q accountsQuery) (int, interface{}, error) {
+	as, err := env.selectAccounts(ctx, q)
 	if err != nil {
 		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "selecting Accounts from client")
 	}
 	return http.StatusOK, as, nil
 }
 
+// selectAccounts selects the Accounts from storage that match the
+// accountsQuery, in the order of the accountsQuery.
+func (env *environment) selectAccounts(ctx context.Context, q accountsQuery) (*storage.Accounts, error) {
+	as, err := env.contextStorage().SelectAccountsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.conditions) == 0 && q.sort == nil && !q.descending {
+		return as, nil
+	}
+	selected := storage.Accounts{}
+	for _, a := range *as {
+		if q.conditions.And(a) {
+			selected = append(selected, a)
+		}
+	}
+	if q.sort != nil {
+		q.sort(selected)
+	}
+	if q.descending {
+		for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+			selected[i], selected[j] = selected[j], selected[i]
+		}
+	}
+	return &selected, nil
+}
+
+func (env *environment) muxAccountsBalancesHandlerFunc(r *http.Request) (int, interface{}, error) {
+	vs := r.URL.Query()
+	q, err := parseAccountsQuery(vs)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing accounts query")
+	}
+	at := date.Today()
+	if value := vs.Get(QueryKeyAt); value != "" {
+		at, err = date.Parse(value)
+		if err != nil {
+			return http.StatusBadRequest, nil, errors.Wrapf(err, "parsing %s date", QueryKeyAt)
+		}
+	}
+	return env.handlerSelectAccountsBalances(r.Context(), q, at)
+}
+
+func (env *environment) handlerSelectAccountsBalances(ctx context.Context, q accountsQuery, at date.Date) (int, interface{}, error) {
+	as, err := env.selectAccounts(ctx, q)
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "selecting Accounts from storage")
+	}
+	bs, err := env.balancesAt(ctx, *as, at.Time())
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrapf(err, "selecting Balances at %s from storage", at)
+	}
+	abs := []accountbalance.AccountBalance{}
+	for _, a := range *as {
+		if b, ok := bs[a.ID]; ok {
+			abs = append(abs, accountbalance.AccountBalance{Account: a, Balance: b.Balance})
+		}
+	}
+	return http.StatusOK, abs, nil
+}
+
+// balancesAt returns the Balance of each of the given Accounts at a time,
+// keyed by Account ID. A storage.BalancesAtStorage selects them in a single
+// operation, otherwise the Balances of each Account are selected in turn.
+func (env *environment) balancesAt(ctx context.Context, as storage.Accounts, t time.Time) (map[uint]storage.Balance, error) {
+	cs := env.contextStorage()
+	if store, ok := cs.(storage.BalancesAtStorage); ok {
+		return store.SelectBalancesAtContext(ctx, t)
+	}
+	at := make(map[uint]storage.Balance)
+	for _, a := range as {
+		bs, err := cs.SelectAccountBalancesContext(ctx, a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "selecting Balances for Account with id %d", a.ID)
+		}
+		var latest *storage.Balance
+		for i := range *bs {
+			if b := &(*bs)[i]; !b.Date.After(t) && (latest == nil || !latest.Date.After(b.Date)) {
+				latest = b
+			}
+		}
+		if latest != nil {
+			at[a.ID] = *latest
+		}
+	}
+	return at, nil
+}
+
+func parseAccountsQuery(vs url.Values) (accountsQuery, error) {
+	var q accountsQuery
+	for _, dateCondition := range []struct {
+		key       string
+		condition func(time.Time) filter.AccountCondition
+	}{
+		{key: QueryKeyOpenAt, condition: filter.OpenAt},
+		{key: QueryKeyExistedAt, condition: filter.Existed},
+	} {
+		value := vs.Get(dateCondition.key)
+		if value == "" {
+			continue
+		}
+		d, err := date.Parse(value)
+		if err != nil {
+			return accountsQuery{}, errors.Wrapf(err, "parsing %s date", dateCondition.key)
+		}
+		q.conditions = append(q.conditions, dateCondition.condition(d.Time()))
+	}
+
+	ids, err := queryIDs(vs, QueryKeyIDs)
+	if err != nil {
+		return accountsQuery{}, err
+	}
+	if len(ids) > 0 {
+		q.conditions = append(q.conditions, filter.IDs(ids...))
+	}
+
+	notIDs, err := queryIDs(vs, QueryKeyNotIDs)
+	if err != nil {
+		return accountsQuery{}, err
+	}
+	if len(notIDs) > 0 {
+		q.conditions = append(q.conditions, filter.Not(filter.IDs(notIDs...)))
+	}
+
+	var codes []currency.Code
+	for _, value := range queryValues(vs, QueryKeyCurrency) {
+		c, err := currency.NewCode(strings.ToUpper(value))
+		if err != nil {
+			return accountsQuery{}, errors.Wrapf(err, "parsing %s %q", QueryKeyCurrency, value)
+		}
+		codes = append(codes, *c)
+	}
+	if len(codes) > 0 {
+		q.conditions = append(q.conditions, filter.Currencies(codes...))
+	}
+
+	if key := vs.Get(QueryKeySort); key != "" {
+		s, ok := sort.AccountSorts()[key]
+		if !ok {
+			return accountsQuery{}, errors.Errorf("unsupported %s key %q", QueryKeySort, key)
+		}
+		q.sort = s
+	}
+
+	descending, err := queryDescending(vs)
+	if err != nil {
+		return accountsQuery{}, err
+	}
+	q.descending = descending
+	return q, nil
+}
+
+// queryDescending returns true if the query orders in descending order
+func queryDescending(vs url.Values) (bool, error) {
+	switch order := vs.Get(QueryKeyOrder); order {
+	case "", OrderAscending:
+		return false, nil
+	case OrderDescending:
+		return true, nil
+	default:
+		return false, errors.Errorf("unsupported %s %q", QueryKeyOrder, order)
+	}
+}
+
+// queryValues returns the values of a query parameter that can be given as
+// repeated or comma-separated values
+func queryValues(vs url.Values, key string) []string {
+	var values []string
+	for _, v := range vs[key] {
+		for _, value := range strings.Split(v, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func queryIDs(vs url.Values, key string) ([]uint, error) {
+	var ids []uint
+	for _, value := range queryValues(vs, key) {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", key)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
 func (env *environment) muxAccountIDHandlerFunc(r *http.Request) (int, interface{}, error) {
 	id, err := extractID(mux.Vars(r))
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
 	}
-	return env.handlerSelectAccount(id)
+	return env.handlerSelectAccount(r.Context(), id)
 }
 
-func (env *environment) handlerSelectAccount(id uint) (int, interface{}, error) {
-	a, err := env.storage.SelectAccount(id)
+func (env *environment) handlerSelectAccount(ctx context.Context, id uint) (int, interface{}, error) {
+	a, err := env.contextStorage().SelectAccountContext(ctx, id)
 	if err != nil {
-		return http.StatusNotFound, nil, errors.Wrapf(err, "selecting Account with id:%d from storage", id)
+		return accountErrorStatus(err), nil, errors.Wrapf(err, "selecting Account with id:%d from storage", id)
 	}
 	return http.StatusOK, a, nil
 }
 
 func (env *environment) muxAccountInsertHandlerFunc(r *http.Request) (int, interface{}, error) {
-	bod, err := ioutil.ReadAll(r.Body)
+	bod, err := readBody(r)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrapf(err, "reading request body")
+		return http.StatusBadRequest, nil, err
 	}
 
-	defer func() {
-		// TODO: this handler only needs to take a []byte which would mean we
-		// TODO: can handle closing the body elsewhere
-		cErr := r.Body.Close()
-		if cErr != nil {
-			log.Print(errors.Wrap(err, "closing request body"))
-		}
-	}()
-
 	a, err := account.UnmarshalJSON(bod)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
 	}
-	return env.handlerInsertAccount(*a)
+	return env.handlerInsertAccount(r.Context(), *a)
 }
 
-func (env *environment) handlerInsertAccount(a account.Account) (int, interface{}, error) {
-	inserted, err := env.storage.InsertAccount(a)
+func (env *environment) handlerInsertAccount(ctx context.Context, a account.Account) (int, interface{}, error) {
+	inserted, err := env.contextStorage().InsertAccountContext(ctx, a)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrap(err, "inserting Account into storage")
 	}
@@ -74,34 +277,26 @@ func (env *environment) muxAccountUpdateHandlerFunc(r *http.Request) (int, inter
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
 	}
 
-	o, err := env.storage.SelectAccount(id)
+	o, err := env.contextStorage().SelectAccountContext(r.Context(), id)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrapf(err, "selecting account with id:%d", id)
+		return accountErrorStatus(err), nil, errors.Wrapf(err, "selecting account with id:%d", id)
 	}
 
-	bod, err := ioutil.ReadAll(r.Body)
+	bod, err := readBody(r)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrapf(err, "reading request body")
+		return http.StatusBadRequest, nil, err
 	}
 
-	defer func() {
-		// TODO: this handler only needs to take a []byte which would mean we can handle closing the body elsewhere
-		cErr := r.Body.Close()
-		if cErr != nil {
-			log.Print(errors.Wrap(err, "closing request body"))
-		}
-	}()
-
 	updates, err := account.UnmarshalJSON(bod)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
 	}
 
-	return env.handlerUpdateAccount(*o, *updates)
+	return env.handlerUpdateAccount(r.Context(), *o, *updates)
 }
 
-func (env *environment) handlerUpdateAccount(a storage.Account, updates account.Account) (int, interface{}, error) {
-	updated, err := env.storage.UpdateAccount(&a, &updates)
+func (env *environment) handlerUpdateAccount(ctx context.Context, a storage.Account, updates account.Account) (int, interface{}, error) {
+	updated, err := env.contextStorage().UpdateAccountContext(ctx, &a, &updates)
 	if err != nil {
 		return http.StatusBadRequest, nil, err
 	}
@@ -113,24 +308,39 @@ func (env *environment) muxAccountDeleteHandlerFunc(r *http.Request) (int, inter
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
 	}
-	return env.handlerDeleteAccount(id)
+	return env.handlerDeleteAccount(r.Context(), id)
 }
 
-func (env *environment) handlerDeleteAccount(id uint) (int, interface{}, error) {
-	err := env.storage.DeleteAccount(id)
+func (env *environment) handlerDeleteAccount(ctx context.Context, id uint) (int, interface{}, error) {
+	err := env.contextStorage().DeleteAccountContext(ctx, id)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrapf(err, "deleting Account with id:%d from storage", id)
+		return accountErrorStatus(err), nil, errors.Wrapf(err, "deleting Account with id:%d from storage", id)
 	}
 	return http.StatusOK, nil, nil
 }
 
+// accountErrorStatus returns the status of a response to a request that
+// failed to select or delete an Account. The Account is not found when the
+// error is caused by storage.ErrNoAccount, otherwise the storage is taken to
+// be unavailable.
+func accountErrorStatus(err error) int {
+	if errors.Cause(err) == storage.ErrNoAccount {
+		return http.StatusNotFound
+	}
+	return http.StatusServiceUnavailable
+}
+
 func extractID(vars map[string]string) (uint, error) {
+	return extractUintVar(vars, "id")
+}
+
+func extractUintVar(vars map[string]string, key string) (uint, error) {
 	if vars == nil {
 		return 0, errors.New("nil vars map")
 	}
-	idString, ok := vars["id"]
+	idString, ok := vars[key]
 	if !ok {
-		return 0, errors.New("no account id context variable")
+		return 0, errors.Errorf("no %s context variable", key)
 	}
 	id, err := strconv.ParseUint(idString, 10, 64)
 	return uint(id), errors.Wrapf(err, "parsing %s to uint", idString)
@@ -1,12 +1,18 @@
 package router
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/glynternet/go-accounting/account"
 	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/internal/accountbalance"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/glynternet/mon/pkg/storage/storagetest"
 	"github.com/pkg/errors"
@@ -19,7 +25,7 @@ func Test_handlerSelectAccounts(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{Err: expected},
 		}
-		code, as, err := server.handlerSelectAccounts(nil)
+		code, as, err := server.handlerSelectAccounts(context.Background(), accountsQuery{})
 		assert.Equal(t, http.StatusServiceUnavailable, code)
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Nil(t, as)
@@ -34,12 +40,188 @@ func Test_handlerSelectAccounts(t *testing.T) {
 				Accounts: expected,
 			},
 		}
-		code, as, err := server.handlerSelectAccounts(nil)
+		code, as, err := server.handlerSelectAccounts(context.Background(), accountsQuery{})
 		assert.Equal(t, http.StatusOK, code)
 		assert.NoError(t, err)
 		storeAs := as.(*storage.Accounts)
 		assert.Equal(t, expected, storeAs)
 	})
+
+	t.Run("request context cancelled", func(t *testing.T) {
+		server := &environment{
+			storage: &storagetest.Storage{
+				Accounts: &storage.Accounts{{ID: 8767}},
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r := httptest.NewRequest(http.MethodGet, EndpointAccounts, nil).WithContext(ctx)
+		code, as, err := server.muxAccountsHandlerFunc(r)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+		assert.Nil(t, as)
+	})
+}
+
+func Test_muxAccountsHandlerFunc(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	newAccount := func(id uint, name, code string, os ...account.Option) storage.Account {
+		return storage.Account{
+			ID:      id,
+			Account: *accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, code), opened, os...),
+		}
+	}
+	as := &storage.Accounts{
+		newAccount(1, "current", "GBP"),
+		newAccount(2, "savings", "EUR", account.CloseTime(opened.AddDate(0, 1, 0))),
+		newAccount(3, "bonds", "GBP"),
+	}
+	env := &environment{storage: &storagetest.Storage{Accounts: as}}
+
+	for _, test := range []struct {
+		query string
+		ids   []uint
+	}{
+		{query: "", ids: []uint{1, 2, 3}},
+		{query: "?open_at=2018-07-01", ids: []uint{1, 3}},
+		{query: "?existed_at=2018-05-03", ids: []uint{}},
+		{query: "?ids=1,2&ids=3&not_ids=2", ids: []uint{1, 3}},
+		{query: "?currency=eur", ids: []uint{2}},
+		{query: "?currency=EUR,GBP&sort=name", ids: []uint{3, 1, 2}},
+		{query: "?sort=id&order=desc", ids: []uint{3, 2, 1}},
+		{query: "?order=desc", ids: []uint{3, 2, 1}},
+		{query: "?currency=GBP&order=desc", ids: []uint{3, 1}},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			code, selected, err := env.muxAccountsHandlerFunc(httptest.NewRequest(http.MethodGet, EndpointAccounts+test.query, nil))
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, code)
+			ids := []uint{}
+			for _, a := range *selected.(*storage.Accounts) {
+				ids = append(ids, a.ID)
+			}
+			assert.Equal(t, test.ids, ids)
+		})
+	}
+
+	for _, query := range []string{
+		"?open_at=yesterday",
+		"?ids=one",
+		"?not_ids=-1",
+		"?currency=POUNDS",
+		"?sort=balance",
+		"?order=up",
+	} {
+		t.Run(query, func(t *testing.T) {
+			code, selected, err := env.muxAccountsHandlerFunc(httptest.NewRequest(http.MethodGet, EndpointAccounts+query, nil))
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Nil(t, selected)
+		})
+	}
+}
+
+// balancesAtStorage is a storage.BalancesAtStorage that returns the same
+// Balances for any time, recording the context.Context that they were
+// selected with in ctx when it is not nil
+type balancesAtStorage struct {
+	*storagetest.Storage
+	balancesAt map[uint]storage.Balance
+	ctx        *context.Context
+}
+
+func (s balancesAtStorage) SelectBalancesAt(time.Time) (map[uint]storage.Balance, error) {
+	return s.balancesAt, s.Err
+}
+
+func (s balancesAtStorage) SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]storage.Balance, error) {
+	if s.ctx != nil {
+		*s.ctx = ctx
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.SelectBalancesAt(t)
+}
+
+func Test_muxAccountsBalancesHandlerFunc(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	newAccount := func(id uint, name string) storage.Account {
+		return storage.Account{
+			ID:      id,
+			Account: *accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), opened),
+		}
+	}
+	as := &storage.Accounts{newAccount(1, "current"), newAccount(2, "savings")}
+	newBalance := func(id uint, days, amount int) storage.Balance {
+		return storage.Balance{ID: id, Balance: balance.Balance{Date: opened.AddDate(0, 0, days), Amount: amount}}
+	}
+
+	accountsBalances := func(t *testing.T, env *environment, query string) []accountbalance.AccountBalance {
+		code, abs, err := env.muxAccountsBalancesHandlerFunc(httptest.NewRequest(http.MethodGet, EndpointAccountsBalances+query, nil))
+		common.FatalIfError(t, err, "handling request")
+		assert.Equal(t, http.StatusOK, code)
+		return abs.([]accountbalance.AccountBalance)
+	}
+
+	t.Run("balances of each account", func(t *testing.T) {
+		env := &environment{storage: &storagetest.Storage{
+			Accounts: as,
+			Balances: &storage.Balances{newBalance(1, 0, 10), newBalance(2, 2, 20), newBalance(3, 2, 30), newBalance(4, 5, 40)},
+		}}
+		abs := accountsBalances(t, env, "?at=2018-05-07&sort=name&order=desc")
+		if assert.Len(t, abs, 2) {
+			assert.Equal(t, uint(2), abs[0].Account.ID)
+			assert.Equal(t, uint(1), abs[1].Account.ID)
+			assert.Equal(t, 30, abs[0].Amount)
+			assert.Equal(t, 30, abs[1].Amount)
+		}
+		assert.Len(t, accountsBalances(t, env, "?at=2018-05-03"), 0)
+	})
+
+	t.Run("balances at storage", func(t *testing.T) {
+		env := &environment{storage: balancesAtStorage{
+			Storage:    &storagetest.Storage{Accounts: as},
+			balancesAt: map[uint]storage.Balance{2: newBalance(5, 1, 50), 3: newBalance(6, 1, 60)},
+		}}
+		abs := accountsBalances(t, env, "?at=2018-05-07")
+		if assert.Len(t, abs, 1) {
+			assert.Equal(t, uint(2), abs[0].Account.ID)
+			assert.Equal(t, 50, abs[0].Amount)
+		}
+	})
+
+	t.Run("balances at storage uses request context", func(t *testing.T) {
+		type key struct{}
+		var selected context.Context
+		env := &environment{storage: balancesAtStorage{
+			Storage: &storagetest.Storage{Accounts: as},
+			ctx:     &selected,
+		}}
+		ctx := context.WithValue(context.Background(), key{}, "request")
+		_, _, err := env.handlerSelectAccountsBalances(ctx, accountsQuery{}, date.FromTime(opened))
+		common.FatalIfError(t, err, "handling request")
+		if assert.NotNil(t, selected) {
+			assert.Equal(t, "request", selected.Value(key{}))
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		env := &environment{storage: &storagetest.Storage{Accounts: as}}
+		for _, query := range []string{"?at=tomorrow", "?ids=one"} {
+			code, abs, err := env.muxAccountsBalancesHandlerFunc(httptest.NewRequest(http.MethodGet, EndpointAccountsBalances+query, nil))
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Nil(t, abs)
+		}
+
+		expected := errors.New("select balances test error")
+		env = &environment{storage: &storagetest.Storage{Accounts: as, BalancesErr: expected}}
+		code, abs, err := env.handlerSelectAccountsBalances(context.Background(), accountsQuery{}, date.FromTime(opened))
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, abs)
+	})
 }
 
 func Test_handlerSelectAccount(t *testing.T) {
@@ -48,12 +230,22 @@ func Test_handlerSelectAccount(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{AccountErr: expected},
 		}
-		code, a, err := server.handlerSelectAccount(1)
-		assert.Equal(t, http.StatusNotFound, code)
+		code, a, err := server.handlerSelectAccount(context.Background(), 1)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Nil(t, a)
 	})
 
+	t.Run("no account", func(t *testing.T) {
+		server := &environment{
+			storage: &storagetest.Storage{AccountErr: errors.Wrap(storage.ErrNoAccount, "account with id 1")},
+		}
+		code, a, err := server.handlerSelectAccount(context.Background(), 1)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+		assert.Nil(t, a)
+	})
+
 	t.Run("success", func(t *testing.T) {
 		expected := &storage.Account{
 			ID: 456789,
@@ -66,7 +258,7 @@ func Test_handlerSelectAccount(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{Account: expected},
 		}
-		code, a, err := server.handlerSelectAccount(1)
+		code, a, err := server.handlerSelectAccount(context.Background(), 1)
 		assert.Equal(t, http.StatusOK, code)
 		assert.NoError(t, err)
 		storeA := a.(*storage.Account)
@@ -80,7 +272,7 @@ func Test_handlerInsertAccount(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{AccountErr: expected},
 		}
-		code, inserted, err := server.handlerInsertAccount(account.Account{})
+		code, inserted, err := server.handlerInsertAccount(context.Background(), account.Account{})
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Nil(t, inserted)
 		assert.Equal(t, http.StatusBadRequest, code)
@@ -97,7 +289,7 @@ func Test_handlerInsertAccount(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{Account: expected},
 		}
-		code, inserted, err := server.handlerInsertAccount(expected.Account)
+		code, inserted, err := server.handlerInsertAccount(context.Background(), expected.Account)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, code)
 		assert.NotNil(t, inserted)
@@ -112,6 +304,7 @@ func Test_handlerUpdateAccount(t *testing.T) {
 			storage: &storagetest.Storage{AccountErr: expected},
 		}
 		code, updated, err := server.handlerUpdateAccount(
+			context.Background(),
 			storage.Account{},
 			account.Account{},
 		)
@@ -131,7 +324,7 @@ func Test_handlerUpdateAccount(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{Account: expected},
 		}
-		code, updated, err := server.handlerUpdateAccount(storage.Account{}, expected.Account)
+		code, updated, err := server.handlerUpdateAccount(context.Background(), storage.Account{}, expected.Account)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, code)
 		assert.NotNil(t, updated)
@@ -145,17 +338,27 @@ func Test_handlerDeleteAccount(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{AccountErr: expected},
 		}
-		code, body, err := server.handlerDeleteAccount(1)
-		assert.Equal(t, http.StatusBadRequest, code)
+		code, body, err := server.handlerDeleteAccount(context.Background(), 1)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Nil(t, body)
 	})
 
+	t.Run("no account", func(t *testing.T) {
+		server := &environment{
+			storage: &storagetest.Storage{AccountErr: errors.Wrap(storage.ErrNoAccount, "account with id 1")},
+		}
+		code, body, err := server.handlerDeleteAccount(context.Background(), 1)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+		assert.Nil(t, body)
+	})
+
 	t.Run("success", func(t *testing.T) {
 		server := &environment{
 			storage: &storagetest.Storage{},
 		}
-		code, body, err := server.handlerDeleteAccount(1)
+		code, body, err := server.handlerDeleteAccount(context.Background(), 1)
 		assert.Equal(t, http.StatusOK, code)
 		assert.NoError(t, err)
 		assert.Nil(t, body)
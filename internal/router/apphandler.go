@@ -1,59 +1,142 @@
 package router
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
-	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/mon/pkg/storage"
 	"github.com/pkg/errors"
 )
 
+// HeaderRequestID is the header that holds the ID of a request. The ID is
+// taken from the request when it is given, otherwise one is generated, and it
+// is always set on the response.
+const HeaderRequestID = "X-Request-ID"
+
+// ErrorResponse is the body of every response to a request that fails
+type ErrorResponse struct {
+	// Code is the HTTP status code of the response
+	Code int `json:"code"`
+	// Message describes what went wrong. The message of a server error is
+	// the status text of its Code, with the details of the error only being
+	// logged by the server.
+	Message string `json:"message"`
+	// Fields holds a description of each field that failed validation
+	Fields []string `json:"fields,omitempty"`
+	// RequestID is the ID of the request, which can be used to find the
+	// request in the logs of the server
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// readBody reads the whole body of a request and closes it
+func readBody(r *http.Request) ([]byte, error) {
+	bod, err := ioutil.ReadAll(r.Body)
+	if cErr := r.Body.Close(); cErr != nil {
+		log.Print(errors.Wrap(cErr, "closing request body"))
+	}
+	return bod, errors.Wrap(err, "reading request body")
+}
+
 type appJSONHandler func(*http.Request) (int, interface{}, error)
 
 // ServeHTTP makes our appJSONHandler function satisfy the http.HandlerFunc interface
 // We won't have written to our ResponseWriter within the appJSONHandler, so we
 // marshal our appJSONHandler's interface{} return value into some bytes as JSON
 func (ah appJSONHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := requestID(r)
+	w.Header().Set(HeaderRequestID, id)
+
 	status, bod, err := ah(r)
 
 	// handle errors
 	if err != nil {
 		log.Printf(
-			"error serving on appJSONHandler %v. Error: %v - Status: %d (%s) - Request: %+v",
-			ah, err, status, http.StatusText(status), r,
+			"error serving on appJSONHandler %v. Error: %v - Status: %d (%s) - Request ID: %s - Request: %+v",
+			ah, err, status, http.StatusText(status), id, r,
 		)
-		switch status {
-		case http.StatusServiceUnavailable:
-			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
-			// We can have cases as granular as we like, if we wanted to
-			// return custom errors for specific status codes.
-			// TODO: if http.StatusInternalServerError is received, we should return bad request and log the error maybe?
-		case http.StatusInternalServerError:
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		default:
-			// Catch any other errors we haven't explicitly handled
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		res := newErrorResponse(status, err, id)
+		if res.Code == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
 		}
+		writeJSON(w, res.Code, res)
 		return
 	}
+	// a handler can reply with a body and a status other than http.StatusOK,
+	// such as a status document that reports a failure, by returning them
+	// without an error
+	if status == 0 {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, bod)
+}
+
+// newErrorResponse creates the ErrorResponse for an error returned with the
+// given status. Any status that is not an error status is treated as an
+// internal server error, and an error caused by storage.ErrForbidden is always
+// forbidden, as any operation on the storage can be refused for the caller.
+func newErrorResponse(status int, err error, requestID string) ErrorResponse {
+	if errors.Cause(err) == storage.ErrForbidden {
+		status = http.StatusForbidden
+	}
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+	res := ErrorResponse{Code: status, RequestID: requestID}
+	if status >= http.StatusInternalServerError {
+		res.Message = http.StatusText(status)
+		return res
+	}
+	res.Message = err.Error()
+	if fe, ok := errors.Cause(err).(account.FieldError); ok {
+		res.Fields = fe
+	}
+	return res
+}
 
+// writeJSON writes a value as the JSON body of a response with the given
+// status, or an ErrorResponse if the value cannot be marshalled.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	// here, I don't want to write to the writer immediately using a json
 	// encoder, in case there is an error in json encoding
-	bs, err := json.Marshal(bod)
+	bs, err := json.Marshal(v)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_, wErr := io.WriteString(w, http.StatusText(http.StatusInternalServerError))
-		if wErr != nil {
-			log.Print(errors.Wrap(wErr, "writing status text to ResponseWriter"))
-		}
 		log.Print(errors.Wrap(err, "marshalling json reponse"))
-		return
+		status = http.StatusInternalServerError
+		bs, err = json.Marshal(ErrorResponse{
+			Code:      status,
+			Message:   http.StatusText(status),
+			RequestID: w.Header().Get(HeaderRequestID),
+		})
+		if err != nil {
+			log.Print(errors.Wrap(err, "marshalling json error response"))
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
 	}
 
 	w.Header().Set(`Content-Type`, `application/json; charset=UTF-8`)
+	w.WriteHeader(status)
 	_, wErr := w.Write(bs)
 	if wErr != nil {
 		log.Print(errors.Wrap(wErr, "writing body to ResponseWriter"))
 	}
 }
+
+// requestID returns the ID given in the HeaderRequestID header of a request,
+// or a newly generated ID if the request does not have one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(HeaderRequestID); id != "" {
+		return id
+	}
+	bs := make([]byte, 8)
+	if _, err := rand.Read(bs); err != nil {
+		log.Print(errors.Wrap(err, "generating request ID"))
+		return ""
+	}
+	return hex.EncodeToString(bs)
+}
@@ -0,0 +1,153 @@
+package router
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppJSONHandler_ServeHTTP(t *testing.T) {
+	serve := func(t *testing.T, h http.Handler, r *http.Request) (*httptest.ResponseRecorder, ErrorResponse) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		var res ErrorResponse
+		if w.Code != http.StatusOK {
+			common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &res), "unmarshalling error response")
+		}
+		return w, res
+	}
+
+	for _, test := range []struct {
+		name     string
+		status   int
+		err      error
+		expected ErrorResponse
+	}{
+		{
+			name:     "bad request",
+			status:   http.StatusBadRequest,
+			err:      errors.New("parsing query"),
+			expected: ErrorResponse{Code: http.StatusBadRequest, Message: "parsing query"},
+		},
+		{
+			name:     "not found",
+			status:   http.StatusNotFound,
+			err:      errors.Wrap(errors.New("no account"), "selecting account"),
+			expected: ErrorResponse{Code: http.StatusNotFound, Message: "selecting account: no account"},
+		},
+		{
+			name:   "field error",
+			status: http.StatusBadRequest,
+			err:    errors.Wrap(account.FieldError{account.EmptyNameError}, "inserting account"),
+			expected: ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: "inserting account: FieldError: empty name",
+				Fields:  []string{account.EmptyNameError},
+			},
+		},
+		{
+			name:     "service unavailable",
+			status:   http.StatusServiceUnavailable,
+			err:      errors.New("connecting to storage"),
+			expected: ErrorResponse{Code: http.StatusServiceUnavailable, Message: "Service Unavailable"},
+		},
+		{
+			name:     "forbidden",
+			status:   http.StatusBadRequest,
+			err:      errors.Wrap(errors.Wrap(storage.ErrForbidden, "write access to account 1 is required"), "inserting balance"),
+			expected: ErrorResponse{Code: http.StatusForbidden, Message: "inserting balance: write access to account 1 is required: access denied"},
+		},
+		{
+			name:     "not an error status",
+			status:   http.StatusOK,
+			err:      errors.New("something"),
+			expected: ErrorResponse{Code: http.StatusInternalServerError, Message: "Internal Server Error"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			h := appJSONHandler(func(*http.Request) (int, interface{}, error) {
+				return test.status, "ignored", test.err
+			})
+			w, res := serve(t, h, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, test.expected.Code, w.Code)
+			assert.Equal(t, `application/json; charset=UTF-8`, w.Header().Get("Content-Type"))
+			id := w.Header().Get(HeaderRequestID)
+			assert.NotEmpty(t, id)
+			test.expected.RequestID = id
+			assert.Equal(t, test.expected, res)
+		})
+	}
+
+	t.Run("given request ID", func(t *testing.T) {
+		h := appJSONHandler(func(*http.Request) (int, interface{}, error) {
+			return http.StatusBadRequest, nil, errors.New("bad")
+		})
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(HeaderRequestID, "abc123")
+		w, res := serve(t, h, r)
+		assert.Equal(t, "abc123", w.Header().Get(HeaderRequestID))
+		assert.Equal(t, "abc123", res.RequestID)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		h := appJSONHandler(func(*http.Request) (int, interface{}, error) {
+			return http.StatusOK, []int{1, 2}, nil
+		})
+		w, _ := serve(t, h, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "[1,2]", w.Body.String())
+	})
+
+	t.Run("marshal error", func(t *testing.T) {
+		h := appJSONHandler(func(*http.Request) (int, interface{}, error) {
+			return http.StatusOK, func() {}, nil
+		})
+		w, res := serve(t, h, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.Equal(t, w.Header().Get(HeaderRequestID), res.RequestID)
+	})
+
+	t.Run("unknown endpoint", func(t *testing.T) {
+		router, err := New(&storagetest.Storage{})
+		common.FatalIfError(t, err, "creating router")
+		w, res := serve(t, router, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+
+		w, res = serve(t, router, httptest.NewRequest(http.MethodDelete, EndpointAccounts, nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, http.StatusMethodNotAllowed, res.Code)
+	})
+}
+
+// closeCounter is a request body that counts how many times it is closed
+type closeCounter struct {
+	io.Reader
+	closed int
+}
+
+func (c *closeCounter) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestReadBody(t *testing.T) {
+	body := &closeCounter{Reader: strings.NewReader("body")}
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Body = body
+	bod, err := readBody(r)
+	common.FatalIfError(t, err, "reading body")
+	assert.Equal(t, "body", string(bod))
+	assert.Equal(t, 1, body.closed)
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// Option configures a router created with New
+type Option func(*config)
+
+type config struct {
+	tokens            storage.TokenStorage
+	maxStorageLatency time.Duration
+}
+
+// WithTokenAuth makes the router reject any request that does not give the
+// secret of an unrevoked Token from the TokenStorage as a bearer token in its
+// Authorization header, replying with http.StatusUnauthorized. When the
+// TokenStorage is a storage.UserStorage, each request that is authenticated
+// by the Token of a User is scoped to the Accounts that the User has access
+// to.
+func WithTokenAuth(ts storage.TokenStorage) Option {
+	return func(c *config) {
+		c.tokens = ts
+	}
+}
+
+// requireToken wraps an appJSONHandler so that it is only called for requests
+// that are authenticated with a Token from the TokenStorage.
+func requireToken(ts storage.TokenStorage, inner appJSONHandler) appJSONHandler {
+	return func(r *http.Request) (int, interface{}, error) {
+		secret, err := bearerToken(r)
+		if err != nil {
+			return http.StatusUnauthorized, nil, err
+		}
+		t, err := ts.SelectTokenByHash(storage.HashTokenSecret(secret))
+		if errors.Cause(err) == storage.ErrNoToken {
+			return http.StatusUnauthorized, nil, errors.New("invalid token")
+		}
+		if err != nil {
+			return http.StatusServiceUnavailable, nil, errors.Wrap(err, "selecting token from storage")
+		}
+		if t.Revoked != nil {
+			return http.StatusUnauthorized, nil, errors.New("token has been revoked")
+		}
+		if t.UserID != 0 {
+			r = r.WithContext(storage.ContextWithUser(r.Context(), t.UserID))
+		}
+		return inner(r)
+	}
+}
+
+// bearerToken returns the bearer token of the Authorization header of a
+// request.
+func bearerToken(r *http.Request) (string, error) {
+	h := r.Header.Get("Authorization")
+	if h == "" {
+		return "", errors.New("missing Authorization header")
+	}
+	const scheme = "bearer "
+	if len(h) <= len(scheme) || !strings.EqualFold(h[:len(scheme)], scheme) {
+		return "", errors.New("Authorization header must hold a bearer token")
+	}
+	return strings.TrimSpace(h[len(scheme):]), nil
+}
@@ -0,0 +1,65 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTokenAuth(t *testing.T) {
+	store := memory.New()
+	secret, err := storage.NewTokenSecret()
+	common.FatalIfError(t, err, "generating secret")
+	_, err = store.InsertToken("valid", storage.HashTokenSecret(secret), 0)
+	common.FatalIfError(t, err, "inserting token")
+	revokedSecret, err := storage.NewTokenSecret()
+	common.FatalIfError(t, err, "generating secret")
+	revoked, err := store.InsertToken("revoked", storage.HashTokenSecret(revokedSecret), 0)
+	common.FatalIfError(t, err, "inserting token")
+	common.FatalIfError(t, store.RevokeToken(revoked.ID), "revoking token")
+
+	r, err := New(store, WithTokenAuth(store))
+	common.FatalIfError(t, err, "creating router")
+
+	for _, test := range []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{name: "no header", status: http.StatusUnauthorized},
+		{name: "basic auth", authorization: "Basic dXNlcjpwYXNz", status: http.StatusUnauthorized},
+		{name: "empty bearer token", authorization: "Bearer ", status: http.StatusUnauthorized},
+		{name: "unknown token", authorization: "Bearer mon_unknown", status: http.StatusUnauthorized},
+		{name: "revoked token", authorization: "Bearer " + revokedSecret, status: http.StatusUnauthorized},
+		{name: "valid token", authorization: "Bearer " + secret, status: http.StatusOK},
+		{name: "lower case scheme", authorization: "bearer " + secret, status: http.StatusOK},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, EndpointAccounts, nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, test.status, w.Code)
+			if test.status == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			} else {
+				assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+
+	t.Run("without token auth", func(t *testing.T) {
+		r, err := New(store)
+		common.FatalIfError(t, err, "creating router")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EndpointAccounts, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
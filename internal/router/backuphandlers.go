@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// maxRestoreBytes is the size of the largest document that can be restored
+const maxRestoreBytes = 64 << 20
+
+func (env *environment) archiveStorage() (storage.ArchiveStorage, error) {
+	archive, ok := env.storage.(storage.ArchiveStorage)
+	if !ok {
+		return nil, errors.New("storage does not support export and restore")
+	}
+	return archive, nil
+}
+
+// requireAllAccounts returns an error caused by storage.ErrForbidden when the
+// context.Context holds a User, as exporting and restoring cover the Accounts
+// of every User.
+func requireAllAccounts(ctx context.Context) error {
+	if _, ok := storage.UserFromContext(ctx); ok {
+		return errors.Wrap(storage.ErrForbidden, "a token that is not for a user is required")
+	}
+	return nil
+}
+
+func (env *environment) handlerExport(r *http.Request) (int, interface{}, error) {
+	if err := requireAllAccounts(r.Context()); err != nil {
+		return http.StatusForbidden, nil, err
+	}
+	archive, err := env.archiveStorage()
+	if err != nil {
+		return http.StatusNotImplemented, nil, err
+	}
+	d, err := backup.Export(archive)
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "exporting storage")
+	}
+	return http.StatusOK, d, nil
+}
+
+func (env *environment) restore(d backup.Document) (int, interface{}, error) {
+	archive, err := env.archiveStorage()
+	if err != nil {
+		return http.StatusNotImplemented, nil, err
+	}
+	err = backup.Restore(archive, d)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "restoring document")
+	}
+	return http.StatusOK, d.Summary(), nil
+}
+
+func (env *environment) muxRestoreHandlerFunc(r *http.Request) (int, interface{}, error) {
+	if err := requireAllAccounts(r.Context()); err != nil {
+		return http.StatusForbidden, nil, err
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, maxRestoreBytes)
+	bod, err := readBody(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	var d backup.Document
+	err = json.Unmarshal(bod, &d)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
+	}
+	return env.restore(d)
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// nonArchiveStorage is a storage.Storage that is not a storage.ArchiveStorage
+type nonArchiveStorage struct {
+	storage.Storage
+}
+
+func Test_handlerExport(t *testing.T) {
+	t.Run("storage is not an ArchiveStorage", func(t *testing.T) {
+		srv := environment{storage: nonArchiveStorage{&storagetest.Storage{}}}
+		code, d, err := srv.handlerExport(httptest.NewRequest(http.MethodGet, EndpointExport, nil))
+		assert.Equal(t, http.StatusNotImplemented, code)
+		assert.Error(t, err)
+		assert.Nil(t, d)
+	})
+
+	t.Run("request for a user", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{Accounts: &storage.Accounts{}}}
+		r := httptest.NewRequest(http.MethodGet, EndpointExport, nil)
+		r = r.WithContext(storage.ContextWithUser(r.Context(), 1))
+		code, d, err := srv.handlerExport(r)
+		assert.Equal(t, http.StatusForbidden, code)
+		assert.Equal(t, storage.ErrForbidden, errors.Cause(err))
+		assert.Nil(t, d)
+	})
+
+	t.Run("SelectAllAccounts error", func(t *testing.T) {
+		expected := errors.New("accounts error")
+		srv := environment{storage: &storagetest.Storage{Err: expected}}
+		code, d, err := srv.handlerExport(httptest.NewRequest(http.MethodGet, EndpointExport, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, d)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{
+			Accounts:     &storage.Accounts{},
+			Transactions: &storage.Transactions{},
+			Rates:        &storage.Rates{{ID: 1}},
+		}}
+		code, d, err := srv.handlerExport(httptest.NewRequest(http.MethodGet, EndpointExport, nil))
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		if assert.IsType(t, &backup.Document{}, d) {
+			assert.Equal(t, backup.Version, d.(*backup.Document).Version)
+			assert.Equal(t, storage.Rates{{ID: 1}}, d.(*backup.Document).Rates)
+		}
+	})
+}
+
+func Test_restore(t *testing.T) {
+	t.Run("storage is not an ArchiveStorage", func(t *testing.T) {
+		srv := environment{storage: nonArchiveStorage{&storagetest.Storage{}}}
+		code, s, err := srv.restore(backup.Document{Version: backup.Version})
+		assert.Equal(t, http.StatusNotImplemented, code)
+		assert.Error(t, err)
+		assert.Nil(t, s)
+	})
+
+	t.Run("storage not empty", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{Accounts: &storage.Accounts{{ID: 1}}}}
+		code, s, err := srv.restore(backup.Document{Version: backup.Version})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Error(t, err)
+		assert.Nil(t, s)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{Accounts: &storage.Accounts{}, Rates: &storage.Rates{}}}
+		code, s, err := srv.restore(backup.Document{Version: backup.Version})
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, backup.Summary{}, s)
+	})
+}
+
+// spaceReader is an io.Reader of endless spaces
+type spaceReader struct{}
+
+func (spaceReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = ' '
+	}
+	return len(p), nil
+}
+
+func Test_muxRestoreHandlerFunc(t *testing.T) {
+	t.Run("body too large", func(t *testing.T) {
+		srv := environment{storage: memory.New()}
+		r := httptest.NewRequest(http.MethodPost, EndpointRestore, io.LimitReader(spaceReader{}, maxRestoreBytes+1))
+		code, s, err := srv.muxRestoreHandlerFunc(r)
+		assert.Equal(t, http.StatusBadRequest, code)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "too large")
+		}
+		assert.Nil(t, s)
+	})
+}
@@ -1,24 +1,26 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
-	"io/ioutil"
-	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 )
 
-func (env *environment) balances(accountID uint) (int, interface{}, error) {
-	a, err := env.storage.SelectAccount(accountID)
+func (env *environment) balances(ctx context.Context, accountID uint, o storage.BalancesOptions) (int, interface{}, error) {
+	a, err := env.contextStorage().SelectAccountContext(ctx, accountID)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrapf(err, "selecting account with id %d", accountID)
+		return accountErrorStatus(err), nil, errors.Wrapf(err, "selecting account with id %d", accountID)
 	}
 	var bs *storage.Balances
-	bs, err = env.storage.SelectAccountBalances(*a)
+	bs, err = env.contextStorage().SelectAccountBalancesWithOptionsContext(ctx, *a, o)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "selecting balances for account %+v", *a)
 	}
@@ -30,15 +32,63 @@ func (env *environment) muxAccountBalancesHandlerFunc(r *http.Request) (int, int
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
 	}
-	return env.balances(id)
+	o, err := parseBalancesOptions(r.URL.Query())
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing balances query")
+	}
+	return env.balances(r.Context(), id, o)
 }
 
-func (env *environment) insertBalance(accountID uint, b balance.Balance) (int, interface{}, error) {
-	a, err := env.storage.SelectAccount(accountID)
+func parseBalancesOptions(vs url.Values) (storage.BalancesOptions, error) {
+	var o storage.BalancesOptions
+	for _, d := range []struct {
+		key  string
+		date **date.Date
+	}{
+		{key: QueryKeyFrom, date: &o.From},
+		{key: QueryKeyTo, date: &o.To},
+	} {
+		value := vs.Get(d.key)
+		if value == "" {
+			continue
+		}
+		parsed, err := date.Parse(value)
+		if err != nil {
+			return storage.BalancesOptions{}, errors.Wrapf(err, "parsing %s date", d.key)
+		}
+		*d.date = &parsed
+	}
+
+	if value := vs.Get(QueryKeyCursor); value != "" {
+		c, err := storage.ParseBalanceCursor(value)
+		if err != nil {
+			return storage.BalancesOptions{}, errors.Wrapf(err, "parsing %s", QueryKeyCursor)
+		}
+		o.After = &c
+	}
+
+	if value := vs.Get(QueryKeyLimit); value != "" {
+		limit, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return storage.BalancesOptions{}, errors.Wrapf(err, "parsing %s", QueryKeyLimit)
+		}
+		o.Limit = uint(limit)
+	}
+
+	descending, err := queryDescending(vs)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrap(err, "selecting account")
+		return storage.BalancesOptions{}, err
 	}
-	inserted, err := env.storage.InsertBalance(*a, b)
+	o.Descending = descending
+	return o, nil
+}
+
+func (env *environment) insertBalance(ctx context.Context, accountID uint, b balance.Balance) (int, interface{}, error) {
+	a, err := env.contextStorage().SelectAccountContext(ctx, accountID)
+	if err != nil {
+		return accountErrorStatus(err), nil, errors.Wrap(err, "selecting account")
+	}
+	inserted, err := env.contextStorage().InsertBalanceContext(ctx, *a, b)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrap(err, "inserting balance")
 	}
@@ -51,23 +101,95 @@ func (env *environment) muxAccountBalanceInsertHandlerFunc(r *http.Request) (int
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
 	}
 
-	bod, err := ioutil.ReadAll(r.Body)
+	bod, err := readBody(r)
 	if err != nil {
-		return http.StatusBadRequest, nil, errors.Wrapf(err, "reading request body")
+		return http.StatusBadRequest, nil, err
 	}
 
-	defer func() {
-		// TODO: this handler only needs to take a []byte which would mean we can handle closing the body elsewhere
-		cErr := r.Body.Close()
-		if cErr != nil {
-			log.Print(errors.Wrap(err, "closing request body"))
-		}
-	}()
-
 	var b balance.Balance
 	err = json.Unmarshal(bod, &b)
 	if err != nil {
 		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
 	}
-	return env.insertBalance(id, b)
+	return env.insertBalance(r.Context(), id, b)
+}
+
+func (env *environment) updateBalance(ctx context.Context, accountID, balanceID uint, us balance.Balance) (int, interface{}, error) {
+	a, b, code, err := env.accountBalance(ctx, accountID, balanceID)
+	if err != nil {
+		return code, nil, err
+	}
+	updated, err := env.contextStorage().UpdateBalanceContext(ctx, *a, b, us)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "updating balance")
+	}
+	return http.StatusOK, updated, nil
+}
+
+func (env *environment) muxAccountBalanceUpdateHandlerFunc(r *http.Request) (int, interface{}, error) {
+	accountID, balanceID, err := extractAccountBalanceIDs(mux.Vars(r))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	bod, err := readBody(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	var us balance.Balance
+	err = json.Unmarshal(bod, &us)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
+	}
+	return env.updateBalance(r.Context(), accountID, balanceID, us)
+}
+
+func (env *environment) deleteBalance(ctx context.Context, accountID, balanceID uint) (int, interface{}, error) {
+	a, b, code, err := env.accountBalance(ctx, accountID, balanceID)
+	if err != nil {
+		return code, nil, err
+	}
+	err = env.contextStorage().DeleteBalanceContext(ctx, *a, b)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "deleting balance")
+	}
+	return http.StatusOK, nil, nil
+}
+
+func (env *environment) muxAccountBalanceDeleteHandlerFunc(r *http.Request) (int, interface{}, error) {
+	accountID, balanceID, err := extractAccountBalanceIDs(mux.Vars(r))
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+	return env.deleteBalance(r.Context(), accountID, balanceID)
+}
+
+// accountBalance selects the Account with the given id and the Balance with
+// the given id from the Balances of that Account, returning the status code
+// to use if either cannot be found.
+func (env *environment) accountBalance(ctx context.Context, accountID, balanceID uint) (*storage.Account, *storage.Balance, int, error) {
+	a, err := env.contextStorage().SelectAccountContext(ctx, accountID)
+	if err != nil {
+		return nil, nil, accountErrorStatus(err), errors.Wrap(err, "selecting account")
+	}
+	bs, err := env.contextStorage().SelectAccountBalancesContext(ctx, *a)
+	if err != nil {
+		return nil, nil, http.StatusBadRequest, errors.Wrapf(err, "selecting balances for account %+v", *a)
+	}
+	for _, b := range *bs {
+		if b.ID == balanceID {
+			return a, &b, http.StatusOK, nil
+		}
+	}
+	return nil, nil, http.StatusNotFound, errors.Errorf("no balance with id %d for account with id %d", balanceID, accountID)
+}
+
+func extractAccountBalanceIDs(vars map[string]string) (uint, uint, error) {
+	accountID, err := extractID(vars)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "extracting account ID")
+	}
+	balanceID, err := extractUintVar(vars, "balance_id")
+	return accountID, balanceID, errors.Wrap(err, "extracting balance ID")
 }
@@ -1,11 +1,21 @@
 package router
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
 	"github.com/glynternet/mon/pkg/storage/storagetest"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -19,12 +29,24 @@ func Test_balances(t *testing.T) {
 				AccountErr: expected,
 			},
 		}
-		code, bs, err := srv.balances(1) // any ID can be used because of the stub
-		assert.Equal(t, http.StatusBadRequest, code)
+		code, bs, err := srv.balances(context.Background(), 1, storage.BalancesOptions{}) // any ID can be used because of the stub
+		assert.Equal(t, http.StatusServiceUnavailable, code)
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Nil(t, bs)
 	})
 
+	t.Run("no account", func(t *testing.T) {
+		srv := &environment{
+			storage: &storagetest.Storage{
+				AccountErr: errors.Wrap(storage.ErrNoAccount, "account with id 1"),
+			},
+		}
+		code, bs, err := srv.balances(context.Background(), 1, storage.BalancesOptions{})
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+		assert.Nil(t, bs)
+	})
+
 	t.Run("SelectBalance error", func(t *testing.T) {
 		account := &storage.Account{}
 		expected := errors.New("balances error")
@@ -34,7 +56,7 @@ func Test_balances(t *testing.T) {
 				BalancesErr: expected,
 			},
 		}
-		code, bs, err := srv.balances(1) // any ID can be used because of the stub
+		code, bs, err := srv.balances(context.Background(), 1, storage.BalancesOptions{}) // any ID can be used because of the stub
 		assert.Equal(t, http.StatusBadRequest, code)
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Nil(t, bs)
@@ -48,7 +70,7 @@ func Test_balances(t *testing.T) {
 				Balances: expected,
 			},
 		}
-		code, bs, err := srv.balances(1) // any ID can be used because of the stub
+		code, bs, err := srv.balances(context.Background(), 1, storage.BalancesOptions{}) // any ID can be used because of the stub
 		assert.Equal(t, http.StatusOK, code)
 		assert.NoError(t, err)
 		assert.IsType(t, &storage.Balances{}, bs)
@@ -56,26 +78,60 @@ func Test_balances(t *testing.T) {
 	})
 }
 
+func Test_parseBalancesOptions(t *testing.T) {
+	o, err := parseBalancesOptions(url.Values{})
+	common.FatalIfError(t, err, "parsing empty query")
+	assert.Equal(t, storage.BalancesOptions{}, o)
+
+	o, err = parseBalancesOptions(url.Values{
+		QueryKeyFrom:   {"2018-05-04"},
+		QueryKeyTo:     {"2018-06-04"},
+		QueryKeyCursor: {"2018-05-10_12"},
+		QueryKeyLimit:  {"20"},
+		QueryKeyOrder:  {OrderDescending},
+	})
+	common.FatalIfError(t, err, "parsing query")
+	from, to := date.New(2018, time.May, 4), date.New(2018, time.June, 4)
+	assert.Equal(t, storage.BalancesOptions{
+		From:       &from,
+		To:         &to,
+		After:      &storage.BalanceCursor{Date: date.New(2018, time.May, 10), ID: 12},
+		Limit:      20,
+		Descending: true,
+	}, o)
+
+	for _, vs := range []url.Values{
+		{QueryKeyFrom: {"04-05-2018"}},
+		{QueryKeyTo: {"tomorrow"}},
+		{QueryKeyCursor: {"12"}},
+		{QueryKeyLimit: {"-1"}},
+		{QueryKeyOrder: {"down"}},
+	} {
+		_, err := parseBalancesOptions(vs)
+		assert.Error(t, err, vs.Encode())
+	}
+}
+
 func TestServer_InsertBalance(t *testing.T) {
 	t.Run("SelectAccount error", func(t *testing.T) {
 		expected := errors.New("SelectAccount error")
-		srv := environment{&storagetest.Storage{
+		srv := environment{storage: &storagetest.Storage{
 			AccountErr: expected,
 		}}
-		code, b, err := srv.insertBalance(0, balance.Balance{})
+		code, b, err := srv.insertBalance(context.Background(), 0, balance.Balance{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "selecting account")
-		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
 		assert.Nil(t, b)
 	})
 
 	t.Run("InsertBalance error", func(t *testing.T) {
 		expected := errors.New("InsertBalance error")
-		srv := environment{&storagetest.Storage{
+		srv := environment{storage: &storagetest.Storage{
 			Account:    &storage.Account{},
 			BalanceErr: expected,
 		}}
-		code, b, err := srv.insertBalance(0, balance.Balance{})
+		code, b, err := srv.insertBalance(context.Background(), 0, balance.Balance{})
 		assert.Equal(t, expected, errors.Cause(err))
 		assert.Contains(t, err.Error(), "inserting balance")
 		assert.Equal(t, http.StatusBadRequest, code)
@@ -84,13 +140,135 @@ func TestServer_InsertBalance(t *testing.T) {
 
 	t.Run("all ok", func(t *testing.T) {
 		expected := &storage.Balance{}
-		srv := environment{&storagetest.Storage{
+		srv := environment{storage: &storagetest.Storage{
 			Account: &storage.Account{},
 			Balance: expected,
 		}}
-		code, b, err := srv.insertBalance(0, balance.Balance{})
+		code, b, err := srv.insertBalance(context.Background(), 0, balance.Balance{})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, expected, b)
+	})
+}
+
+func TestServer_UpdateBalance(t *testing.T) {
+	t.Run("SelectAccount error", func(t *testing.T) {
+		expected := errors.New("SelectAccount error")
+		srv := environment{storage: &storagetest.Storage{
+			AccountErr: expected,
+		}}
+		code, b, err := srv.updateBalance(context.Background(), 0, 1, balance.Balance{})
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Contains(t, err.Error(), "selecting account")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Nil(t, b)
+	})
+
+	t.Run("SelectAccountBalances error", func(t *testing.T) {
+		expected := errors.New("SelectAccountBalances error")
+		srv := environment{storage: &storagetest.Storage{
+			Account:     &storage.Account{},
+			BalancesErr: expected,
+		}}
+		code, b, err := srv.updateBalance(context.Background(), 0, 1, balance.Balance{})
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Nil(t, b)
+	})
+
+	t.Run("balance not found", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{
+			Account:  &storage.Account{},
+			Balances: &storage.Balances{{ID: 2}},
+		}}
+		code, b, err := srv.updateBalance(context.Background(), 0, 1, balance.Balance{})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Nil(t, b)
+	})
+
+	t.Run("UpdateBalance error", func(t *testing.T) {
+		expected := errors.New("UpdateBalance error")
+		srv := environment{storage: &storagetest.Storage{
+			Account:    &storage.Account{},
+			Balances:   &storage.Balances{{ID: 1}},
+			BalanceErr: expected,
+		}}
+		code, b, err := srv.updateBalance(context.Background(), 0, 1, balance.Balance{})
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Contains(t, err.Error(), "updating balance")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Nil(t, b)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		expected := &storage.Balance{ID: 1}
+		srv := environment{storage: &storagetest.Storage{
+			Account:  &storage.Account{},
+			Balances: &storage.Balances{{ID: 1}},
+			Balance:  expected,
+		}}
+		code, b, err := srv.updateBalance(context.Background(), 0, 1, balance.Balance{})
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, code)
 		assert.Equal(t, expected, b)
 	})
 }
+
+func TestServer_DeleteBalance(t *testing.T) {
+	t.Run("balance not found", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{
+			Account:  &storage.Account{},
+			Balances: &storage.Balances{},
+		}}
+		code, b, err := srv.deleteBalance(context.Background(), 0, 1)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Nil(t, b)
+	})
+
+	t.Run("DeleteBalance error", func(t *testing.T) {
+		expected := errors.New("DeleteBalance error")
+		srv := environment{storage: &storagetest.Storage{
+			Account:    &storage.Account{},
+			Balances:   &storage.Balances{{ID: 1}},
+			BalanceErr: expected,
+		}}
+		code, b, err := srv.deleteBalance(context.Background(), 0, 1)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Contains(t, err.Error(), "deleting balance")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Nil(t, b)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{
+			Account:  &storage.Account{},
+			Balances: &storage.Balances{{ID: 1}},
+		}}
+		code, b, err := srv.deleteBalance(context.Background(), 0, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Nil(t, b)
+	})
+}
+
+func TestBalances_unknownAccount(t *testing.T) {
+	r, err := New(memory.New())
+	common.FatalIfError(t, err, "creating router")
+	bod, err := json.Marshal(balance.Balance{Date: time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC), Amount: 1})
+	common.FatalIfError(t, err, "marshalling balance")
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, fmt.Sprintf(EndpointFmtAccountBalances, 999), nil),
+		httptest.NewRequest(http.MethodPost, fmt.Sprintf(EndpointFmtAccountBalanceInsert, 999), bytes.NewReader(bod)),
+		httptest.NewRequest(http.MethodPost, fmt.Sprintf(EndpointFmtAccountBalanceUpdate, 999, 1), bytes.NewReader(bod)),
+	} {
+		t.Run(req.Method+" "+req.URL.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+			assert.Contains(t, w.Body.String(), "selecting account", "handler should reject the account")
+		})
+	}
+}
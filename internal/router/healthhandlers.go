@@ -0,0 +1,121 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StatusOK is the Status of a server, or of one of its checks, that is
+	// working
+	StatusOK = "ok"
+	// StatusUnavailable is the Status of a server, or of one of its checks,
+	// that is not working
+	StatusUnavailable = "unavailable"
+
+	// defaultMaxStorageLatency is the longest that the storage can take to
+	// respond for the server to be ready, unless configured otherwise with
+	// WithMaxStorageLatency
+	defaultMaxStorageLatency = time.Second
+)
+
+// Status is the body of a response from EndpointHealth or EndpointReady
+type Status struct {
+	// Status is StatusOK when every Check passed, otherwise it is
+	// StatusUnavailable
+	Status string `json:"status"`
+	// Checks holds the result of each check made by EndpointReady
+	Checks []Check `json:"checks,omitempty"`
+}
+
+// Check is the result of one of the checks of a Status
+type Check struct {
+	// Name of the check, e.g. storage or schema
+	Name string `json:"name"`
+	// Status is either StatusOK or StatusUnavailable
+	Status string `json:"status"`
+	// Message describes why the check failed
+	Message string `json:"message,omitempty"`
+	// DurationMS is the number of milliseconds that the check took
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// WithMaxStorageLatency sets the longest that the storage can take to respond
+// for EndpointReady to report that the server is ready.
+func WithMaxStorageLatency(d time.Duration) Option {
+	return func(c *config) {
+		c.maxStorageLatency = d
+	}
+}
+
+func (env *environment) handlerHealth(_ *http.Request) (int, interface{}, error) {
+	return http.StatusOK, Status{Status: StatusOK}, nil
+}
+
+// handlerReady checks that the storage is available and responds in time and,
+// when the storage is a storage.SchemaStorage, that its schema is at the
+// required version. Each check fails once it has taken longer than the
+// maxStorageLatency of the environment.
+func (env *environment) handlerReady(r *http.Request) (int, interface{}, error) {
+	cs := storage.WithContext(env.storage)
+	checks := []Check{env.runCheck(r.Context(), "storage", "storage is unavailable", func(ctx context.Context) error {
+		if !cs.AvailableContext(ctx) {
+			return errors.New("storage is unavailable")
+		}
+		return nil
+	})}
+	if ss, ok := env.storage.(storage.SchemaStorage); ok {
+		checks = append(checks, env.runCheck(r.Context(), "schema", "schema is not at the required version", ss.CheckSchemaVersionContext))
+	}
+	s := Status{Status: StatusOK, Checks: checks}
+	for _, c := range checks {
+		if c.Status != StatusOK {
+			s.Status = StatusUnavailable
+			return http.StatusServiceUnavailable, s, nil
+		}
+	}
+	return http.StatusOK, s, nil
+}
+
+// runCheck runs a check, timing how long it takes. The check is given a
+// context.Context that is cancelled once the maxStorageLatency of the
+// environment has passed, and fails when it returns an error or has not
+// returned by then. A check on a storage that cannot be cancelled is left to
+// finish in the background.
+// As EndpointReady does not require authentication, the error of a failed
+// check is only logged and the Check is given the generic message.
+func (env *environment) runCheck(ctx context.Context, name, message string, check func(context.Context) error) Check {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, env.maxStorageLatency)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		message = fmt.Sprintf("storage did not respond within %s", env.maxStorageLatency)
+	}
+	c := Check{Name: name, Status: StatusOK, DurationMS: durationMS(time.Since(start))}
+	if err != nil {
+		log.Printf("readiness check %s failed: %v", name, err)
+		c.Status = StatusUnavailable
+		c.Message = message
+	}
+	return c
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,186 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// schemaStorage is a storage.SchemaStorage whose schema check returns err
+type schemaStorage struct {
+	storage.Storage
+	err error
+}
+
+func (s schemaStorage) CheckSchemaVersion() error { return s.err }
+
+func (s schemaStorage) CheckSchemaVersionContext(context.Context) error { return s.err }
+
+// slowSchemaStorage is a storage.SchemaStorage whose schema check does not
+// return until its context.Context is done, closing returned when it does
+type slowSchemaStorage struct {
+	storage.Storage
+	returned chan struct{}
+}
+
+func (s slowSchemaStorage) CheckSchemaVersion() error {
+	return s.CheckSchemaVersionContext(context.Background())
+}
+
+func (s slowSchemaStorage) CheckSchemaVersionContext(ctx context.Context) error {
+	<-ctx.Done()
+	close(s.returned)
+	return ctx.Err()
+}
+
+// hungStorage is a storage.Storage that does not report whether it is
+// available until release is closed
+type hungStorage struct {
+	storage.Storage
+	release chan struct{}
+}
+
+func (s hungStorage) Available() bool {
+	<-s.release
+	return s.Storage.Available()
+}
+
+func TestHealth(t *testing.T) {
+	store := memory.New()
+	for _, opts := range [][]Option{nil, {WithTokenAuth(store)}} {
+		r, err := New(store, opts...)
+		common.FatalIfError(t, err, "creating router")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EndpointHealth, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		var s Status
+		common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &s), "unmarshalling status")
+		assert.Equal(t, Status{Status: StatusOK}, s)
+	}
+}
+
+func TestReady(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		store  storage.Storage
+		status int
+		checks map[string]string
+	}{
+		{
+			name:   "ready",
+			store:  memory.New(),
+			status: http.StatusOK,
+			checks: map[string]string{"storage": StatusOK},
+		},
+		{
+			name:   "unavailable storage",
+			store:  &storagetest.Storage{IsAvailable: false},
+			status: http.StatusServiceUnavailable,
+			checks: map[string]string{"storage": StatusUnavailable},
+		},
+		{
+			name:   "current schema",
+			store:  schemaStorage{Storage: memory.New()},
+			status: http.StatusOK,
+			checks: map[string]string{"storage": StatusOK, "schema": StatusOK},
+		},
+		{
+			name:   "outdated schema",
+			store:  schemaStorage{Storage: memory.New(), err: errors.New("secret detail")},
+			status: http.StatusServiceUnavailable,
+			checks: map[string]string{"storage": StatusOK, "schema": StatusUnavailable},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := New(test.store)
+			common.FatalIfError(t, err, "creating router")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EndpointReady, nil))
+			assert.Equal(t, test.status, w.Code)
+
+			var s Status
+			common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &s), "unmarshalling status")
+			expected := StatusOK
+			if test.status != http.StatusOK {
+				expected = StatusUnavailable
+			}
+			assert.Equal(t, expected, s.Status)
+			checks := make(map[string]string)
+			for _, c := range s.Checks {
+				checks[c.Name] = c.Status
+				if c.Status == StatusOK {
+					assert.Empty(t, c.Message)
+				} else {
+					assert.NotEmpty(t, c.Message)
+					assert.NotContains(t, c.Message, "secret detail", "errors should not be exposed")
+				}
+			}
+			assert.Equal(t, test.checks, checks)
+		})
+	}
+
+	t.Run("hung storage", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		r, err := New(hungStorage{Storage: memory.New(), release: release}, WithMaxStorageLatency(10*time.Millisecond))
+		common.FatalIfError(t, err, "creating router")
+		w := httptest.NewRecorder()
+		done := make(chan struct{})
+		go func() {
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EndpointReady, nil))
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("readiness check did not time out")
+		}
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var s Status
+		common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &s), "unmarshalling status")
+		if assert.Len(t, s.Checks, 1) {
+			assert.Equal(t, StatusUnavailable, s.Checks[0].Status)
+			assert.Contains(t, s.Checks[0].Message, "did not respond within")
+		}
+	})
+
+	t.Run("slow schema check", func(t *testing.T) {
+		returned := make(chan struct{})
+		r, err := New(slowSchemaStorage{Storage: memory.New(), returned: returned}, WithMaxStorageLatency(10*time.Millisecond))
+		common.FatalIfError(t, err, "creating router")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EndpointReady, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var s Status
+		common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &s), "unmarshalling status")
+		if assert.Len(t, s.Checks, 2) {
+			assert.Equal(t, StatusOK, s.Checks[0].Status)
+			assert.Equal(t, StatusUnavailable, s.Checks[1].Status)
+			assert.Contains(t, s.Checks[1].Message, "did not respond within")
+		}
+		select {
+		case <-returned:
+		case <-time.After(time.Second):
+			t.Fatal("schema check was not cancelled")
+		}
+	})
+
+	t.Run("without authentication", func(t *testing.T) {
+		store := memory.New()
+		r, err := New(store, WithTokenAuth(store))
+		common.FatalIfError(t, err, "creating router")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, EndpointReady, nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
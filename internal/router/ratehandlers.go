@@ -0,0 +1,40 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+func (env *environment) handlerSelectRates(r *http.Request) (int, interface{}, error) {
+	rs, err := env.contextStorage().SelectRatesContext(r.Context())
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "selecting Rates from storage")
+	}
+	return http.StatusOK, rs, nil
+}
+
+func (env *environment) insertRate(ctx context.Context, r storage.Rate) (int, interface{}, error) {
+	inserted, err := env.contextStorage().InsertRateContext(ctx, r)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "inserting rate")
+	}
+	return http.StatusOK, inserted, nil
+}
+
+func (env *environment) muxRateInsertHandlerFunc(r *http.Request) (int, interface{}, error) {
+	bod, err := readBody(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	var rate storage.Rate
+	err = json.Unmarshal(bod, &rate)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
+	}
+	return env.insertRate(r.Context(), rate)
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_handlerSelectRates(t *testing.T) {
+	t.Run("SelectRates error", func(t *testing.T) {
+		expected := errors.New("rates error")
+		srv := environment{storage: &storagetest.Storage{RatesErr: expected}}
+		code, rs, err := srv.handlerSelectRates(httptest.NewRequest(http.MethodGet, EndpointRates, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, rs)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		expected := &storage.Rates{{ID: 1}}
+		srv := environment{storage: &storagetest.Storage{Rates: expected}}
+		code, rs, err := srv.handlerSelectRates(httptest.NewRequest(http.MethodGet, EndpointRates, nil))
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, rs)
+	})
+}
+
+func Test_insertRate(t *testing.T) {
+	t.Run("InsertRate error", func(t *testing.T) {
+		expected := errors.New("InsertRate error")
+		srv := environment{storage: &storagetest.Storage{RateErr: expected}}
+		code, r, err := srv.insertRate(context.Background(), storage.Rate{})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, r)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		expected := &storage.Rate{ID: 1}
+		srv := environment{storage: &storagetest.Storage{Rate: expected}}
+		code, r, err := srv.insertRate(context.Background(), storage.Rate{})
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, r)
+	})
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/report"
+	"github.com/pkg/errors"
+)
+
+func (env *environment) netWorth(ctx context.Context, from, to date.Date, i report.Interval) (int, interface{}, error) {
+	ds, err := report.Dates(from, to, i)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "generating report dates")
+	}
+	as, err := env.contextStorage().SelectAccountsContext(ctx)
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "selecting Accounts from storage")
+	}
+	abs := make([]report.AccountBalances, len(*as))
+	for n, a := range *as {
+		bs, err := env.contextStorage().SelectAccountBalancesContext(ctx, a)
+		if err != nil {
+			return http.StatusServiceUnavailable, nil, errors.Wrapf(err, "selecting balances for account %+v", a)
+		}
+		abs[n] = report.AccountBalances{Account: a, Balances: *bs}
+	}
+	return http.StatusOK, report.NetWorth(abs, ds), nil
+}
+
+func (env *environment) muxNetWorthHandlerFunc(r *http.Request) (int, interface{}, error) {
+	q := r.URL.Query()
+	from, err := date.Parse(q.Get(QueryKeyFrom))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing from date")
+	}
+	to, err := date.Parse(q.Get(QueryKeyTo))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing to date")
+	}
+	i, err := report.ParseInterval(q.Get(QueryKeyInterval))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing interval")
+	}
+	return env.netWorth(r.Context(), from, to, i)
+}
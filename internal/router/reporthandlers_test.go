@@ -0,0 +1,88 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/report"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_netWorth(t *testing.T) {
+	from := date.New(2018, time.May, 4)
+
+	t.Run("invalid dates", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{}}
+		code, ss, err := srv.netWorth(context.Background(), from, from.AddDays(-1), report.Daily)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Error(t, err)
+		assert.Nil(t, ss)
+	})
+
+	t.Run("SelectAccounts error", func(t *testing.T) {
+		expected := errors.New("accounts error")
+		srv := environment{storage: &storagetest.Storage{Err: expected}}
+		code, ss, err := srv.netWorth(context.Background(), from, from, report.Daily)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, ss)
+	})
+
+	a := storage.Account{
+		Account: *accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "GBP"), from.Time()),
+	}
+
+	t.Run("SelectAccountBalances error", func(t *testing.T) {
+		expected := errors.New("balances error")
+		srv := environment{storage: &storagetest.Storage{
+			Accounts:    &storage.Accounts{a},
+			BalancesErr: expected,
+		}}
+		code, ss, err := srv.netWorth(context.Background(), from, from, report.Daily)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, ss)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{
+			Accounts: &storage.Accounts{a},
+			Balances: &storage.Balances{{Balance: balance.Balance{Date: from.Time(), Amount: 12}}},
+		}}
+		code, ss, err := srv.netWorth(context.Background(), from, from.AddDays(1), report.Daily)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, []report.Series{{
+			Currency: "GBP",
+			Points:   []report.Point{{Date: from, Amount: 12}, {Date: from.AddDays(1), Amount: 12}},
+		}}, ss)
+	})
+}
+
+func Test_muxNetWorthHandlerFunc(t *testing.T) {
+	srv := environment{storage: &storagetest.Storage{Accounts: &storage.Accounts{}}}
+	for _, test := range []struct {
+		query string
+		code  int
+	}{
+		{query: "?from=2018-05-04&to=2018-06-04&interval=weekly", code: http.StatusOK},
+		{query: "?to=2018-06-04&interval=weekly", code: http.StatusBadRequest},
+		{query: "?from=2018-05-04&interval=weekly", code: http.StatusBadRequest},
+		{query: "?from=2018-05-04&to=2018-06-04&interval=yearly", code: http.StatusBadRequest},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, EndpointReportNetWorth+test.query, nil)
+			code, _, _ := srv.muxNetWorthHandlerFunc(r)
+			assert.Equal(t, test.code, code)
+		})
+	}
+}
@@ -5,4 +5,7 @@ type route struct {
 	method     string
 	pattern    string
 	appHandler appJSONHandler
+	// public routes do not require requests to be authenticated, even when
+	// the router uses token authentication
+	public bool
 }
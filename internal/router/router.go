@@ -3,6 +3,7 @@ package router
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/gorilla/mux"
@@ -10,10 +11,20 @@ import (
 )
 
 const (
-	// EndpointAccounts is the endpoint for Accounts
+	// EndpointAccounts is the endpoint for Accounts. The Accounts can be
+	// filtered using the QueryKeyOpenAt, QueryKeyExistedAt, QueryKeyIDs,
+	// QueryKeyNotIDs and QueryKeyCurrency query parameters, and sorted using
+	// the QueryKeySort and QueryKeyOrder query parameters.
 	EndpointAccounts = "/accounts"
 	patternAccounts  = EndpointAccounts
 
+	// EndpointAccountsBalances is the endpoint for the Balance of each Account
+	// at the date given by the QueryKeyAt query parameter, or at the current
+	// date when it is not given. The Accounts can be filtered and sorted
+	// using the same query parameters as EndpointAccounts, and Accounts that
+	// have no Balance at the date are left out.
+	EndpointAccountsBalances = EndpointAccounts + "/balances"
+
 	// EndpointAccount is the endpoint for Account
 	EndpointAccount = "/account"
 
@@ -34,7 +45,10 @@ const (
 	patternAccountUpdate     = patternAccount + "/update"
 
 	// EndpointFmtAccountBalances is the format string for use when generating
-	// the endpoint to get the balances for a specific Account
+	// the endpoint to get the balances for a specific Account. The Balances
+	// can be filtered using the QueryKeyFrom and QueryKeyTo query parameters,
+	// ordered using the QueryKeyOrder query parameter and paged using the
+	// QueryKeyLimit and QueryKeyCursor query parameters.
 	EndpointFmtAccountBalances = EndpointAccount + "/%d/balances"
 	patternAccountBalances     = EndpointAccount + "/{id}/balances"
 
@@ -42,14 +56,139 @@ const (
 	// the endpoint insert a Balance for a specific Account
 	EndpointFmtAccountBalanceInsert = EndpointAccount + "/%d/balance/insert"
 	patternAccountBalanceInsert     = EndpointAccount + "/{id}/balance/insert"
+
+	// EndpointFmtAccountBalance is the format string for use when generating
+	// the endpoint for a specific Balance of a specific Account
+	EndpointFmtAccountBalance = EndpointAccount + "/%d/balance/%d"
+	patternAccountBalance     = EndpointAccount + "/{id}/balance/{balance_id}"
+
+	// EndpointFmtAccountBalanceUpdate is the format string for use when
+	// generating the endpoint to update a specific Balance of a specific Account
+	EndpointFmtAccountBalanceUpdate = EndpointFmtAccountBalance + "/update"
+	patternAccountBalanceUpdate     = patternAccountBalance + "/update"
+
+	// EndpointFmtAccountShare is the format string for use when generating
+	// the endpoint to share a specific Account with a User
+	EndpointFmtAccountShare = EndpointAccount + "/%d/share"
+	patternAccountShare     = EndpointAccount + "/{id}/share"
+
+	// EndpointFmtAccountTransactions is the format string for use when
+	// generating the endpoint to get the Transactions of a specific Account
+	EndpointFmtAccountTransactions = EndpointAccount + "/%d/transactions"
+	patternAccountTransactions     = EndpointAccount + "/{id}/transactions"
+
+	// EndpointTransactions is the endpoint for Transactions
+	EndpointTransactions = "/transactions"
+	patternTransactions  = EndpointTransactions
+
+	// EndpointTransactionInsert is the endpoint for inserting a Transaction
+	EndpointTransactionInsert = "/transaction/insert"
+
+	// EndpointRates is the endpoint for Rates
+	EndpointRates = "/rates"
+	patternRates  = EndpointRates
+
+	// EndpointRateInsert is the endpoint for inserting a Rate
+	EndpointRateInsert = "/rate/insert"
+
+	// EndpointExport is the endpoint for exporting the whole of the storage
+	// as a backup.Document
+	EndpointExport = "/export"
+
+	// EndpointRestore is the endpoint for restoring a backup.Document into
+	// empty storage
+	EndpointRestore = "/restore"
+
+	// EndpointReportNetWorth is the endpoint for the net worth report. The
+	// report is configured using the QueryKeyFrom, QueryKeyTo and
+	// QueryKeyInterval query parameters.
+	EndpointReportNetWorth = "/reports/networth"
+
+	// EndpointHealth is the liveness endpoint, which replies with a Status as
+	// long as the server is able to serve requests
+	EndpointHealth = "/healthz"
+
+	// EndpointReady is the readiness endpoint, which replies with a Status
+	// of the checks of the storage, with http.StatusServiceUnavailable when
+	// any of them fail. Neither EndpointHealth nor EndpointReady require
+	// requests to be authenticated.
+	EndpointReady = "/readyz"
+
+	// QueryKeyFrom is the query parameter key for the first date of a report
+	// or of a range of Balances
+	QueryKeyFrom = "from"
+	// QueryKeyTo is the query parameter key for the last date of a report or
+	// of a range of Balances
+	QueryKeyTo = "to"
+	// QueryKeyInterval is the query parameter key for the interval between
+	// the dates of a report
+	QueryKeyInterval = "interval"
+
+	// QueryKeyAt is the query parameter key for the date to get Balances at
+	QueryKeyAt = "at"
+
+	// QueryKeyOpenAt is the query parameter key for a date that Accounts
+	// must be open at
+	QueryKeyOpenAt = "open_at"
+	// QueryKeyExistedAt is the query parameter key for a date that Accounts
+	// must have been opened by
+	QueryKeyExistedAt = "existed_at"
+	// QueryKeyIDs is the query parameter key for IDs that Accounts must have
+	// one of. IDs can be given as repeated or comma-separated values.
+	QueryKeyIDs = "ids"
+	// QueryKeyNotIDs is the query parameter key for IDs that Accounts must
+	// not have. IDs can be given as repeated or comma-separated values.
+	QueryKeyNotIDs = "not_ids"
+	// QueryKeyCurrency is the query parameter key for currency codes that
+	// Accounts must have one of. Codes can be given as repeated or
+	// comma-separated values.
+	QueryKeyCurrency = "currency"
+	// QueryKeySort is the query parameter key for the key to sort Accounts
+	// by, one of the keys of sort.AccountSorts
+	QueryKeySort = "sort"
+	// QueryKeyOrder is the query parameter key for the order to sort in,
+	// either OrderAscending or OrderDescending
+	QueryKeyOrder = "order"
+	// QueryKeyLimit is the query parameter key for the maximum number of
+	// Balances to return
+	QueryKeyLimit = "limit"
+	// QueryKeyCursor is the query parameter key for the storage.BalanceCursor
+	// of the last Balance of a previous page of Balances, so that the Balances
+	// that follow it are returned
+	QueryKeyCursor = "cursor"
+
+	// OrderAscending is the QueryKeyOrder value for ascending order, which
+	// is the default order
+	OrderAscending = "asc"
+	// OrderDescending is the QueryKeyOrder value for descending order
+	OrderDescending = "desc"
 )
 
-// New creates a new mux.Router and initialises it with generateRoutes for the store
-func New(store storage.Storage) (*mux.Router, error) {
+// New creates a new mux.Router and initialises it with generateRoutes for the
+// store, configured with any given Options
+func New(store storage.Storage, opts ...Option) (*mux.Router, error) {
 	if store == nil {
 		return nil, errors.New("nil store")
 	}
-	rs := generateRoutes(environment{storage: store})
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	env := environment{storage: store, maxStorageLatency: defaultMaxStorageLatency}
+	if c.maxStorageLatency != 0 {
+		env.maxStorageLatency = c.maxStorageLatency
+	}
+	if users, ok := c.tokens.(storage.UserStorage); ok {
+		env.users = users
+	}
+	rs := generateRoutes(env)
+	if c.tokens != nil {
+		for i := range rs {
+			if !rs[i].public {
+				rs[i].appHandler = requireToken(c.tokens, rs[i].appHandler)
+			}
+		}
+	}
 	return newRouter(rs)
 }
 
@@ -65,11 +204,35 @@ func newRouter(rs []route) (*mux.Router, error) {
 			Handler(handler)
 		log.Printf("Route registered: %+v", route)
 	}
+	router.NotFoundHandler = logger(func(r *http.Request) (int, interface{}, error) {
+		return http.StatusNotFound, nil, errors.Errorf("no endpoint %s", r.URL.Path)
+	}, "NotFound")
+	router.MethodNotAllowedHandler = logger(func(r *http.Request) (int, interface{}, error) {
+		return http.StatusMethodNotAllowed, nil, errors.Errorf("method %s not allowed for endpoint %s", r.Method, r.URL.Path)
+	}, "MethodNotAllowed")
 	return router, nil
 }
 
 type environment struct {
 	storage storage.Storage
+	// users is set when requests are authenticated by Tokens that can be for
+	// Users, in which case the storage is scoped to the User of each request
+	users storage.UserStorage
+	// maxStorageLatency is the longest that the storage can take to respond
+	// for the server to be ready
+	maxStorageLatency time.Duration
+}
+
+// contextStorage returns the storage of the environment as a
+// storage.ContextStorage, so that handlers can pass the context.Context of
+// their request down to it. When the environment has users, the storage is
+// scoped to the User held by the context.Context.
+func (env *environment) contextStorage() storage.ContextStorage {
+	cs := storage.WithContext(env.storage)
+	if env.users != nil {
+		return storage.WithUserScope(cs, env.users)
+	}
+	return cs
 }
 
 func generateRoutes(e environment) []route {
@@ -77,7 +240,13 @@ func generateRoutes(e environment) []route {
 		{
 			name:       "Accounts",
 			pattern:    patternAccounts,
-			appHandler: e.handlerSelectAccounts,
+			appHandler: e.muxAccountsHandlerFunc,
+			method:     http.MethodGet,
+		},
+		{
+			name:       "AccountsBalances",
+			pattern:    EndpointAccountsBalances,
+			appHandler: e.muxAccountsBalancesHandlerFunc,
 			method:     http.MethodGet,
 		},
 		{
@@ -116,5 +285,85 @@ func generateRoutes(e environment) []route {
 			appHandler: e.muxAccountBalanceInsertHandlerFunc,
 			method:     http.MethodPost,
 		},
+		{
+			name:       "BalanceUpdate",
+			pattern:    patternAccountBalanceUpdate,
+			appHandler: e.muxAccountBalanceUpdateHandlerFunc,
+			method:     http.MethodPost,
+		},
+		{
+			name:       "BalanceDelete",
+			pattern:    patternAccountBalance,
+			appHandler: e.muxAccountBalanceDeleteHandlerFunc,
+			method:     http.MethodDelete,
+		},
+		{
+			name:       "AccountTransactions",
+			pattern:    patternAccountTransactions,
+			appHandler: e.muxAccountTransactionsHandlerFunc,
+			method:     http.MethodGet,
+		},
+		{
+			name:       "AccountShare",
+			pattern:    patternAccountShare,
+			appHandler: e.muxAccountShareHandlerFunc,
+			method:     http.MethodPost,
+		},
+		{
+			name:       "Transactions",
+			pattern:    patternTransactions,
+			appHandler: e.handlerSelectTransactions,
+			method:     http.MethodGet,
+		},
+		{
+			name:       "TransactionInsert",
+			pattern:    EndpointTransactionInsert,
+			appHandler: e.muxTransactionInsertHandlerFunc,
+			method:     http.MethodPost,
+		},
+		{
+			name:       "Rates",
+			pattern:    patternRates,
+			appHandler: e.handlerSelectRates,
+			method:     http.MethodGet,
+		},
+		{
+			name:       "RateInsert",
+			pattern:    EndpointRateInsert,
+			appHandler: e.muxRateInsertHandlerFunc,
+			method:     http.MethodPost,
+		},
+		{
+			name:       "Export",
+			pattern:    EndpointExport,
+			appHandler: e.handlerExport,
+			method:     http.MethodGet,
+		},
+		{
+			name:       "Restore",
+			pattern:    EndpointRestore,
+			appHandler: e.muxRestoreHandlerFunc,
+			method:     http.MethodPost,
+		},
+		{
+			name:       "ReportNetWorth",
+			pattern:    EndpointReportNetWorth,
+			appHandler: e.muxNetWorthHandlerFunc,
+			method:     http.MethodGet,
+		},
+		{
+			name:       "Health",
+			pattern:    EndpointHealth,
+			appHandler: e.handlerHealth,
+			method:     http.MethodGet,
+			public:     true,
+		},
+		{
+			name:       "Ready",
+			pattern:    EndpointReady,
+			appHandler: e.handlerReady,
+			method:     http.MethodGet,
+			public:     true,
+		},
 	}
 }
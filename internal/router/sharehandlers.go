@@ -0,0 +1,74 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// AccountShare is the body of a request to share an Account with a User, and
+// of its response
+type AccountShare struct {
+	// User is the name of the User to share the Account with
+	User string `json:"user"`
+	// Access is the Access to give the User, replacing any that they have
+	Access storage.Access `json:"access"`
+}
+
+func (env *environment) muxAccountShareHandlerFunc(r *http.Request) (int, interface{}, error) {
+	id, err := extractID(mux.Vars(r))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
+	}
+
+	bod, err := readBody(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	var s AccountShare
+	err = json.Unmarshal(bod, &s)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
+	}
+	return env.shareAccount(r.Context(), id, s)
+}
+
+// shareAccount gives a User an Access to an Account. Only the owner of the
+// Account, or a caller that is not a User, can share it.
+func (env *environment) shareAccount(ctx context.Context, id uint, s AccountShare) (int, interface{}, error) {
+	if env.users == nil {
+		return http.StatusNotImplemented, nil, errors.New("server does not have users")
+	}
+	access, err := storage.ParseAccess(string(s.Access))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "parsing access")
+	}
+	_, err = env.contextStorage().SelectAccountContext(ctx, id)
+	if err != nil {
+		return accountErrorStatus(err), nil, errors.Wrapf(err, "selecting Account with id:%d from storage", id)
+	}
+	err = storage.RequireAccess(ctx, env.users, id, storage.AccessOwner)
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "checking access to account")
+	}
+	u, err := env.users.SelectUserByName(s.User)
+	if errors.Cause(err) == storage.ErrNoUser {
+		return http.StatusNotFound, nil, errors.Wrapf(err, "selecting user %q", s.User)
+	}
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrapf(err, "selecting user %q from storage", s.User)
+	}
+	if callerID, ok := storage.UserFromContext(ctx); ok && callerID == u.ID {
+		return http.StatusBadRequest, nil, errors.New("cannot change your own access to an account")
+	}
+	err = env.users.SetAccountAccess(id, u.ID, access)
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "setting account access in storage")
+	}
+	return http.StatusOK, AccountShare{User: u.Name, Access: access}, nil
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountShare(t *testing.T) {
+	store := memory.New()
+	token := func(name string) string {
+		u, err := store.InsertUser(name)
+		common.FatalIfError(t, err, "inserting user")
+		secret, err := storage.NewTokenSecret()
+		common.FatalIfError(t, err, "generating secret")
+		_, err = store.InsertToken(name, storage.HashTokenSecret(secret), u.ID)
+		common.FatalIfError(t, err, "inserting token")
+		return secret
+	}
+	alice, bob := token("alice"), token("bob")
+	r, err := New(store, WithTokenAuth(store))
+	common.FatalIfError(t, err, "creating router")
+
+	serve := func(secret, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+secret)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	accounts := func(secret string) storage.Accounts {
+		w := serve(secret, http.MethodGet, EndpointAccounts, "")
+		var as storage.Accounts
+		common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &as), "unmarshalling accounts")
+		return as
+	}
+
+	bs, err := json.Marshal(accountingtest.NewAccount(t, "joint", accountingtest.NewCurrencyCode(t, "GBP"), time.Date(2018, time.May, 1, 0, 0, 0, 0, time.UTC)))
+	common.FatalIfError(t, err, "marshalling account")
+	w := serve(alice, http.MethodPost, EndpointAccountInsert, string(bs))
+	var a storage.Account
+	common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &a), "unmarshalling account")
+	share := fmt.Sprintf(EndpointFmtAccountShare, a.ID)
+
+	assert.Len(t, accounts(alice), 1)
+	assert.Len(t, accounts(bob), 0)
+	assert.Equal(t, http.StatusNotFound, serve(bob, http.MethodGet, fmt.Sprintf(EndpointFmtAccount, a.ID), "").Code)
+
+	for _, test := range []struct {
+		name   string
+		secret string
+		body   string
+		status int
+	}{
+		{name: "account not visible", secret: bob, body: `{"user":"bob","access":"read"}`, status: http.StatusNotFound},
+		{name: "unknown user", secret: alice, body: `{"user":"carol","access":"read"}`, status: http.StatusNotFound},
+		{name: "unknown access", secret: alice, body: `{"user":"bob","access":"admin"}`, status: http.StatusBadRequest},
+		{name: "own access", secret: alice, body: `{"user":"alice","access":"read"}`, status: http.StatusBadRequest},
+		{name: "share", secret: alice, body: `{"user":"bob","access":"read"}`, status: http.StatusOK},
+		{name: "not the owner", secret: bob, body: `{"user":"bob","access":"owner"}`, status: http.StatusForbidden},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.status, serve(test.secret, http.MethodPost, share, test.body).Code)
+		})
+	}
+
+	assert.Len(t, accounts(bob), 1)
+	assert.Equal(t, http.StatusOK, serve(bob, http.MethodGet, fmt.Sprintf(EndpointFmtAccountBalances, a.ID), "").Code)
+	bs, err = json.Marshal(balance.Balance{Date: time.Date(2018, time.May, 2, 0, 0, 0, 0, time.UTC), Amount: 100})
+	common.FatalIfError(t, err, "marshalling balance")
+	b := string(bs)
+	assert.Equal(t, http.StatusForbidden, serve(bob, http.MethodPost, fmt.Sprintf(EndpointFmtAccountBalanceInsert, a.ID), b).Code)
+	assert.Equal(t, http.StatusForbidden, serve(bob, http.MethodGet, EndpointExport, "").Code)
+
+	w = serve(alice, http.MethodPost, share, `{"user":"bob","access":"write"}`)
+	var res AccountShare
+	common.FatalIfError(t, json.Unmarshal(w.Body.Bytes(), &res), "unmarshalling share")
+	assert.Equal(t, AccountShare{User: "bob", Access: storage.AccessWrite}, res)
+	assert.Equal(t, http.StatusOK, serve(bob, http.MethodPost, fmt.Sprintf(EndpointFmtAccountBalanceInsert, a.ID), b).Code)
+
+	t.Run("without users", func(t *testing.T) {
+		r, err := New(store)
+		common.FatalIfError(t, err, "creating router")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, share, strings.NewReader(`{"user":"bob","access":"read"}`)))
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	})
+}
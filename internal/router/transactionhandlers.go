@@ -0,0 +1,116 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/glynternet/mon/pkg/reconcile"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+func (env *environment) handlerSelectTransactions(r *http.Request) (int, interface{}, error) {
+	ts, err := env.contextStorage().SelectTransactionsContext(r.Context())
+	if err != nil {
+		return http.StatusServiceUnavailable, nil, errors.Wrap(err, "selecting Transactions from storage")
+	}
+	return http.StatusOK, ts, nil
+}
+
+func (env *environment) accountTransactions(ctx context.Context, accountID uint) (int, interface{}, error) {
+	a, err := env.contextStorage().SelectAccountContext(ctx, accountID)
+	if err != nil {
+		return accountErrorStatus(err), nil, errors.Wrapf(err, "selecting account with id %d", accountID)
+	}
+	ts, err := env.contextStorage().SelectAccountTransactionsContext(ctx, *a)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "selecting transactions for account %+v", *a)
+	}
+	return http.StatusOK, ts, nil
+}
+
+func (env *environment) muxAccountTransactionsHandlerFunc(r *http.Request) (int, interface{}, error) {
+	id, err := extractID(mux.Vars(r))
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "extracting account ID")
+	}
+	return env.accountTransactions(r.Context(), id)
+}
+
+// InsertedTransaction is the response to a request to insert a Transaction,
+// holding the stored Transaction and a TransactionCheck for each of its
+// Accounts.
+type InsertedTransaction struct {
+	storage.Transaction
+	Checks []TransactionCheck
+}
+
+// TransactionCheck holds the reconciliation of an Account over the Interval
+// between the Balances recorded either side of a Transaction, including the
+// Transaction itself. Interval is nil when the Account has no Balances either
+// side of the Transaction to check against.
+type TransactionCheck struct {
+	AccountID uint
+	Interval  *reconcile.Interval
+}
+
+func (env *environment) insertTransaction(ctx context.Context, t storage.Transaction) (int, interface{}, error) {
+	inserted, err := env.contextStorage().InsertTransactionContext(ctx, t)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrap(err, "inserting transaction")
+	}
+	res := InsertedTransaction{Transaction: *inserted}
+	for _, id := range []uint{inserted.SourceID, inserted.DestinationID} {
+		c, err := env.checkTransaction(ctx, *inserted, id)
+		if err != nil {
+			// the Transaction has been stored, so it is returned without
+			// the check rather than as a failure
+			log.Print(errors.Wrapf(err, "checking transaction %d against balances of account %d", inserted.ID, id))
+			continue
+		}
+		res.Checks = append(res.Checks, c)
+	}
+	return http.StatusOK, res, nil
+}
+
+// checkTransaction reconciles the Account with the given id over the Interval
+// that contains the date of the Transaction.
+func (env *environment) checkTransaction(ctx context.Context, t storage.Transaction, accountID uint) (TransactionCheck, error) {
+	c := TransactionCheck{AccountID: accountID}
+	a, err := env.contextStorage().SelectAccountContext(ctx, accountID)
+	if err != nil {
+		return c, errors.Wrap(err, "selecting account")
+	}
+	bs, err := env.contextStorage().SelectAccountBalancesContext(ctx, *a)
+	if err != nil {
+		return c, errors.Wrap(err, "selecting account balances")
+	}
+	ts, err := env.contextStorage().SelectAccountTransactionsContext(ctx, *a)
+	if err != nil {
+		return c, errors.Wrap(err, "selecting account transactions")
+	}
+	for _, i := range reconcile.Account(accountID, *bs, *ts) {
+		if i.Contains(t.Date) {
+			c.Interval = &i
+			break
+		}
+	}
+	return c, nil
+}
+
+func (env *environment) muxTransactionInsertHandlerFunc(r *http.Request) (int, interface{}, error) {
+	bod, err := readBody(r)
+	if err != nil {
+		return http.StatusBadRequest, nil, err
+	}
+
+	var t storage.Transaction
+	err = json.Unmarshal(bod, &t)
+	if err != nil {
+		return http.StatusBadRequest, nil, errors.Wrapf(err, "unmarshalling request body")
+	}
+	return env.insertTransaction(r.Context(), t)
+}
@@ -0,0 +1,141 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_handlerSelectTransactions(t *testing.T) {
+	t.Run("SelectTransactions error", func(t *testing.T) {
+		expected := errors.New("transactions error")
+		srv := environment{storage: &storagetest.Storage{TransactionsErr: expected}}
+		code, ts, err := srv.handlerSelectTransactions(httptest.NewRequest(http.MethodGet, EndpointTransactions, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, ts)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		expected := &storage.Transactions{{ID: 1}}
+		srv := environment{storage: &storagetest.Storage{Transactions: expected}}
+		code, ts, err := srv.handlerSelectTransactions(httptest.NewRequest(http.MethodGet, EndpointTransactions, nil))
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ts)
+	})
+}
+
+func Test_accountTransactions(t *testing.T) {
+	t.Run("SelectAccount error", func(t *testing.T) {
+		expected := errors.New("account error")
+		srv := environment{storage: &storagetest.Storage{AccountErr: expected}}
+		code, ts, err := srv.accountTransactions(context.Background(), 1) // any ID can be used because of the stub
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, ts)
+	})
+
+	t.Run("no account", func(t *testing.T) {
+		srv := environment{storage: &storagetest.Storage{AccountErr: errors.Wrap(storage.ErrNoAccount, "account with id 1")}}
+		code, ts, err := srv.accountTransactions(context.Background(), 1)
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+		assert.Nil(t, ts)
+	})
+
+	t.Run("SelectAccountTransactions error", func(t *testing.T) {
+		expected := errors.New("transactions error")
+		srv := environment{storage: &storagetest.Storage{
+			Account:         &storage.Account{},
+			TransactionsErr: expected,
+		}}
+		code, ts, err := srv.accountTransactions(context.Background(), 1)
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, ts)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		expected := &storage.Transactions{{ID: 1}}
+		srv := environment{storage: &storagetest.Storage{
+			Account:      &storage.Account{},
+			Transactions: expected,
+		}}
+		code, ts, err := srv.accountTransactions(context.Background(), 1)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, ts)
+	})
+}
+
+func Test_insertTransaction(t *testing.T) {
+	t.Run("InsertTransaction error", func(t *testing.T) {
+		expected := errors.New("InsertTransaction error")
+		srv := environment{storage: &storagetest.Storage{TransactionErr: expected}}
+		code, tx, err := srv.insertTransaction(context.Background(), storage.Transaction{})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, expected, errors.Cause(err))
+		assert.Nil(t, tx)
+	})
+
+	t.Run("all ok", func(t *testing.T) {
+		d := date.New(2018, time.May, 4)
+		inserted := &storage.Transaction{ID: 1, Date: d.AddDays(1), Amount: 10, SourceID: 1, DestinationID: 2}
+		// the stub returns the same Balances and Transactions for each Account
+		srv := environment{storage: &storagetest.Storage{
+			Transaction:  inserted,
+			Transactions: &storage.Transactions{*inserted},
+			Account:      &storage.Account{ID: 1},
+			Balances: &storage.Balances{
+				{ID: 1, Balance: balance.Balance{Date: d.Time(), Amount: 100}},
+				{ID: 2, Balance: balance.Balance{Date: d.AddDays(2).Time(), Amount: 90}},
+			},
+		}}
+		code, res, err := srv.insertTransaction(context.Background(), storage.Transaction{})
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		it, ok := res.(InsertedTransaction)
+		if !assert.True(t, ok, "%T", res) {
+			t.FailNow()
+		}
+		assert.Equal(t, *inserted, it.Transaction)
+		if assert.Len(t, it.Checks, 2) {
+			source, destination := it.Checks[0], it.Checks[1]
+			assert.Equal(t, uint(1), source.AccountID)
+			if assert.NotNil(t, source.Interval) {
+				assert.True(t, source.Interval.Reconciled())
+			}
+			assert.Equal(t, uint(2), destination.AccountID)
+			if assert.NotNil(t, destination.Interval) {
+				assert.Equal(t, -20, destination.Interval.Unexplained())
+			}
+		}
+	})
+
+	t.Run("no balances either side", func(t *testing.T) {
+		inserted := &storage.Transaction{ID: 1, SourceID: 1, DestinationID: 2}
+		srv := environment{storage: &storagetest.Storage{
+			Transaction:  inserted,
+			Transactions: &storage.Transactions{*inserted},
+			Account:      &storage.Account{ID: 1},
+			Balances:     &storage.Balances{},
+		}}
+		code, res, err := srv.insertTransaction(context.Background(), storage.Transaction{})
+		assert.Equal(t, http.StatusOK, code)
+		assert.NoError(t, err)
+		assert.Equal(t, InsertedTransaction{
+			Transaction: *inserted,
+			Checks:      []TransactionCheck{{AccountID: 1}, {AccountID: 2}},
+		}, res)
+	})
+}
@@ -0,0 +1,77 @@
+package tlsconfig_test
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/internal/tlsconfig"
+	"github.com/glynternet/mon/internal/tlsconfig/tlsconfigtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerAndClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mon-tlsconfig")
+	common.FatalIfError(t, err, "creating temp dir")
+	defer func() {
+		common.FatalIfError(t, os.RemoveAll(dir), "removing temp dir")
+	}()
+	fs := tlsconfigtest.Generate(t, dir)
+
+	newServer := func(t *testing.T, clientCA string) *httptest.Server {
+		c, err := tlsconfig.Server(fs.ServerCert, fs.ServerKey, clientCA)
+		common.FatalIfError(t, err, "creating server config")
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		srv.TLS = c
+		srv.StartTLS()
+		return srv
+	}
+	get := func(t *testing.T, url string, c *tls.Config) error {
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: c}}
+		res, err := hc.Get(url)
+		if err == nil {
+			common.FatalIfError(t, res.Body.Close(), "closing body")
+		}
+		return err
+	}
+	client := func(t *testing.T, ca, cert, key string) *tls.Config {
+		c, err := tlsconfig.Client(ca, cert, key)
+		common.FatalIfError(t, err, "creating client config")
+		return c
+	}
+
+	t.Run("TLS", func(t *testing.T) {
+		srv := newServer(t, "")
+		defer srv.Close()
+		assert.NoError(t, get(t, srv.URL, client(t, fs.CA, "", "")))
+		assert.Error(t, get(t, srv.URL, client(t, "", "", "")), "a server signed by an unknown CA should be rejected")
+	})
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		srv := newServer(t, fs.CA)
+		defer srv.Close()
+		assert.NoError(t, get(t, srv.URL, client(t, fs.CA, fs.ClientCert, fs.ClientKey)))
+		assert.Error(t, get(t, srv.URL, client(t, fs.CA, "", "")), "a client without a certificate should be rejected")
+		assert.Error(t, get(t, srv.URL, client(t, fs.CA, fs.ServerCert, fs.ServerKey)), "a certificate that is not for clients should be rejected")
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		_, err := tlsconfig.Server("", fs.ServerKey, "")
+		assert.Error(t, err)
+		_, err = tlsconfig.Server(fs.ServerCert, fs.ClientKey, "")
+		assert.Error(t, err, "a key that does not match the certificate should be rejected")
+		_, err = tlsconfig.Server(fs.ServerCert, fs.ServerKey, filepath.Join(dir, "missing.pem"))
+		assert.Error(t, err)
+		_, err = tlsconfig.Server(fs.ServerCert, fs.ServerKey, fs.ServerKey)
+		assert.Error(t, err, "a CA file without certificates should be rejected")
+		_, err = tlsconfig.Client(fs.CA, fs.ClientCert, "")
+		assert.Error(t, err)
+		_, err = tlsconfig.Client(filepath.Join(dir, "missing.pem"), "", "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,104 @@
+// Package tlsconfigtest generates self-signed certificates for testing TLS
+// servers and clients.
+package tlsconfigtest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-money/common"
+)
+
+// Files holds the paths of the PEM encoded files of a generated CA and of a
+// server and client certificate that are signed by it.
+type Files struct {
+	CA         string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+}
+
+// Generate generates a self-signed CA, a server certificate for localhost and
+// 127.0.0.1 and a client certificate, and writes them to files in the given
+// directory.
+func Generate(t *testing.T, dir string) Files {
+	fs := Files{
+		CA:         filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "mon test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caKey := newKey(t)
+	writeCert(t, fs.CA, ca, ca, caKey, caKey)
+
+	server := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverKey := newKey(t)
+	writeCert(t, fs.ServerCert, server, ca, serverKey, caKey)
+	writeKey(t, fs.ServerKey, serverKey)
+
+	client := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "mon test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientKey := newKey(t)
+	writeCert(t, fs.ClientCert, client, ca, clientKey, caKey)
+	writeKey(t, fs.ClientKey, clientKey)
+	return fs
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	common.FatalIfError(t, err, "generating key")
+	return k
+}
+
+func writeCert(t *testing.T, file string, cert, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) {
+	der, err := x509.CreateCertificate(rand.Reader, cert, parent, &key.PublicKey, parentKey)
+	common.FatalIfError(t, err, "creating certificate")
+	writePEM(t, file, "CERTIFICATE", der)
+}
+
+func writeKey(t *testing.T, file string, key *ecdsa.PrivateKey) {
+	der, err := x509.MarshalECPrivateKey(key)
+	common.FatalIfError(t, err, "marshalling key")
+	writePEM(t, file, "EC PRIVATE KEY", der)
+}
+
+func writePEM(t *testing.T, file, blockType string, der []byte) {
+	bs := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	common.FatalIfError(t, ioutil.WriteFile(file, bs, 0600), "writing "+file)
+}
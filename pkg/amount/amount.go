@@ -0,0 +1,26 @@
+// Package amount formats amounts of minor currency units, such as pence or
+// cents, as decimal amounts of major units.
+package amount
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format formats an amount of minor units as a decimal amount with the given
+// number of decimal places.
+func Format(amount, decimalPlaces int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.Itoa(amount)
+	if decimalPlaces <= 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimalPlaces {
+		digits = strings.Repeat("0", decimalPlaces-len(digits)+1) + digits
+	}
+	split := len(digits) - decimalPlaces
+	return sign + digits[:split] + "." + digits[split:]
+}
@@ -0,0 +1,24 @@
+package amount
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	for _, test := range []struct {
+		amount, places int
+		expected       string
+	}{
+		{amount: 12345, places: 2, expected: "123.45"},
+		{amount: 5, places: 2, expected: "0.05"},
+		{amount: -5, places: 2, expected: "-0.05"},
+		{amount: -100, places: 2, expected: "-1.00"},
+		{amount: 0, places: 2, expected: "0.00"},
+		{amount: 12345, places: 0, expected: "12345"},
+		{amount: 7, places: 3, expected: "0.007"},
+	} {
+		assert.Equal(t, test.expected, Format(test.amount, test.places))
+	}
+}
@@ -0,0 +1,248 @@
+// Package backup exports the whole of a storage.ArchiveStorage into a
+// versioned Document and restores a Document into an empty one.
+package backup
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// Version is the version of the Document format that is written by Export and
+// read by Restore. Version 2 added Users, and Restore still reads version 1
+// Documents, which have none.
+const Version = 2
+
+// Document holds every Account of a storage, including those that have been
+// deleted, along with all of their Balances, Transactions and Rates, and every
+// User along with their Access to the Accounts.
+type Document struct {
+	Version      int
+	Exported     time.Time
+	Accounts     []Account
+	Transactions storage.Transactions
+	Rates        storage.Rates
+	Users        []User
+}
+
+// Account holds an Account along with all of its Balances
+type Account struct {
+	Account  storage.Account
+	Balances storage.Balances
+}
+
+// User holds a User along with their Access to each Account, keyed by the ID
+// of the Account in the Document
+type User struct {
+	User   storage.User
+	Access map[uint]storage.Access
+}
+
+// Summary holds the number of each item of a Document
+type Summary struct {
+	Accounts, DeletedAccounts, Balances, Transactions, Rates, Users int
+}
+
+// Summary returns the number of each item held by the Document
+func (d Document) Summary() Summary {
+	s := Summary{
+		Accounts:     len(d.Accounts),
+		Transactions: len(d.Transactions),
+		Rates:        len(d.Rates),
+		Users:        len(d.Users),
+	}
+	for _, a := range d.Accounts {
+		if a.Account.Deleted() {
+			s.DeletedAccounts++
+		}
+		s.Balances += len(a.Balances)
+	}
+	return s
+}
+
+// Export returns a Document holding the whole of the store. Users are only
+// exported when the store is a storage.UserStorage.
+func Export(store storage.ArchiveStorage) (*Document, error) {
+	as, err := store.SelectAllAccounts()
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting all accounts")
+	}
+	d := Document{
+		Version:  Version,
+		Exported: time.Now().UTC(),
+		Accounts: make([]Account, len(*as)),
+	}
+	for i, a := range *as {
+		bs, err := store.SelectAccountBalances(a)
+		if err != nil {
+			return nil, errors.Wrapf(err, "selecting balances of account %d", a.ID)
+		}
+		d.Accounts[i] = Account{Account: a, Balances: *bs}
+	}
+	ts, err := store.SelectTransactions()
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting transactions")
+	}
+	d.Transactions = *ts
+	rs, err := store.SelectRates()
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting rates")
+	}
+	d.Rates = *rs
+	if us, ok := store.(storage.UserStorage); ok {
+		d.Users, err = exportUsers(us)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &d, nil
+}
+
+func exportUsers(store storage.UserStorage) ([]User, error) {
+	us, err := store.SelectUsers()
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting users")
+	}
+	users := make([]User, len(*us))
+	for i, u := range *us {
+		access, err := store.SelectUserAccess(u.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "selecting access of user %d", u.ID)
+		}
+		users[i] = User{User: u, Access: access}
+	}
+	return users, nil
+}
+
+// Restore inserts every item of the Document into the store, which must be
+// empty. Items are given new IDs as they are inserted and the Balances and
+// Transactions of each Account are linked to the new ID of the Account.
+// Deleted Accounts are deleted at their original time once all of their
+// Balances and Transactions have been inserted. Users are matched to any User
+// of the store with the same name, so that the Tokens of existing Users keep
+// authenticating them, and are inserted otherwise. The Document is restored
+// in a single transaction of the store, so nothing is restored if an error is
+// returned.
+func Restore(store storage.ArchiveStorage, d Document) error {
+	if d.Version < 1 || d.Version > Version {
+		return fmt.Errorf("unsupported document version %d, expected at most %d", d.Version, Version)
+	}
+	return store.InTransaction(func(tx storage.ArchiveStorage) error {
+		return restore(tx, d)
+	})
+}
+
+func restore(store storage.ArchiveStorage, d Document) error {
+	if err := checkEmpty(store); err != nil {
+		return err
+	}
+
+	as := make([]Account, len(d.Accounts))
+	copy(as, d.Accounts)
+	sort.SliceStable(as, func(i, j int) bool {
+		return as[i].Account.ID < as[j].Account.ID
+	})
+	ids := make(map[uint]uint, len(as))
+	for _, a := range as {
+		if _, ok := ids[a.Account.ID]; ok {
+			return fmt.Errorf("document holds more than one account with id %d", a.Account.ID)
+		}
+		inserted, err := store.InsertAccount(a.Account.Account)
+		if err != nil {
+			return errors.Wrapf(err, "inserting account %d", a.Account.ID)
+		}
+		ids[a.Account.ID] = inserted.ID
+		for _, b := range a.Balances {
+			if _, err := store.InsertBalance(*inserted, b.Balance); err != nil {
+				return errors.Wrapf(err, "inserting balance %d of account %d", b.ID, a.Account.ID)
+			}
+		}
+	}
+
+	for _, t := range d.Transactions {
+		var ok bool
+		if t.SourceID, ok = ids[t.SourceID]; !ok {
+			return fmt.Errorf("transaction %d has unknown source account", t.ID)
+		}
+		if t.DestinationID, ok = ids[t.DestinationID]; !ok {
+			return fmt.Errorf("transaction %d has unknown destination account", t.ID)
+		}
+		if _, err := store.InsertTransaction(t); err != nil {
+			return errors.Wrapf(err, "inserting transaction %d", t.ID)
+		}
+	}
+
+	for _, r := range d.Rates {
+		if _, err := store.InsertRate(r); err != nil {
+			return errors.Wrapf(err, "inserting rate %d", r.ID)
+		}
+	}
+
+	if len(d.Users) > 0 {
+		us, ok := store.(storage.UserStorage)
+		if !ok {
+			return errors.New("storage cannot store the users of the document")
+		}
+		for _, u := range d.Users {
+			if err := restoreUser(us, u, ids); err != nil {
+				return errors.Wrapf(err, "restoring user %d", u.User.ID)
+			}
+		}
+	}
+
+	for _, a := range as {
+		if at, deleted := a.Account.DeletedTime(); deleted {
+			if err := store.DeleteAccountAt(ids[a.Account.ID], at); err != nil {
+				return errors.Wrapf(err, "deleting account %d", a.Account.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// restoreUser restores a User and their Access to each Account, using ids to
+// find the ID that each Account was restored with.
+func restoreUser(store storage.UserStorage, u User, ids map[uint]uint) error {
+	restored, err := store.SelectUserByName(u.User.Name)
+	if errors.Cause(err) == storage.ErrNoUser {
+		restored, err = store.InsertUser(u.User.Name)
+	}
+	if err != nil {
+		return errors.Wrap(err, "inserting user")
+	}
+	for accountID, a := range u.Access {
+		if _, err := storage.ParseAccess(string(a)); err != nil {
+			return err
+		}
+		id, ok := ids[accountID]
+		if !ok {
+			return fmt.Errorf("access to unknown account %d", accountID)
+		}
+		if err := store.SetAccountAccess(id, restored.ID, a); err != nil {
+			return errors.Wrapf(err, "setting access to account %d", accountID)
+		}
+	}
+	return nil
+}
+
+// checkEmpty returns an error if the store holds any Accounts or Rates
+func checkEmpty(store storage.ArchiveStorage) error {
+	as, err := store.SelectAllAccounts()
+	if err != nil {
+		return errors.Wrap(err, "selecting all accounts")
+	}
+	if len(*as) > 0 {
+		return fmt.Errorf("storage must be empty but holds %d account(s)", len(*as))
+	}
+	rs, err := store.SelectRates()
+	if err != nil {
+		return errors.Wrap(err, "selecting rates")
+	}
+	if len(*rs) > 0 {
+		return fmt.Errorf("storage must be empty but holds %d rate(s)", len(*rs))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package backup
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportRestore(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	source := memory.New()
+	var as []*storage.Account
+	for _, name := range []string{"A", "B", "C"} {
+		a, err := source.InsertAccount(*accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), d.Time()))
+		common.FatalIfError(t, err, "inserting account")
+		as = append(as, a)
+		for days := 0; days < 2; days++ {
+			_, err := source.InsertBalance(*a, balance.Balance{Date: d.AddDays(days).Time(), Amount: len(as) * days})
+			common.FatalIfError(t, err, "inserting balance")
+		}
+	}
+	_, err := source.InsertTransaction(storage.Transaction{Date: d.AddDays(1), Amount: 10, SourceID: as[0].ID, DestinationID: as[2].ID})
+	common.FatalIfError(t, err, "inserting transaction")
+	_, err = source.InsertRate(storage.Rate{Date: d, From: "EUR", To: "GBP", Rate: 0.875})
+	common.FatalIfError(t, err, "inserting rate")
+	alice, err := source.InsertUser("alice")
+	common.FatalIfError(t, err, "inserting user")
+	common.FatalIfError(t, source.SetAccountAccess(as[0].ID, alice.ID, storage.AccessOwner), "setting access")
+	common.FatalIfError(t, source.SetAccountAccess(as[2].ID, alice.ID, storage.AccessRead), "setting access")
+	_, err = source.InsertUser("bob")
+	common.FatalIfError(t, err, "inserting user")
+	deletedAt := time.Date(2018, time.May, 6, 12, 0, 0, 0, time.UTC)
+	common.FatalIfError(t, source.DeleteAccountAt(as[2].ID, deletedAt), "deleting account")
+
+	exported, err := Export(source)
+	common.FatalIfError(t, err, "exporting")
+	assert.Equal(t, Summary{Accounts: 3, DeletedAccounts: 1, Balances: 6, Transactions: 1, Rates: 1, Users: 2}, exported.Summary())
+
+	data, err := json.Marshal(exported)
+	common.FatalIfError(t, err, "marshalling document")
+	var unmarshalled Document
+	common.FatalIfError(t, json.Unmarshal(data, &unmarshalled), "unmarshalling document")
+
+	destination := memory.New()
+	common.FatalIfError(t, Restore(destination, unmarshalled), "restoring")
+	restored, err := Export(destination)
+	common.FatalIfError(t, err, "exporting restored")
+	restored.Exported = exported.Exported
+	restoredData, err := json.Marshal(restored)
+	common.FatalIfError(t, err, "marshalling restored document")
+	assert.JSONEq(t, string(data), string(restoredData))
+
+	t.Run("into non-empty storage", func(t *testing.T) {
+		assert.Error(t, Restore(destination, unmarshalled))
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		unsupported := unmarshalled
+		unsupported.Version = Version + 1
+		assert.Error(t, Restore(memory.New(), unsupported))
+	})
+
+	t.Run("version 1 document", func(t *testing.T) {
+		v1 := unmarshalled
+		v1.Version = 1
+		v1.Users = nil
+		assert.NoError(t, Restore(memory.New(), v1))
+	})
+
+	t.Run("access to unknown account", func(t *testing.T) {
+		unknown := unmarshalled
+		unknown.Users = []User{{User: storage.User{ID: 1, Name: "alice"}, Access: map[uint]storage.Access{99: storage.AccessRead}}}
+		assert.Error(t, Restore(memory.New(), unknown))
+	})
+
+	t.Run("transaction of unknown account", func(t *testing.T) {
+		unknown := unmarshalled
+		unknown.Transactions = storage.Transactions{{Date: d.AddDays(1), Amount: 1, SourceID: as[0].ID, DestinationID: 99}}
+		store := memory.New()
+		assert.Error(t, Restore(store, unknown))
+		all, err := store.SelectAllAccounts()
+		common.FatalIfError(t, err, "selecting all accounts")
+		assert.Empty(t, *all, "nothing should be restored when restoring fails")
+		assert.NoError(t, Restore(store, unmarshalled), "restoring should be retryable")
+	})
+}
+
+func TestRestore_remapsIDs(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	account := func(id uint, name string) Account {
+		return Account{Account: storage.Account{
+			ID:      id,
+			Account: *accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), d.Time()),
+		}}
+	}
+	doc := Document{
+		Version:      Version,
+		Accounts:     []Account{account(7, "B"), account(3, "A")},
+		Transactions: storage.Transactions{{ID: 12, Date: d, Amount: 5, SourceID: 7, DestinationID: 3}},
+	}
+	doc.Accounts[0].Balances = storage.Balances{{ID: 40, Balance: balance.Balance{Date: d.Time(), Amount: 5}}}
+
+	store := memory.New()
+	common.FatalIfError(t, Restore(store, doc), "restoring")
+
+	as, err := store.SelectAccounts()
+	common.FatalIfError(t, err, "selecting accounts")
+	if !assert.Len(t, *as, 2) {
+		t.FailNow()
+	}
+	a, b := (*as)[0], (*as)[1]
+	assert.Equal(t, "A", a.Account.Name(), "accounts should be restored in order of their original id")
+	assert.Equal(t, "B", b.Account.Name())
+	bs, err := store.SelectAccountBalances(b)
+	common.FatalIfError(t, err, "selecting balances")
+	assert.Len(t, *bs, 1)
+	ts, err := store.SelectTransactions()
+	common.FatalIfError(t, err, "selecting transactions")
+	if assert.Len(t, *ts, 1) {
+		assert.Equal(t, b.ID, (*ts)[0].SourceID)
+		assert.Equal(t, a.ID, (*ts)[0].DestinationID)
+	}
+}
+
+func TestRestore_existingUser(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	doc := Document{
+		Version: Version,
+		Accounts: []Account{{Account: storage.Account{
+			ID:      5,
+			Account: *accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "GBP"), d.Time()),
+		}}},
+		Users: []User{{
+			User:   storage.User{ID: 9, Name: "alice"},
+			Access: map[uint]storage.Access{5: storage.AccessWrite},
+		}},
+	}
+
+	store := memory.New()
+	_, err := store.InsertUser("bob")
+	common.FatalIfError(t, err, "inserting user")
+	alice, err := store.InsertUser("alice")
+	common.FatalIfError(t, err, "inserting user")
+	common.FatalIfError(t, Restore(store, doc), "restoring")
+
+	us, err := store.SelectUsers()
+	common.FatalIfError(t, err, "selecting users")
+	assert.Len(t, *us, 2, "existing user should be reused")
+	as, err := store.SelectAccounts()
+	common.FatalIfError(t, err, "selecting accounts")
+	if !assert.Len(t, *as, 1) {
+		t.FailNow()
+	}
+	access, err := store.SelectUserAccess(alice.ID)
+	common.FatalIfError(t, err, "selecting access")
+	assert.Equal(t, map[uint]storage.Access{(*as)[0].ID: storage.AccessWrite}, access)
+}
@@ -0,0 +1,216 @@
+package date
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// isoFormat is the format used when writing a Date as a string
+const isoFormat = "2006-01-02"
+
+// Date is a calendar date, without any time of day or location. The zero
+// value of Date is January 1, year 1.
+type Date struct {
+	// t is always midnight UTC of the Date
+	t time.Time
+}
+
+// New returns the Date for the given year, month and day. Values outside of
+// their usual ranges are normalised in the same way as they are for time.Date.
+func New(year int, month time.Month, day int) Date {
+	return Date{t: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// FromTime returns the calendar Date of a time.Time in its own location.
+func FromTime(t time.Time) Date {
+	y, m, d := t.Date()
+	return New(y, m, d)
+}
+
+// Today returns the current Date in the local location.
+func Today() Date {
+	return FromTime(time.Now())
+}
+
+// Parse parses a Date from a string in yyyy-mm-dd format. The month and day
+// may be given with or without leading zeros.
+func Parse(value string) (Date, error) {
+	t, err := time.Parse(dateFormat, value)
+	if err != nil {
+		return Date{}, errors.Wrapf(err, "parsing into format:%s", dateFormat)
+	}
+	return FromTime(t), nil
+}
+
+// parseDateOrTimestamp parses a Date from either a yyyy-mm-dd string or a
+// RFC3339 timestamp, as dates were previously held as timestamps.
+func parseDateOrTimestamp(value string) (Date, error) {
+	d, err := Parse(value)
+	if err == nil {
+		return d, nil
+	}
+	t, tErr := time.Parse(time.RFC3339Nano, value)
+	if tErr != nil {
+		return Date{}, fmt.Errorf("unsupported date value: %q", value)
+	}
+	return FromTime(t), nil
+}
+
+// Time returns the time.Time at midnight UTC of the Date.
+func (d Date) Time() time.Time {
+	return d.t
+}
+
+// IsZero reports whether the Date is the zero value.
+func (d Date) IsZero() bool {
+	return d.t.IsZero()
+}
+
+// AddDays returns the Date that is the given number of days after the Date.
+func (d Date) AddDays(days int) Date {
+	return Date{t: d.t.AddDate(0, 0, days)}
+}
+
+// Equal returns true if both Dates are the same calendar date.
+func (d Date) Equal(o Date) bool {
+	return d.t.Equal(o.t)
+}
+
+// Before returns true if the Date is before the other Date.
+func (d Date) Before(o Date) bool {
+	return d.t.Before(o.t)
+}
+
+// After returns true if the Date is after the other Date.
+func (d Date) After(o Date) bool {
+	return d.t.After(o.t)
+}
+
+// String returns the Date in yyyy-mm-dd format.
+func (d Date) String() string {
+	return d.t.Format(isoFormat)
+}
+
+// MarshalJSON marshals the Date into a yyyy-mm-dd json string.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON unmarshals a json string holding either a yyyy-mm-dd date or
+// a RFC3339 timestamp into a Date.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return errors.Wrap(err, "unmarshalling date string")
+	}
+	*d, err = parseDateOrTimestamp(s)
+	return err
+}
+
+// Scan implements the sql.Scanner interface so that a Date can be read from
+// a database column holding either a date or a timestamp.
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = FromTime(v)
+		return nil
+	case string:
+		var err error
+		*d, err = parseDateOrTimestamp(v)
+		return err
+	case []byte:
+		var err error
+		*d, err = parseDateOrTimestamp(string(v))
+		return err
+	case nil:
+		return errors.New("cannot scan NULL into Date")
+	}
+	return fmt.Errorf("cannot scan type %T into Date", src)
+}
+
+// Value implements the driver.Valuer interface so that a Date is written to a
+// database as a yyyy-mm-dd string.
+func (d Date) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// NullDate is a Date that may be null, such as the date that an account was
+// closed on.
+type NullDate struct {
+	Date  Date
+	Valid bool
+}
+
+// Equal returns true if both NullDates are invalid or both hold the same Date.
+func (nd NullDate) Equal(o NullDate) bool {
+	if nd.Valid != o.Valid {
+		return false
+	}
+	return !nd.Valid || nd.Date.Equal(o.Date)
+}
+
+// MarshalJSON marshals the NullDate into a yyyy-mm-dd json string, or null
+// if the NullDate is not valid.
+func (nd NullDate) MarshalJSON() ([]byte, error) {
+	if !nd.Valid {
+		return []byte("null"), nil
+	}
+	return nd.Date.MarshalJSON()
+}
+
+// UnmarshalJSON unmarshals null, a json date string or a json object of the
+// form {"Time": ..., "Valid": ...}, which is how dates that could be null were
+// previously held, into a NullDate.
+func (nd *NullDate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*nd = NullDate{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '{' {
+		var aux struct {
+			Time  time.Time
+			Valid bool
+		}
+		err := json.Unmarshal(data, &aux)
+		if err != nil {
+			return errors.Wrap(err, "unmarshalling null time object")
+		}
+		*nd = NullDate{Valid: aux.Valid}
+		if aux.Valid {
+			nd.Date = FromTime(aux.Time)
+		}
+		return nil
+	}
+	err := nd.Date.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	nd.Valid = true
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (nd *NullDate) Scan(src interface{}) error {
+	if src == nil {
+		*nd = NullDate{}
+		return nil
+	}
+	err := nd.Date.Scan(src)
+	nd.Valid = err == nil
+	return err
+}
+
+// Value implements the driver.Valuer interface.
+func (nd NullDate) Value() (driver.Value, error) {
+	if !nd.Valid {
+		return nil, nil
+	}
+	return nd.Date.Value()
+}
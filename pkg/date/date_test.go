@@ -0,0 +1,137 @@
+package date
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromTime(t *testing.T) {
+	expected := New(2018, time.May, 4)
+	for _, tm := range []time.Time{
+		time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2018, time.May, 4, 13, 45, 0, 0, time.UTC),
+		time.Date(2018, time.May, 4, 23, 59, 59, 999, time.UTC),
+		time.Date(2018, time.May, 4, 0, 30, 0, 0, time.FixedZone("BST", 3600)),
+	} {
+		t.Run(tm.String(), func(t *testing.T) {
+			d := FromTime(tm)
+			assert.Equal(t, expected, d)
+			assert.True(t, expected.Equal(d))
+			assert.Equal(t, time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC), d.Time())
+		})
+	}
+}
+
+func TestDate_Comparisons(t *testing.T) {
+	d := New(2018, time.May, 4)
+	next := d.AddDays(1)
+	assert.Equal(t, New(2018, time.May, 5), next)
+	assert.True(t, d.Before(next))
+	assert.False(t, next.Before(d))
+	assert.True(t, next.After(d))
+	assert.False(t, d.After(d))
+	assert.True(t, d.Equal(FromTime(next.Time().Add(-time.Nanosecond))))
+	assert.True(t, Date{}.IsZero())
+	assert.False(t, d.IsZero())
+}
+
+func TestParse(t *testing.T) {
+	for _, val := range []string{"2018-05-04", "2018-5-4"} {
+		d, err := Parse(val)
+		assert.NoError(t, err)
+		assert.Equal(t, New(2018, time.May, 4), d)
+	}
+
+	for _, val := range []string{"", "04-05-2018", "2018-05-04T13:45:00Z"} {
+		_, err := Parse(val)
+		assert.Error(t, err, val)
+	}
+}
+
+func TestDate_JSON(t *testing.T) {
+	d := New(2018, time.May, 4)
+	bs, err := json.Marshal(d)
+	common.FatalIfError(t, err, "marshalling date")
+	assert.Equal(t, `"2018-05-04"`, string(bs))
+
+	for _, data := range []string{`"2018-05-04"`, `"2018-05-04T13:45:00Z"`, `"2018-05-04T00:30:00+01:00"`} {
+		var actual Date
+		err = json.Unmarshal([]byte(data), &actual)
+		assert.NoError(t, err, data)
+		assert.Equal(t, d, actual, data)
+	}
+
+	var invalid Date
+	assert.Error(t, json.Unmarshal([]byte(`"bloop"`), &invalid))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &invalid))
+}
+
+func TestNullDate_JSON(t *testing.T) {
+	valid := NullDate{Valid: true, Date: New(2018, time.May, 4)}
+	for _, test := range []struct {
+		name     string
+		nd       NullDate
+		expected string
+	}{
+		{name: "invalid", expected: `null`},
+		{name: "valid", nd: valid, expected: `"2018-05-04"`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			bs, err := json.Marshal(test.nd)
+			common.FatalIfError(t, err, "marshalling null date")
+			assert.Equal(t, test.expected, string(bs))
+			var actual NullDate
+			common.FatalIfError(t, json.Unmarshal(bs, &actual), "unmarshalling null date")
+			assert.True(t, test.nd.Equal(actual))
+		})
+	}
+
+	t.Run("null time object", func(t *testing.T) {
+		var actual NullDate
+		err := json.Unmarshal([]byte(`{"Time":"2018-05-04T13:45:00Z","Valid":true}`), &actual)
+		common.FatalIfError(t, err, "unmarshalling null time object")
+		assert.Equal(t, valid, actual)
+
+		err = json.Unmarshal([]byte(`{"Time":"0001-01-01T00:00:00Z","Valid":false}`), &actual)
+		common.FatalIfError(t, err, "unmarshalling null time object")
+		assert.Equal(t, NullDate{}, actual)
+	})
+}
+
+func TestDate_Scan(t *testing.T) {
+	expected := New(2018, time.May, 4)
+	for _, src := range []interface{}{
+		time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC),
+		"2018-05-04",
+		[]byte("2018-05-04"),
+		"2018-05-04T13:45:00Z",
+	} {
+		var d Date
+		assert.NoError(t, d.Scan(src))
+		assert.Equal(t, expected, d)
+	}
+
+	var d Date
+	assert.Error(t, d.Scan(nil))
+	assert.Error(t, d.Scan(1))
+
+	var nd NullDate
+	assert.NoError(t, nd.Scan(nil))
+	assert.False(t, nd.Valid)
+	assert.NoError(t, nd.Scan("2018-05-04"))
+	assert.Equal(t, NullDate{Valid: true, Date: expected}, nd)
+}
+
+func TestDate_Value(t *testing.T) {
+	v, err := New(2018, time.May, 4).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "2018-05-04", v)
+
+	v, err = NullDate{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
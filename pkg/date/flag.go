@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 )
@@ -12,7 +11,7 @@ import (
 const dateFormat = "2006-1-2"
 
 type flag struct {
-	*time.Time
+	Date *Date
 }
 
 // Flag will create a Flag for use as a pflag.Value
@@ -23,10 +22,10 @@ func Flag() *flag {
 // String will return the date in yyyy-mm-dd format, or an empty string if one
 // has not been set.
 func (f flag) String() string {
-	if f.Time == nil {
+	if f.Date == nil {
 		return ""
 	}
-	return f.Time.Format(dateFormat)
+	return f.Date.String()
 }
 
 // Type returns the string that represents the type of flag.
@@ -46,14 +45,14 @@ func (f *flag) Set(value string) error {
 	}
 	val = strings.ToLower(value)
 
-	for _, parse := range []func(string) (time.Time, error){
+	for _, parse := range []func(string) (Date, error){
 		parseYesterday,
 		parseRelative,
-		format(dateFormat).parse,
+		Parse,
 	} {
 		d, err := parse(val)
 		if err == nil {
-			*f = flag{Time: &d}
+			*f = flag{Date: &d}
 			return nil
 		}
 	}
@@ -62,24 +61,19 @@ func (f *flag) Set(value string) error {
 	return fmt.Errorf("unsupported date value: %+v", value)
 }
 
-func parseYesterday(val string) (time.Time, error) {
+func parseYesterday(val string) (Date, error) {
 	for _, valid := range []string{"yesterday", "y"} {
 		if val == valid {
-			return time.Now().Add(-time.Hour * 24), nil
+			return Today().AddDays(-1), nil
 		}
 	}
-	return time.Time{}, errors.New("unsupported value")
+	return Date{}, errors.New("unsupported value")
 }
 
-func parseRelative(val string) (time.Time, error) {
+func parseRelative(val string) (Date, error) {
 	i, err := strconv.Atoi(val)
-	d := time.Now().Add(time.Hour * (24 * time.Duration(i)))
-	return d, errors.Wrap(err, "converting ascii to integer")
-}
-
-type format string
-
-func (fmt format) parse(val string) (time.Time, error) {
-	d, err := time.Parse(string(fmt), val)
-	return d, errors.Wrapf(err, "parsing into format:%s", fmt)
+	if err != nil {
+		return Date{}, errors.Wrap(err, "converting ascii to integer")
+	}
+	return Today().AddDays(i), nil
 }
@@ -37,7 +37,7 @@ func TestFlag_SetErrors(t *testing.T) {
 				t.Run(fmt.Sprintf("%d-%s", k, val), func(t *testing.T) {
 					f := &flag{}
 					err := f.Set(v)
-					assert.Nil(t, f.Time)
+					assert.Nil(t, f.Date)
 					assert.Error(t, err)
 				})
 			}
@@ -49,12 +49,12 @@ func TestFlag_SetExplicit(t *testing.T) {
 	for _, test := range []struct {
 		name string
 		vals []string
-		time.Time
+		Date
 	}{
 		{
 			name: "valid explicit",
 			vals: []string{"2018-03-02", "2018-3-2"},
-			Time: time.Date(2018, 03, 02, 0, 0, 0, 0, time.UTC),
+			Date: New(2018, 03, 02),
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -66,7 +66,7 @@ func TestFlag_SetExplicit(t *testing.T) {
 					err := f.Set(v)
 					assert.NoError(t, err)
 					assert.NotNil(t, f)
-					assert.Equal(t, test.Time, *f.Time)
+					assert.Equal(t, test.Date, *f.Date)
 				})
 			}
 		})
@@ -101,30 +101,14 @@ func TestFlag_SetRelative(t *testing.T) {
 				v := val
 				t.Run(fmt.Sprintf("%d-%s", k, val), func(t *testing.T) {
 					f := &flag{}
-					expected := time.Now().Add(time.Hour * 24 * time.Duration(test.relativeDays))
+					expected := Today().AddDays(test.relativeDays)
 					err := f.Set(v)
 					assert.NoError(t, err)
-					assert.NotNil(t, f)
-					diff := absDuration(f.Time.Sub(expected))
-					acceptableThreshold := time.Millisecond * 10
-					assert.Truef(t,
-						diff < acceptableThreshold,
-						"difference should be small but was %s. "+
-							"If the difference is still quite small, "+
-							"this could be because of a slow running test.",
-						diff)
+					if assert.NotNil(t, f.Date) {
+						assert.Equal(t, expected, *f.Date)
+					}
 				})
 			}
 		})
 	}
 }
-
-// absDuration will make any Duration positive.
-// BUG: If the duration given is equal to math.MinInt64, which is a large
-// negative number, the duration returned will be negative, still.
-func absDuration(a time.Duration) time.Duration {
-	if a >= 0 {
-		return a
-	}
-	return a * -1
-}
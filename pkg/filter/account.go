@@ -65,6 +65,26 @@ func ID(id uint) AccountCondition {
 	}
 }
 
+// IDs produces an AccountCondition that will identify a storage.Account if it
+// matches any of the given IDs
+func IDs(ids ...uint) AccountCondition {
+	var cs AccountConditions
+	for _, id := range ids {
+		cs = append(cs, ID(id))
+	}
+	return cs.Or
+}
+
+// Currencies produces an AccountCondition that will identify a storage.Account
+// if it has any of the given currency.Codes
+func Currencies(codes ...currency.Code) AccountCondition {
+	var cs AccountConditions
+	for _, c := range codes {
+		cs = append(cs, Currency(c))
+	}
+	return cs.Or
+}
+
 // AccountConditions is a set of AccountCondition
 type AccountConditions []AccountCondition
 
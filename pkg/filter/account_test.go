@@ -88,6 +88,23 @@ func TestCurrency(t *testing.T) {
 	}
 }
 
+func TestIDs(t *testing.T) {
+	a := storage.Account{ID: 111}
+	assert.False(t, filter.IDs()(a))
+	assert.False(t, filter.IDs(1, 2)(a))
+	assert.True(t, filter.IDs(1, 111)(a))
+}
+
+func TestCurrencies(t *testing.T) {
+	a := newOpenAccount(t, "test", "BUP", 0)
+	assert.False(t, filter.Currencies()(a))
+	assert.False(t, filter.Currencies(accountingtest.NewCurrencyCode(t, "AUP"))(a))
+	assert.True(t, filter.Currencies(
+		accountingtest.NewCurrencyCode(t, "AUP"),
+		accountingtest.NewCurrencyCode(t, "BUP"),
+	)(a))
+}
+
 func TestExisted(t *testing.T) {
 	for _, test := range []struct {
 		name string
@@ -0,0 +1,163 @@
+// Package importer reads balances from the statements that are exported by
+// banks so that they can be inserted into a storage.Storage.
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/pkg/errors"
+)
+
+// CSVMapping describes how the balances of a CSV statement are held.
+type CSVMapping struct {
+	// DateColumn and AmountColumn identify the columns holding the date and
+	// amount of each balance, either by the name of the column in the header
+	// row or by the zero-based index of the column.
+	DateColumn, AmountColumn string
+	// Header is true if the first row of the statement is a header row.
+	Header bool
+	// Delimiter is the rune that separates the fields of each row. The zero
+	// value is a comma.
+	Delimiter rune
+	// DateFormat is the layout, as used by time.Parse, of each date.
+	DateFormat string
+	// DecimalSeparator is the rune that separates the whole and fractional
+	// parts of each amount. The zero value is a full stop. Whichever of a
+	// comma or full stop is not the DecimalSeparator is treated as a
+	// thousands separator and ignored, as is any whitespace.
+	DecimalSeparator rune
+	// DecimalPlaces is the number of minor units in a major unit of the
+	// currency of the amounts, as a power of 10. Each amount is multiplied by
+	// 10^DecimalPlaces to give its amount in minor units, so a DecimalPlaces
+	// of 0 is used for amounts that are already held in minor units.
+	DecimalPlaces int
+}
+
+// CSV reads a CSV statement, returning a balance.Balance for each of its rows
+// using the given mapping.
+func CSV(r io.Reader, m CSVMapping) ([]balance.Balance, error) {
+	cr := csv.NewReader(r)
+	if m.Delimiter != 0 {
+		cr.Comma = m.Delimiter
+	}
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading csv")
+	}
+
+	var header []string
+	if m.Header {
+		if len(rows) == 0 {
+			return nil, errors.New("csv has no header row")
+		}
+		header, rows = rows[0], rows[1:]
+	}
+	dateIndex, err := columnIndex(header, m.DateColumn)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding date column")
+	}
+	amountIndex, err := columnIndex(header, m.AmountColumn)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding amount column")
+	}
+
+	bs := make([]balance.Balance, len(rows))
+	for i, row := range rows {
+		// rowNum is the line of the row in the statement, for error messages
+		rowNum := i + 1
+		if m.Header {
+			rowNum++
+		}
+		if dateIndex >= len(row) || amountIndex >= len(row) {
+			return nil, fmt.Errorf("row %d has %d columns", rowNum, len(row))
+		}
+		t, err := time.Parse(m.DateFormat, strings.TrimSpace(row[dateIndex]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing date of row %d", rowNum)
+		}
+		amount, err := ParseAmount(row[amountIndex], m.DecimalSeparator, m.DecimalPlaces)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing amount of row %d", rowNum)
+		}
+		bs[i] = balance.Balance{Date: date.FromTime(t).Time(), Amount: amount}
+	}
+	return bs, nil
+}
+
+// columnIndex returns the index of the column with the given name in the
+// header or, if the column is not found in the header, the column is parsed
+// as a zero-based index.
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.TrimSpace(name) == column {
+			return i, nil
+		}
+	}
+	i, err := strconv.Atoi(column)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("column %q is not in the header and is not a valid index", column)
+	}
+	return i, nil
+}
+
+// ParseAmount parses a decimal amount into an amount of minor units, where
+// decimalPlaces is the number of minor units in a major unit as a power of 10.
+// The zero value of decimalSeparator is a full stop. Whichever of a comma or
+// full stop is not the decimalSeparator is treated as a thousands separator
+// and ignored, as is any whitespace. An error is returned if the amount has
+// more decimal places than decimalPlaces.
+func ParseAmount(value string, decimalSeparator rune, decimalPlaces int) (int, error) {
+	if decimalSeparator == 0 {
+		decimalSeparator = '.'
+	}
+	if decimalSeparator != '.' && decimalSeparator != ',' {
+		return 0, fmt.Errorf("unsupported decimal separator %q", decimalSeparator)
+	}
+	if decimalPlaces < 0 {
+		return 0, fmt.Errorf("decimal places must not be negative but was %d", decimalPlaces)
+	}
+	thousandsSeparator := ','
+	if decimalSeparator == ',' {
+		thousandsSeparator = '.'
+	}
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case r == thousandsSeparator, r == ' ', r == '\t':
+			return -1
+		case r == decimalSeparator:
+			return '.'
+		}
+		return r
+	}, value)
+
+	whole, fraction := cleaned, ""
+	if i := strings.IndexRune(cleaned, '.'); i >= 0 {
+		whole, fraction = cleaned[:i], cleaned[i+1:]
+	}
+	if len(fraction) > decimalPlaces {
+		return 0, fmt.Errorf("amount %q has more than %d decimal places", value, decimalPlaces)
+	}
+	fraction += strings.Repeat("0", decimalPlaces-len(fraction))
+	for _, r := range fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+	}
+	if whole == "" || whole == "-" || whole == "+" {
+		whole += "0"
+	}
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || amount > math.MaxInt32 || amount < math.MinInt32 {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return int(amount), nil
+}
@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSV(t *testing.T) {
+	d := func(day int) time.Time {
+		return date.New(2018, time.May, day).Time()
+	}
+
+	t.Run("header with named columns", func(t *testing.T) {
+		bs, err := CSV(strings.NewReader("Description,Date,Balance\nfoo,04/05/2018,\"1,234.5\"\nbar,05/05/2018,-0.01\n"), CSVMapping{
+			DateColumn:    "Date",
+			AmountColumn:  "Balance",
+			Header:        true,
+			DateFormat:    "02/01/2006",
+			DecimalPlaces: 2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []balance.Balance{{Date: d(4), Amount: 123450}, {Date: d(5), Amount: -1}}, bs)
+	})
+
+	t.Run("no header with indexed columns", func(t *testing.T) {
+		bs, err := CSV(strings.NewReader("2018-05-04;1.234,56\n"), CSVMapping{
+			DateColumn:       "0",
+			AmountColumn:     "1",
+			Delimiter:        ';',
+			DateFormat:       "2006-01-02",
+			DecimalSeparator: ',',
+			DecimalPlaces:    2,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []balance.Balance{{Date: d(4), Amount: 123456}}, bs)
+	})
+
+	for _, test := range []struct {
+		name, csv string
+		CSVMapping
+	}{
+		{
+			name:       "unknown column",
+			csv:        "Date,Balance\n2018-05-04,1\n",
+			CSVMapping: CSVMapping{DateColumn: "Date", AmountColumn: "Amount", Header: true, DateFormat: "2006-01-02"},
+		},
+		{
+			name:       "index out of range",
+			csv:        "2018-05-04,1\n",
+			CSVMapping: CSVMapping{DateColumn: "0", AmountColumn: "2", DateFormat: "2006-01-02"},
+		},
+		{
+			name:       "invalid date",
+			csv:        "04/05/2018,1\n",
+			CSVMapping: CSVMapping{DateColumn: "0", AmountColumn: "1", DateFormat: "2006-01-02"},
+		},
+		{
+			name:       "invalid amount",
+			csv:        "2018-05-04,one\n",
+			CSVMapping: CSVMapping{DateColumn: "0", AmountColumn: "1", DateFormat: "2006-01-02"},
+		},
+		{
+			name:       "missing header",
+			csv:        "",
+			CSVMapping: CSVMapping{DateColumn: "0", AmountColumn: "1", Header: true, DateFormat: "2006-01-02"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := CSV(strings.NewReader(test.csv), test.CSVMapping)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for _, test := range []struct {
+		value     string
+		separator rune
+		places    int
+		expected  int
+	}{
+		{value: "12.34", places: 2, expected: 1234},
+		{value: "12.3", places: 2, expected: 1230},
+		{value: "12", places: 2, expected: 1200},
+		{value: "-0.5", places: 2, expected: -50},
+		{value: ".5", places: 2, expected: 50},
+		{value: " 1,000.00 ", places: 2, expected: 100000},
+		{value: "1.000,5", separator: ',', places: 2, expected: 100050},
+		{value: "1234", places: 0, expected: 1234},
+		{value: "+7.125", places: 3, expected: 7125},
+	} {
+		t.Run(test.value, func(t *testing.T) {
+			amount, err := ParseAmount(test.value, test.separator, test.places)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, amount)
+		})
+	}
+
+	for _, test := range []struct {
+		value     string
+		separator rune
+		places    int
+	}{
+		{value: "1.234", places: 2},
+		{value: "1.5", places: 0},
+		{value: "abc", places: 2},
+		{value: "1.a", places: 2},
+		{value: "1", separator: ';', places: 2},
+		{value: "1", places: -1},
+		{value: "99999999999", places: 2},
+	} {
+		t.Run("invalid "+test.value, func(t *testing.T) {
+			_, err := ParseAmount(test.value, test.separator, test.places)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package importer
+
+import (
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+)
+
+// NewBalances splits the imported Balances into those that should be inserted
+// and those that are duplicates. A Balance is a duplicate if a Balance with
+// the same date and amount is already stored or appears earlier in the
+// imported Balances.
+func NewBalances(existing storage.Balances, imported []balance.Balance) (inserts, duplicates []balance.Balance) {
+	type key struct {
+		date.Date
+		amount int
+	}
+	seen := make(map[key]bool)
+	for _, b := range existing {
+		seen[key{Date: date.FromTime(b.Date), amount: b.Amount}] = true
+	}
+	for _, b := range imported {
+		k := key{Date: date.FromTime(b.Date), amount: b.Amount}
+		if seen[k] {
+			duplicates = append(duplicates, b)
+			continue
+		}
+		seen[k] = true
+		inserts = append(inserts, b)
+	}
+	return inserts, duplicates
+}
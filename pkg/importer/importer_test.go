@@ -0,0 +1,28 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBalances(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	existing := storage.Balances{
+		{ID: 1, Balance: balance.Balance{Date: d.Time(), Amount: 10}},
+	}
+	imported := []balance.Balance{
+		{Date: d.Time(), Amount: 10},
+		{Date: d.Time(), Amount: 20},
+		{Date: d.AddDays(1).Time(), Amount: 10},
+		{Date: d.AddDays(1).Time(), Amount: 10},
+	}
+
+	inserts, duplicates := NewBalances(existing, imported)
+	assert.Equal(t, []balance.Balance{imported[1], imported[2]}, inserts)
+	assert.Equal(t, []balance.Balance{imported[0], imported[3]}, duplicates)
+}
@@ -0,0 +1,41 @@
+package importer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// AccountMapping maps the account keys of statements to the ids of the
+// Accounts that their balances are imported into.
+type AccountMapping map[string]uint
+
+// LoadAccountMapping reads an AccountMapping from the json file at the given
+// path, returning an empty AccountMapping if the file does not exist.
+func LoadAccountMapping(path string) (AccountMapping, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return make(AccountMapping), nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading account mapping file")
+	}
+	m := make(AccountMapping)
+	return m, errors.Wrap(json.Unmarshal(data, &m), "unmarshalling account mapping")
+}
+
+// Save writes the AccountMapping as json to the file at the given path,
+// creating the directory of the file if it does not exist.
+func (m AccountMapping) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling account mapping")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "creating account mapping directory")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, data, 0600), "writing account mapping file")
+}
@@ -0,0 +1,35 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountMapping(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mon-importer")
+	common.FatalIfError(t, err, "creating temp dir")
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+	path := filepath.Join(dir, "nested", "mapping.json")
+
+	m, err := LoadAccountMapping(path)
+	common.FatalIfError(t, err, "loading missing mapping")
+	assert.Empty(t, m)
+
+	m["123456/00012345"] = 4
+	common.FatalIfError(t, m.Save(path), "saving mapping")
+
+	loaded, err := LoadAccountMapping(path)
+	common.FatalIfError(t, err, "loading saved mapping")
+	assert.Equal(t, m, loaded)
+
+	common.FatalIfError(t, ioutil.WriteFile(path, []byte("not json"), 0600), "writing invalid mapping")
+	_, err = LoadAccountMapping(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/pkg/errors"
+)
+
+// OFXStatement is the ledger balance of a single account, as given by a
+// statement within an OFX file.
+type OFXStatement struct {
+	// BankID is the identifier of the bank of the account, which is empty for
+	// credit card statements.
+	BankID string
+	// AccountID is the identifier of the account at the bank
+	AccountID string
+	// Currency is the default currency of the statement
+	Currency string
+	// Balance is the ledger balance of the account
+	Balance balance.Balance
+}
+
+// AccountKey returns the key that identifies the account of the statement in
+// an AccountMapping.
+func (s OFXStatement) AccountKey() string {
+	if s.BankID == "" {
+		return s.AccountID
+	}
+	return s.BankID + "/" + s.AccountID
+}
+
+// OFX reads the bank and credit card statements of an OFX or QFX file, which
+// may be in either the SGML format of OFX 1.x or the XML format of OFX 2.x.
+// The amount of each ledger balance is parsed with ParseAmount using the
+// given number of decimal places. The date of each ledger balance is taken
+// as the date that is written in the file, ignoring any time or timezone.
+func OFX(r io.Reader, decimalPlaces int) ([]OFXStatement, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading ofx")
+	}
+	start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>"))
+	if start < 0 {
+		return nil, errors.New("no OFX element found")
+	}
+
+	var ss []OFXStatement
+	var current *ofxFields
+	// open holds the names of the aggregates that are currently open
+	var open []string
+	parent := func() string {
+		if len(open) == 0 {
+			return ""
+		}
+		return open[len(open)-1]
+	}
+	for _, token := range strings.Split(string(data[start:]), "<")[1:] {
+		end := strings.IndexByte(token, '>')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated tag %q", token)
+		}
+		name, value := strings.ToUpper(strings.TrimSpace(token[:end])), strings.TrimSpace(token[end+1:])
+
+		if strings.HasPrefix(name, "/") {
+			name = name[1:]
+			// closing tags of elements are optional in SGML, so only those
+			// that close an open aggregate are of interest
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					open = open[:i]
+					break
+				}
+			}
+			if (name == "STMTRS" || name == "CCSTMTRS") && current != nil {
+				s, err := current.statement(decimalPlaces)
+				if err != nil {
+					return nil, errors.Wrapf(err, "reading statement %d", len(ss)+1)
+				}
+				ss = append(ss, s)
+				current = nil
+			}
+			continue
+		}
+
+		if value == "" {
+			open = append(open, name)
+			if name == "STMTRS" || name == "CCSTMTRS" {
+				current = &ofxFields{}
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		switch p := parent(); {
+		case name == "CURDEF" && (p == "STMTRS" || p == "CCSTMTRS"):
+			current.currency = value
+		case name == "BANKID" && p == "BANKACCTFROM":
+			current.bankID = value
+		case name == "ACCTID" && (p == "BANKACCTFROM" || p == "CCACCTFROM"):
+			current.accountID = value
+		case name == "BALAMT" && p == "LEDGERBAL":
+			current.amount = value
+		case name == "DTASOF" && p == "LEDGERBAL":
+			current.date = value
+		}
+	}
+	if current != nil {
+		return nil, errors.New("statement is not closed")
+	}
+	if len(ss) == 0 {
+		return nil, errors.New("no bank or credit card statements found")
+	}
+	return ss, nil
+}
+
+// ofxFields holds the raw values of the fields of a statement as they are read
+type ofxFields struct {
+	bankID, accountID, currency, amount, date string
+}
+
+func (f ofxFields) statement(decimalPlaces int) (OFXStatement, error) {
+	if f.accountID == "" {
+		return OFXStatement{}, errors.New("statement has no account id")
+	}
+	if f.currency == "" {
+		return OFXStatement{}, errors.New("statement has no currency")
+	}
+	if f.amount == "" || f.date == "" {
+		return OFXStatement{}, errors.New("statement has no ledger balance")
+	}
+	separator := '.'
+	if strings.ContainsRune(f.amount, ',') && !strings.ContainsRune(f.amount, '.') {
+		separator = ','
+	}
+	amount, err := ParseAmount(f.amount, separator, decimalPlaces)
+	if err != nil {
+		return OFXStatement{}, errors.Wrap(err, "parsing ledger balance amount")
+	}
+	d, err := parseOFXDate(f.date)
+	if err != nil {
+		return OFXStatement{}, errors.Wrap(err, "parsing ledger balance date")
+	}
+	return OFXStatement{
+		BankID:    f.bankID,
+		AccountID: f.accountID,
+		Currency:  strings.ToUpper(f.currency),
+		Balance:   balance.Balance{Date: d.Time(), Amount: amount},
+	}, nil
+}
+
+// parseOFXDate parses the date of an OFX datetime, which is formatted as
+// YYYYMMDD followed by an optional time and timezone.
+func parseOFXDate(value string) (date.Date, error) {
+	if len(value) < 8 {
+		return date.Date{}, fmt.Errorf("invalid date %q", value)
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return date.Date{}, errors.Wrapf(err, "invalid date %q", value)
+	}
+	return date.FromTime(t), nil
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/stretchr/testify/assert"
+)
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20180506120000</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<TRNUID>1
+<STMTRS>
+<CURDEF>gbp
+<BANKACCTFROM>
+<BANKID>123456
+<ACCTID>00012345
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>XFER
+<TRNAMT>-10.00
+<BANKACCTTO><BANKID>999999<ACCTID>99999999<ACCTTYPE>SAVINGS</BANKACCTTO>
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>1234.5
+<DTASOF>20180505120000.000[+1:BST]
+</LEDGERBAL>
+<AVAILBAL><BALAMT>1000.00<DTASOF>20180505</AVAILBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <CCSTMTRS>
+        <CURDEF>EUR</CURDEF>
+        <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+        <LEDGERBAL><BALAMT>-50,25</BALAMT><DTASOF>20180504</DTASOF></LEDGERBAL>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>
+`
+
+func TestOFX(t *testing.T) {
+	t.Run("sgml bank statement", func(t *testing.T) {
+		ss, err := OFX(strings.NewReader(sgmlStatement), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []OFXStatement{{
+			BankID:    "123456",
+			AccountID: "00012345",
+			Currency:  "GBP",
+			Balance:   balance.Balance{Date: date.New(2018, time.May, 5).Time(), Amount: 123450},
+		}}, ss)
+		assert.Equal(t, "123456/00012345", ss[0].AccountKey())
+	})
+
+	t.Run("xml credit card statement", func(t *testing.T) {
+		ss, err := OFX(strings.NewReader(xmlStatement), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []OFXStatement{{
+			AccountID: "4111",
+			Currency:  "EUR",
+			Balance:   balance.Balance{Date: date.New(2018, time.May, 4).Time(), Amount: -5025},
+		}}, ss)
+		assert.Equal(t, "4111", ss[0].AccountKey())
+	})
+
+	for _, test := range []struct {
+		name, ofx string
+	}{
+		{name: "not ofx", ofx: "Date,Balance\n"},
+		{name: "no statements", ofx: "<OFX><SIGNONMSGSRSV1></SIGNONMSGSRSV1></OFX>"},
+		{name: "no ledger balance", ofx: "<OFX><STMTRS><CURDEF>GBP<BANKACCTFROM><ACCTID>1</BANKACCTFROM></STMTRS></OFX>"},
+		{name: "no currency", ofx: "<OFX><STMTRS><BANKACCTFROM><ACCTID>1</BANKACCTFROM><LEDGERBAL><BALAMT>1<DTASOF>20180504</LEDGERBAL></STMTRS></OFX>"},
+		{name: "invalid date", ofx: "<OFX><STMTRS><CURDEF>GBP<BANKACCTFROM><ACCTID>1</BANKACCTFROM><LEDGERBAL><BALAMT>1<DTASOF>2018</LEDGERBAL></STMTRS></OFX>"},
+		{name: "unclosed statement", ofx: "<OFX><STMTRS><CURDEF>GBP"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := OFX(strings.NewReader(test.ofx), 2)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package plaintext
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/glynternet/mon/pkg/amount"
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/pkg/errors"
+)
+
+// Beancount writes the Document as beancount directives, with amounts written
+// with the given number of decimal places.
+//
+// Each Account is opened on its opened date, restricted to its currency. A
+// Balance includes every Transaction on its date, whereas a beancount balance
+// directive applies at the start of its date, so each Balance is written as a
+// balance directive on the following date. For the same reason, a closed
+// Account is closed on the date after its closed date.
+func Beancount(w io.Writer, d backup.Document, decimalPlaces int) error {
+	j, err := newJournal(d, decimalPlaces)
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+	ew.printf("; exported from mon\n\n")
+	if j.unexplained {
+		ew.printf("%s open %s\n", j.firstDate(), UnexplainedAccount)
+	}
+	for _, a := range j.accounts {
+		ew.printf("%s open %s %s\n", a.opened, a.name, a.currency)
+	}
+
+	for _, e := range j.entries {
+		formatted := amount.Format(e.amount, j.decimalPlaces)
+		if e.kind == kindBalance {
+			ew.printf("\n%s balance %s %s %s\n", e.date.AddDays(1), e.to, formatted, e.currency)
+			continue
+		}
+		ew.printf("\n%s * %s\n", e.date, beancountString(e.description))
+		ew.printf("  %s  %s %s\n", e.to, formatted, e.currency)
+		ew.printf("  %s  %s %s\n", e.from, amount.Format(-e.amount, j.decimalPlaces), e.currency)
+	}
+
+	var closed bool
+	for _, a := range j.accounts {
+		if a.closed.Valid {
+			if !closed {
+				ew.printf("\n")
+				closed = true
+			}
+			ew.printf("%s close %s\n", a.closed.Date.AddDays(1), a.name)
+		}
+	}
+	return errors.Wrap(ew.err, "writing beancount")
+}
+
+func beancountString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
+
+// errWriter writes formatted strings to an io.Writer until the first error,
+// which it holds.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, a ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	_, ew.err = fmt.Fprintf(ew.w, format, a...)
+}
@@ -0,0 +1,194 @@
+// Package plaintext writes the Accounts, Balances and Transactions of a
+// backup.Document as the journals of plain text accounting tools.
+//
+// Each Account that has not been deleted becomes an account under Assets.
+// Each Balance becomes a balance assertion and any change in amount between
+// Balances that is not explained by Transactions is recorded as a transaction
+// against UnexplainedAccount, so that every assertion holds.
+package plaintext
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+)
+
+const (
+	// AssetsRoot is the root account that every Account is written under
+	AssetsRoot = "Assets"
+	// UnexplainedAccount is the account that records any change between
+	// Balances that is not explained by Transactions
+	UnexplainedAccount = "Equity:Unexplained"
+	// UnexplainedDescription is the description of the transactions that
+	// record unexplained changes
+	UnexplainedDescription = "unexplained change"
+)
+
+// journal holds the accounts and entries of a Document in the form that is
+// shared by each of the journal formats.
+type journal struct {
+	accounts      []journalAccount
+	entries       []entry
+	decimalPlaces int
+	unexplained   bool
+}
+
+type journalAccount struct {
+	name, currency string
+	opened         date.Date
+	closed         date.NullDate
+}
+
+type entryKind int
+
+// The kinds of entry, in the order that entries of the same date are written
+const (
+	kindTransaction entryKind = iota
+	kindUnexplained
+	kindBalance
+)
+
+// entry is a transaction between two accounts, or a balance assertion of a
+// single account if its kind is kindBalance. The amount of a transaction is
+// taken from the from account and added to the to account.
+type entry struct {
+	kind        entryKind
+	date        date.Date
+	description string
+	from, to    string
+	amount      int
+	currency    string
+}
+
+func newJournal(d backup.Document, decimalPlaces int) (*journal, error) {
+	if decimalPlaces < 0 {
+		return nil, fmt.Errorf("decimal places must not be negative but was %d", decimalPlaces)
+	}
+	j := &journal{decimalPlaces: decimalPlaces}
+
+	as := make([]backup.Account, 0, len(d.Accounts))
+	for _, a := range d.Accounts {
+		if !a.Account.Deleted() {
+			as = append(as, a)
+		}
+	}
+	sort.SliceStable(as, func(i, k int) bool {
+		return as[i].Account.ID < as[k].Account.ID
+	})
+
+	names := make(map[uint]string, len(as))
+	used := make(map[string]bool, len(as))
+	for _, a := range as {
+		name := AssetsRoot + ":" + accountComponent(a.Account.Account.Name())
+		if used[name] {
+			name += "-" + strconv.FormatUint(uint64(a.Account.ID), 10)
+		}
+		used[name] = true
+		names[a.Account.ID] = name
+		j.accounts = append(j.accounts, journalAccount{
+			name:     name,
+			currency: a.Account.Account.CurrencyCode().String(),
+			opened:   a.Account.Opened(),
+			closed:   a.Account.Closed(),
+		})
+	}
+
+	var ts storage.Transactions
+	for _, t := range d.Transactions {
+		from, fromOK := names[t.SourceID]
+		to, toOK := names[t.DestinationID]
+		if !fromOK || !toOK {
+			continue
+		}
+		ts = append(ts, t)
+		j.entries = append(j.entries, entry{
+			kind:        kindTransaction,
+			date:        t.Date,
+			description: t.Description,
+			from:        from,
+			to:          to,
+			amount:      t.Amount,
+			currency:    currencyOf(as, t.SourceID),
+		})
+	}
+
+	for i, a := range as {
+		name, currency := names[a.Account.ID], j.accounts[i].currency
+		previous, previousAmount := a.Account.Opened().AddDays(-1), 0
+		for _, b := range a.Balances {
+			d := date.FromTime(b.Date)
+			if u := b.Amount - previousAmount - ts.Delta(a.Account.ID, previous, d); u != 0 {
+				j.unexplained = true
+				j.entries = append(j.entries, entry{
+					kind:        kindUnexplained,
+					date:        d,
+					description: UnexplainedDescription,
+					from:        UnexplainedAccount,
+					to:          name,
+					amount:      u,
+					currency:    currency,
+				})
+			}
+			j.entries = append(j.entries, entry{
+				kind:     kindBalance,
+				date:     d,
+				to:       name,
+				amount:   b.Amount,
+				currency: currency,
+			})
+			previous, previousAmount = d, b.Amount
+		}
+	}
+
+	sort.SliceStable(j.entries, func(i, k int) bool {
+		ei, ek := j.entries[i], j.entries[k]
+		if !ei.date.Equal(ek.date) {
+			return ei.date.Before(ek.date)
+		}
+		return ei.kind < ek.kind
+	})
+	return j, nil
+}
+
+// firstDate returns the earliest date that an account is opened on
+func (j journal) firstDate() date.Date {
+	var first date.Date
+	for i, a := range j.accounts {
+		if i == 0 || a.opened.Before(first) {
+			first = a.opened
+		}
+	}
+	return first
+}
+
+func currencyOf(as []backup.Account, id uint) string {
+	for _, a := range as {
+		if a.Account.ID == id {
+			return a.Account.Account.CurrencyCode().String()
+		}
+	}
+	return ""
+}
+
+// accountComponent returns the name of an Account as a single component of an
+// account name, made of the letters and digits of the name with each word
+// capitalised, starting with a letter.
+func accountComponent(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	c := b.String()
+	if c == "" || !unicode.IsLetter(rune(c[0])) {
+		c = "Account" + c
+	}
+	return c
+}
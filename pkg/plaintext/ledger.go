@@ -0,0 +1,57 @@
+package plaintext
+
+import (
+	"io"
+	"strings"
+
+	"github.com/glynternet/mon/pkg/amount"
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/pkg/errors"
+)
+
+// Ledger writes the Document as a journal that can be read by both hledger
+// and ledger, with amounts written with the given number of decimal places.
+//
+// Each Account is declared with an account directive that notes its opened
+// and closed dates in a comment. Each Balance is written as a balance
+// assertion on its date, after every transaction of the date.
+func Ledger(w io.Writer, d backup.Document, decimalPlaces int) error {
+	j, err := newJournal(d, decimalPlaces)
+	if err != nil {
+		return err
+	}
+	ew := &errWriter{w: w}
+	ew.printf("; exported from mon\n\n")
+	if j.unexplained {
+		ew.printf("account %s\n", UnexplainedAccount)
+	}
+	for _, a := range j.accounts {
+		ew.printf("account %s  ; currency: %s, opened: %s", a.name, a.currency, a.opened)
+		if a.closed.Valid {
+			ew.printf(", closed: %s", a.closed.Date)
+		}
+		ew.printf("\n")
+	}
+
+	for _, e := range j.entries {
+		if e.kind == kindBalance {
+			ew.printf("\n%s * balance\n", e.date)
+			ew.printf("    %s  0 %s = %s %s\n", e.to, e.currency, amount.Format(e.amount, j.decimalPlaces), e.currency)
+			continue
+		}
+		ew.printf("\n%s *%s\n", e.date, ledgerDescription(e.description))
+		ew.printf("    %s  %s %s\n", e.to, amount.Format(e.amount, j.decimalPlaces), e.currency)
+		ew.printf("    %s  %s %s\n", e.from, amount.Format(-e.amount, j.decimalPlaces), e.currency)
+	}
+	return errors.Wrap(ew.err, "writing ledger journal")
+}
+
+// ledgerDescription returns the description as it is written after the
+// status of a transaction, including its leading space.
+func ledgerDescription(s string) string {
+	s = strings.TrimSpace(strings.Replace(s, "\n", " ", -1))
+	if s == "" {
+		return ""
+	}
+	return " " + s
+}
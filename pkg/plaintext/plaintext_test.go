@@ -0,0 +1,133 @@
+package plaintext
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDocument(t *testing.T) backup.Document {
+	d := date.New(2018, time.May, 4)
+	newAccount := func(id uint, name string, os ...account.Option) backup.Account {
+		return backup.Account{Account: storage.Account{
+			ID:      id,
+			Account: *accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), d.Time(), os...),
+		}}
+	}
+	newBalances := func(amounts ...int) storage.Balances {
+		var bs storage.Balances
+		for i, amount := range amounts {
+			bs = append(bs, storage.Balance{Balance: balance.Balance{Date: d.AddDays(i).Time(), Amount: amount}})
+		}
+		return bs
+	}
+
+	current := newAccount(1, "current account")
+	current.Balances = newBalances(10000, 9000)
+	savings := newAccount(2, "Savings", account.CloseTime(d.AddDays(1).Time()))
+	savings.Balances = newBalances(0, 1050)
+	deleted := newAccount(3, "old")
+	deleted.Account = storage.Account{ID: 3, Account: deleted.Account.Account}
+	common.FatalIfError(t, storage.DeletedAt(d.Time())(&deleted.Account), "deleting account")
+	deleted.Balances = newBalances(5)
+
+	return backup.Document{
+		Version:  backup.Version,
+		Accounts: []backup.Account{savings, current, deleted},
+		Transactions: storage.Transactions{
+			{ID: 1, Date: d.AddDays(1), Amount: 1000, Description: `"rainy" day`, SourceID: 1, DestinationID: 2},
+			{ID: 2, Date: d.AddDays(1), Amount: 5, SourceID: 3, DestinationID: 1},
+		},
+	}
+}
+
+func TestBeancount(t *testing.T) {
+	var buf bytes.Buffer
+	common.FatalIfError(t, Beancount(&buf, newDocument(t), 2), "writing beancount")
+	assert.Equal(t, `; exported from mon
+
+2018-05-04 open Equity:Unexplained
+2018-05-04 open Assets:CurrentAccount GBP
+2018-05-04 open Assets:Savings GBP
+
+2018-05-04 * "unexplained change"
+  Assets:CurrentAccount  100.00 GBP
+  Equity:Unexplained  -100.00 GBP
+
+2018-05-05 balance Assets:CurrentAccount 100.00 GBP
+
+2018-05-05 balance Assets:Savings 0.00 GBP
+
+2018-05-05 * "\"rainy\" day"
+  Assets:Savings  10.00 GBP
+  Assets:CurrentAccount  -10.00 GBP
+
+2018-05-05 * "unexplained change"
+  Assets:Savings  0.50 GBP
+  Equity:Unexplained  -0.50 GBP
+
+2018-05-06 balance Assets:CurrentAccount 90.00 GBP
+
+2018-05-06 balance Assets:Savings 10.50 GBP
+
+2018-05-06 close Assets:Savings
+`, buf.String())
+
+	assert.Error(t, Beancount(&buf, newDocument(t), -1))
+}
+
+func TestLedger(t *testing.T) {
+	var buf bytes.Buffer
+	common.FatalIfError(t, Ledger(&buf, newDocument(t), 2), "writing ledger")
+	assert.Equal(t, `; exported from mon
+
+account Equity:Unexplained
+account Assets:CurrentAccount  ; currency: GBP, opened: 2018-05-04
+account Assets:Savings  ; currency: GBP, opened: 2018-05-04, closed: 2018-05-05
+
+2018-05-04 * unexplained change
+    Assets:CurrentAccount  100.00 GBP
+    Equity:Unexplained  -100.00 GBP
+
+2018-05-04 * balance
+    Assets:CurrentAccount  0 GBP = 100.00 GBP
+
+2018-05-04 * balance
+    Assets:Savings  0 GBP = 0.00 GBP
+
+2018-05-05 * "rainy" day
+    Assets:Savings  10.00 GBP
+    Assets:CurrentAccount  -10.00 GBP
+
+2018-05-05 * unexplained change
+    Assets:Savings  0.50 GBP
+    Equity:Unexplained  -0.50 GBP
+
+2018-05-05 * balance
+    Assets:CurrentAccount  0 GBP = 90.00 GBP
+
+2018-05-05 * balance
+    Assets:Savings  0 GBP = 10.50 GBP
+`, buf.String())
+}
+
+func TestAccountComponent(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Current":           "Current",
+		"current account":   "CurrentAccount",
+		"joint-account (2)": "JointAccount2",
+		"2018 isa":          "Account2018Isa",
+		"£££":               "Account",
+	} {
+		assert.Equal(t, expected, accountComponent(name), name)
+	}
+}
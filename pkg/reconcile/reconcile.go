@@ -0,0 +1,128 @@
+// Package reconcile compares the recorded Balances of an Account with the
+// Transactions that should explain the changes between them.
+package reconcile
+
+import (
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+)
+
+// AdjustmentDescription is the description given to the Transactions that are
+// generated by Adjustments.
+const AdjustmentDescription = "reconciliation adjustment"
+
+// Interval is the period between two consecutive Balances of an Account. A
+// Balance is taken to include every Transaction on the date of the Balance,
+// so the Transactions of an Interval are those dated after the From Balance,
+// up to and including the date of the To Balance.
+type Interval struct {
+	From, To storage.Balance
+	// Recorded is the change in amount between the From and To Balances
+	Recorded int
+	// Expected is the change in amount that is explained by the Transactions
+	// of the Interval
+	Expected int
+}
+
+// FromDate returns the date of the From Balance of the Interval
+func (i Interval) FromDate() date.Date {
+	return date.FromTime(i.From.Date)
+}
+
+// ToDate returns the date of the To Balance of the Interval
+func (i Interval) ToDate() date.Date {
+	return date.FromTime(i.To.Date)
+}
+
+// Unexplained returns the amount of the recorded change that is not explained
+// by the Transactions of the Interval.
+func (i Interval) Unexplained() int {
+	return i.Recorded - i.Expected
+}
+
+// Reconciled returns true if the Transactions of the Interval explain the
+// whole of its recorded change.
+func (i Interval) Reconciled() bool {
+	return i.Unexplained() == 0
+}
+
+// Contains returns true if a Transaction on the given date would fall within
+// the Interval.
+func (i Interval) Contains(d date.Date) bool {
+	return d.After(i.FromDate()) && !d.After(i.ToDate())
+}
+
+// Account walks the Balances of the Account with the given id in order,
+// returning an Interval for each consecutive pair of Balances. The Balances
+// must be in chronological order, as they are when selected from a
+// storage.Storage, and the Transactions may be any that include those of the
+// Account.
+func Account(accountID uint, bs storage.Balances, ts storage.Transactions) []Interval {
+	if len(bs) < 2 {
+		return nil
+	}
+	is := make([]Interval, len(bs)-1)
+	for n := range is {
+		i := Interval{From: bs[n], To: bs[n+1]}
+		i.Recorded = i.To.Amount - i.From.Amount
+		i.Expected = ts.Delta(accountID, i.FromDate(), i.ToDate())
+		is[n] = i
+	}
+	return is
+}
+
+// Unreconciled returns the Intervals that are not reconciled
+func Unreconciled(is []Interval) []Interval {
+	var us []Interval
+	for _, i := range is {
+		if !i.Reconciled() {
+			us = append(us, i)
+		}
+	}
+	return us
+}
+
+// Adjustments returns a Transaction for each unreconciled Interval of the
+// Account with the given id that, once stored, would explain the unexplained
+// amount of the Interval. Each Transaction is between the Account and the
+// adjustment Account, dated on the date of the To Balance of its Interval.
+// The Intervals must be every Interval of the Account, in order, as returned
+// by Account.
+//
+// An Interval whose From and To Balances share a date can contain no
+// Transactions, because a Transaction on that date falls within the Interval
+// before it. The change of such an Interval is folded into the adjustment of
+// the Interval before it. If there is no Interval before it, the change cannot
+// be adjusted and the Interval is returned as unadjusted instead.
+func Adjustments(accountID, adjustmentAccountID uint, is []Interval) (storage.Transactions, []Interval) {
+	var merged, unadjusted []Interval
+	for _, i := range is {
+		if i.ToDate().After(i.FromDate()) {
+			merged = append(merged, i)
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].ToDate().Equal(i.FromDate()) {
+			merged[n-1].Recorded += i.Unexplained()
+			continue
+		}
+		if !i.Reconciled() {
+			unadjusted = append(unadjusted, i)
+		}
+	}
+	var ts storage.Transactions
+	for _, i := range Unreconciled(merged) {
+		u := i.Unexplained()
+		t := storage.Transaction{
+			Date:          i.ToDate(),
+			Description:   AdjustmentDescription,
+			SourceID:      adjustmentAccountID,
+			DestinationID: accountID,
+			Amount:        u,
+		}
+		if u < 0 {
+			t.SourceID, t.DestinationID, t.Amount = accountID, adjustmentAccountID, -u
+		}
+		ts = append(ts, t)
+	}
+	return ts, unadjusted
+}
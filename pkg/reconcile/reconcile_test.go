@@ -0,0 +1,176 @@
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccount(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	newBalance := func(id uint, days, amount int) storage.Balance {
+		return storage.Balance{
+			ID:      id,
+			Balance: balance.Balance{Date: d.AddDays(days).Time(), Amount: amount},
+		}
+	}
+	bs := storage.Balances{
+		newBalance(1, 0, 100),
+		newBalance(2, 10, 70),
+		newBalance(3, 20, 90),
+	}
+
+	t.Run("fewer than two balances", func(t *testing.T) {
+		assert.Nil(t, Account(1, nil, nil))
+		assert.Nil(t, Account(1, bs[:1], nil))
+	})
+
+	t.Run("reconciled and unreconciled intervals", func(t *testing.T) {
+		ts := storage.Transactions{
+			// on the date of the first balance, so included in it
+			{Date: d, Amount: 1000, SourceID: 1, DestinationID: 2},
+			{Date: d.AddDays(5), Amount: 30, SourceID: 1, DestinationID: 2},
+			{Date: d.AddDays(20), Amount: 15, SourceID: 2, DestinationID: 1},
+			// another account
+			{Date: d.AddDays(15), Amount: 500, SourceID: 2, DestinationID: 3},
+		}
+		is := Account(1, bs, ts)
+		if !assert.Len(t, is, 2) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, bs[0], is[0].From)
+		assert.Equal(t, bs[1], is[0].To)
+		assert.Equal(t, -30, is[0].Recorded)
+		assert.Equal(t, -30, is[0].Expected)
+		assert.True(t, is[0].Reconciled())
+
+		assert.Equal(t, 20, is[1].Recorded)
+		assert.Equal(t, 15, is[1].Expected)
+		assert.Equal(t, 5, is[1].Unexplained())
+		assert.False(t, is[1].Reconciled())
+	})
+}
+
+func TestInterval_Contains(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	i := Interval{
+		From: storage.Balance{Balance: balance.Balance{Date: d.Time()}},
+		To:   storage.Balance{Balance: balance.Balance{Date: d.AddDays(2).Time()}},
+	}
+	assert.False(t, i.Contains(d))
+	assert.True(t, i.Contains(d.AddDays(1)))
+	assert.True(t, i.Contains(d.AddDays(2)))
+	assert.False(t, i.Contains(d.AddDays(3)))
+}
+
+func TestAdjustments(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	newInterval := func(days, recorded, expected int) Interval {
+		return Interval{
+			To:       storage.Balance{Balance: balance.Balance{Date: d.AddDays(days).Time()}},
+			Recorded: recorded,
+			Expected: expected,
+		}
+	}
+	is := []Interval{
+		newInterval(1, 10, 10),
+		newInterval(2, 10, 4),
+		newInterval(3, -10, -4),
+	}
+	ts, unadjusted := Adjustments(1, 9, is)
+	assert.Equal(t, storage.Transactions{
+		{Date: d.AddDays(2), Amount: 6, Description: AdjustmentDescription, SourceID: 9, DestinationID: 1},
+		{Date: d.AddDays(3), Amount: 6, Description: AdjustmentDescription, SourceID: 1, DestinationID: 9},
+	}, ts)
+	assert.Nil(t, unadjusted)
+	ts, _ = Adjustments(1, 9, is[:1])
+	assert.Nil(t, ts)
+	assert.Equal(t, is[1:], Unreconciled(is))
+}
+
+func TestAdjustments_sameDate(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	newBalance := func(id uint, days, amount int) storage.Balance {
+		return storage.Balance{
+			ID:      id,
+			Balance: balance.Balance{Date: d.AddDays(days).Time(), Amount: amount},
+		}
+	}
+
+	t.Run("folded into previous interval", func(t *testing.T) {
+		bs := storage.Balances{
+			newBalance(1, 0, 100),
+			newBalance(2, 10, 70),
+			newBalance(3, 10, 80),
+			newBalance(4, 20, 80),
+		}
+		ts, unadjusted := Adjustments(1, 9, Account(1, bs, nil))
+		assert.Equal(t, storage.Transactions{
+			{Date: d.AddDays(10), Amount: 20, Description: AdjustmentDescription, SourceID: 1, DestinationID: 9},
+		}, ts)
+		assert.Nil(t, unadjusted)
+	})
+
+	t.Run("after a reconciled interval", func(t *testing.T) {
+		bs := storage.Balances{
+			newBalance(1, 0, 100),
+			newBalance(2, 10, 70),
+			newBalance(3, 20, 80),
+			newBalance(4, 20, 90),
+		}
+		ts := storage.Transactions{
+			{Date: d.AddDays(15), Amount: 10, SourceID: 2, DestinationID: 1},
+		}
+		is := Account(1, bs, ts)
+		if !assert.Len(t, is, 3) {
+			t.FailNow()
+		}
+		assert.False(t, is[0].Reconciled())
+		assert.True(t, is[1].Reconciled())
+		assert.False(t, is[2].Reconciled())
+
+		adjustments, unadjusted := Adjustments(1, 9, is)
+		assert.Equal(t, storage.Transactions{
+			{Date: d.AddDays(10), Amount: 30, Description: AdjustmentDescription, SourceID: 1, DestinationID: 9},
+			{Date: d.AddDays(20), Amount: 10, Description: AdjustmentDescription, SourceID: 9, DestinationID: 1},
+		}, adjustments)
+		assert.Nil(t, unadjusted)
+
+		// once stored, the adjustments leave nothing more to adjust
+		adjustments, unadjusted = Adjustments(1, 9, Account(1, bs, append(ts, adjustments...)))
+		assert.Nil(t, adjustments)
+		assert.Nil(t, unadjusted)
+	})
+
+	t.Run("no previous interval", func(t *testing.T) {
+		bs := storage.Balances{
+			newBalance(1, 0, 100),
+			newBalance(2, 0, 70),
+			newBalance(3, 10, 70),
+		}
+		is := Account(1, bs, nil)
+		ts, unadjusted := Adjustments(1, 9, is)
+		assert.Nil(t, ts)
+		assert.Equal(t, is[:1], unadjusted)
+	})
+
+	t.Run("only unreconciled intervals", func(t *testing.T) {
+		bs := storage.Balances{
+			newBalance(1, 0, 100),
+			newBalance(2, 10, 70),
+			newBalance(3, 20, 70),
+			newBalance(4, 20, 90),
+		}
+		is := Unreconciled(Account(1, bs, nil))
+		ts, unadjusted := Adjustments(1, 9, is)
+		assert.Equal(t, storage.Transactions{
+			{Date: d.AddDays(10), Amount: 30, Description: AdjustmentDescription, SourceID: 1, DestinationID: 9},
+		}, ts)
+		assert.Equal(t, is[1:], unadjusted, "a change should not be folded into an interval that does not come directly before it")
+	})
+}
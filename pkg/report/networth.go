@@ -0,0 +1,139 @@
+// Package report generates reports over the Accounts and Balances held in a
+// storage.Storage.
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+)
+
+// Interval is the period between consecutive dates of a report
+type Interval string
+
+// The Intervals that a report can be generated with
+const (
+	Daily   Interval = "daily"
+	Weekly  Interval = "weekly"
+	Monthly Interval = "monthly"
+)
+
+// MaxDates is the maximum number of dates that a report can be generated for
+const MaxDates = 10000
+
+// ParseInterval returns the Interval with the given name.
+func ParseInterval(name string) (Interval, error) {
+	switch i := Interval(name); i {
+	case Daily, Weekly, Monthly:
+		return i, nil
+	}
+	return "", fmt.Errorf("unsupported interval %q, must be one of %s, %s or %s", name, Daily, Weekly, Monthly)
+}
+
+// Dates returns the dates from the from date up to and including the to date,
+// separated by the Interval. Monthly dates fall on the same day of the month
+// as the from date or, for months that are too short, on the last day of the
+// month, so a from date at the end of a month gives the end of every month.
+func Dates(from, to date.Date, i Interval) ([]date.Date, error) {
+	if from.After(to) {
+		return nil, fmt.Errorf("from date %s is after to date %s", from, to)
+	}
+	next, err := stepper(from, i)
+	if err != nil {
+		return nil, err
+	}
+	var ds []date.Date
+	for n := 0; ; n++ {
+		d := next(n)
+		if d.After(to) {
+			return ds, nil
+		}
+		if len(ds) == MaxDates {
+			return nil, fmt.Errorf("report would contain more than the maximum of %d dates", MaxDates)
+		}
+		ds = append(ds, d)
+	}
+}
+
+// stepper returns a function that returns the nth date after the from date
+// for the given Interval.
+func stepper(from date.Date, i Interval) (func(n int) date.Date, error) {
+	switch i {
+	case Daily:
+		return func(n int) date.Date { return from.AddDays(n) }, nil
+	case Weekly:
+		return func(n int) date.Date { return from.AddDays(7 * n) }, nil
+	case Monthly:
+		return func(n int) date.Date { return addMonths(from, n) }, nil
+	}
+	return nil, fmt.Errorf("unsupported interval %q", i)
+}
+
+// addMonths returns the date that is n months after d, on the last day of the
+// month if the day of d does not exist in that month.
+func addMonths(d date.Date, n int) date.Date {
+	y, m, day := d.Time().Date()
+	first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return date.New(first.Year(), first.Month(), day)
+}
+
+// AccountBalances holds an Account along with all of its Balances
+type AccountBalances struct {
+	Account  storage.Account
+	Balances storage.Balances
+}
+
+// Point is the total amount held in a currency on a date
+type Point struct {
+	Date   date.Date
+	Amount int
+}
+
+// Series holds a Point for each date of a report for a single currency
+type Series struct {
+	Currency string
+	Points   []Point
+}
+
+// NetWorth returns a Series for each currency held by the Accounts, sorted by
+// currency, with a Point for each of the given dates. The amount of a Point is
+// the sum of the Balances of the Accounts of the currency at the date, as
+// given by balance.Balances.AtTime. An Account with no Balance at or before a
+// date adds nothing to the Point for that date.
+func NetWorth(abs []AccountBalances, ds []date.Date) []Series {
+	byCurrency := make(map[string][]Point)
+	for _, ab := range abs {
+		c := ab.Account.Account.CurrencyCode().String()
+		ps, ok := byCurrency[c]
+		if !ok {
+			ps = make([]Point, len(ds))
+			for i, d := range ds {
+				ps[i].Date = d
+			}
+			byCurrency[c] = ps
+		}
+		bs := ab.Balances.InnerBalances()
+		for i, d := range ds {
+			b, err := bs.AtTime(d.Time())
+			if err != nil {
+				continue
+			}
+			ps[i].Amount += b.Amount
+		}
+	}
+
+	ss := make([]Series, 0, len(byCurrency))
+	for c, ps := range byCurrency {
+		ss = append(ss, Series{Currency: c, Points: ps})
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].Currency < ss[j].Currency
+	})
+	return ss
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	for _, name := range []string{"daily", "weekly", "monthly"} {
+		i, err := ParseInterval(name)
+		assert.NoError(t, err)
+		assert.Equal(t, Interval(name), i)
+	}
+	_, err := ParseInterval("yearly")
+	assert.Error(t, err)
+}
+
+func TestDates(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		from, to date.Date
+		Interval
+		expected []date.Date
+	}{
+		{
+			name:     "daily",
+			from:     date.New(2018, time.May, 4),
+			to:       date.New(2018, time.May, 6),
+			Interval: Daily,
+			expected: []date.Date{date.New(2018, time.May, 4), date.New(2018, time.May, 5), date.New(2018, time.May, 6)},
+		},
+		{
+			name:     "weekly, not aligned with to date",
+			from:     date.New(2018, time.May, 4),
+			to:       date.New(2018, time.May, 20),
+			Interval: Weekly,
+			expected: []date.Date{date.New(2018, time.May, 4), date.New(2018, time.May, 11), date.New(2018, time.May, 18)},
+		},
+		{
+			name:     "monthly from end of month",
+			from:     date.New(2018, time.January, 31),
+			to:       date.New(2018, time.May, 31),
+			Interval: Monthly,
+			expected: []date.Date{
+				date.New(2018, time.January, 31),
+				date.New(2018, time.February, 28),
+				date.New(2018, time.March, 31),
+				date.New(2018, time.April, 30),
+				date.New(2018, time.May, 31),
+			},
+		},
+		{
+			name:     "single date",
+			from:     date.New(2018, time.May, 4),
+			to:       date.New(2018, time.May, 4),
+			Interval: Monthly,
+			expected: []date.Date{date.New(2018, time.May, 4)},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ds, err := Dates(test.from, test.to, test.Interval)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ds)
+		})
+	}
+
+	t.Run("from after to", func(t *testing.T) {
+		_, err := Dates(date.New(2018, time.May, 5), date.New(2018, time.May, 4), Daily)
+		assert.Error(t, err)
+	})
+
+	t.Run("too many dates", func(t *testing.T) {
+		from := date.New(2018, time.May, 4)
+		_, err := Dates(from, from.AddDays(MaxDates), Daily)
+		assert.Error(t, err)
+		ds, err := Dates(from, from.AddDays(MaxDates-1), Daily)
+		assert.NoError(t, err)
+		assert.Len(t, ds, MaxDates)
+	})
+
+	t.Run("unsupported interval", func(t *testing.T) {
+		_, err := Dates(date.New(2018, time.May, 4), date.New(2018, time.May, 5), Interval("yearly"))
+		assert.Error(t, err)
+	})
+}
+
+func TestNetWorth(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	newAccountBalances := func(cc string, amounts map[int]int) AccountBalances {
+		ab := AccountBalances{
+			Account: storage.Account{
+				Account: *accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, cc), d.Time()),
+			},
+		}
+		for days := 0; days < 10; days++ {
+			if amount, ok := amounts[days]; ok {
+				ab.Balances = append(ab.Balances, storage.Balance{
+					Balance: balance.Balance{Date: d.AddDays(days).Time(), Amount: amount},
+				})
+			}
+		}
+		return ab
+	}
+	abs := []AccountBalances{
+		newAccountBalances("GBP", map[int]int{0: 10, 2: 20}),
+		newAccountBalances("GBP", map[int]int{1: 100}),
+		newAccountBalances("EUR", map[int]int{2: 5}),
+	}
+	ds := []date.Date{d, d.AddDays(1), d.AddDays(2)}
+
+	assert.Equal(t, []Series{
+		{Currency: "EUR", Points: []Point{{Date: ds[0]}, {Date: ds[1]}, {Date: ds[2], Amount: 5}}},
+		{Currency: "GBP", Points: []Point{{Date: ds[0], Amount: 10}, {Date: ds[1], Amount: 110}, {Date: ds[2], Amount: 120}}},
+	}, NetWorth(abs, ds))
+	assert.Empty(t, NetWorth(nil, ds))
+}
@@ -9,8 +9,13 @@ import (
 	"github.com/glynternet/go-accounting/account"
 	"github.com/glynternet/go-money/currency"
 	gtime "github.com/glynternet/go-time"
+	"github.com/glynternet/mon/pkg/date"
 )
 
+// ErrNoAccount is the cause of the error returned by a Storage when it holds
+// no Account with an id, or when the Account has been deleted.
+var ErrNoAccount = errors.New("no such account")
+
 // Account holds logic for an Account item that is held within a Storage.
 type Account struct {
 	ID        uint
@@ -26,6 +31,46 @@ func DeletedAt(t time.Time) func(*Account) error {
 	}
 }
 
+// Deleted returns true if the Account has been deleted.
+func (a Account) Deleted() bool {
+	return a.deletedAt.Valid
+}
+
+// DeletedTime returns the time that the Account was deleted at and true if the
+// Account has been deleted, or false if it has not.
+func (a Account) DeletedTime() (time.Time, bool) {
+	return a.deletedAt.Time, a.deletedAt.Valid
+}
+
+// Opened returns the Date that the Account was opened on.
+func (a Account) Opened() date.Date {
+	return date.FromTime(a.Account.Opened())
+}
+
+// Closed returns the Date that the Account was closed on, which is only
+// Valid if the Account has been closed.
+func (a Account) Closed() date.NullDate {
+	return nullDate(a.Account.Closed())
+}
+
+// DateAccount returns a copy of an account.Account with its opened and closed
+// times truncated to the calendar dates that they fall on, which is how
+// Accounts are held within a Storage.
+func DateAccount(a account.Account) (*account.Account, error) {
+	var o account.Option
+	if c := a.Closed(); c.Valid {
+		o = account.CloseTime(date.FromTime(c.Time).Time())
+	}
+	return account.New(a.Name(), a.CurrencyCode(), date.FromTime(a.Opened()).Time(), o)
+}
+
+func nullDate(t gtime.NullTime) date.NullDate {
+	if !t.Valid {
+		return date.NullDate{}
+	}
+	return date.NullDate{Valid: true, Date: date.FromTime(t.Time)}
+}
+
 // Accounts holds multiple Account items.
 type Accounts []Account
 
@@ -43,19 +88,30 @@ func (a Account) Equal(b Account) (bool, error) {
 	return true, nil
 }
 
+// innerAccountJSON is the json representation of the account.Account of an
+// Account, with its opened and closed times held as dates.
+type innerAccountJSON struct {
+	Name     string
+	Opened   date.Date
+	Closed   date.NullDate
+	Currency string
+}
+
 // MarshalJSON marshals an Account into a json blob, returning the blob with
 // any errors that occur during the marshalling.
 func (a Account) MarshalJSON() ([]byte, error) {
-	_, err := json.Marshal(a.Account)
-	if err != nil {
-		return nil, fmt.Errorf("unable to marshal inner account to json: %v", err)
-	}
-	type Alias Account
 	return json.Marshal(&struct {
-		*Alias
+		ID        uint
+		Account   innerAccountJSON
 		DeletedAt gtime.NullTime
 	}{
-		Alias:     (*Alias)(&a),
+		ID: a.ID,
+		Account: innerAccountJSON{
+			Name:     a.Account.Name(),
+			Opened:   a.Opened(),
+			Closed:   a.Closed(),
+			Currency: a.Account.CurrencyCode().String(),
+		},
 		DeletedAt: a.deletedAt,
 	})
 }
@@ -64,13 +120,8 @@ func (a Account) MarshalJSON() ([]byte, error) {
 // returning any errors that occur during the unmarshalling.
 func (a *Account) UnmarshalJSON(data []byte) (err error) {
 	aux := &struct {
-		ID      uint
-		Account struct {
-			Name     string
-			Opened   time.Time
-			Closed   gtime.NullTime
-			Currency string
-		}
+		ID        uint
+		Account   innerAccountJSON
 		DeletedAt gtime.NullTime
 	}{}
 	err = json.Unmarshal(data, &aux)
@@ -88,9 +139,9 @@ func (a *Account) UnmarshalJSON(data []byte) (err error) {
 	}
 	var o account.Option
 	if aux.Account.Closed.Valid {
-		o = account.CloseTime(aux.Account.Closed.Time)
+		o = account.CloseTime(aux.Account.Closed.Date.Time())
 	}
-	inner, err := account.New(aux.Account.Name, *c, aux.Account.Opened, o)
+	inner, err := account.New(aux.Account.Name, *c, aux.Account.Opened.Time(), o)
 	if err != nil {
 		return fmt.Errorf("error creating inner account: %v", err)
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/glynternet/go-money/common"
 	"github.com/glynternet/go-money/currency"
 	gtime "github.com/glynternet/go-time"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,6 +93,48 @@ func TestDeletedAt(t *testing.T) {
 	assert.Equal(t, gtime.NullTime{Valid: true, Time: time}, a.deletedAt)
 }
 
+func TestAccount_Deleted(t *testing.T) {
+	var a Account
+	assert.False(t, a.Deleted())
+	err := DeletedAt(time.Now())(&a)
+	assert.Nil(t, err)
+	assert.True(t, a.Deleted())
+}
+
+func TestDateAccount(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 13, 45, 0, 0, time.UTC)
+	a := accountingtest.NewAccount(
+		t,
+		"A",
+		accountingtest.NewCurrencyCode(t, "GBP"),
+		opened,
+		account.CloseTime(opened.Add(time.Hour)),
+	)
+	dated, err := DateAccount(*a)
+	common.FatalIfError(t, err, "dating account")
+	midnight := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, midnight, dated.Opened())
+	assert.True(t, dated.Closed().EqualTime(midnight))
+	assert.Equal(t, a.Name(), dated.Name())
+	assert.Equal(t, a.CurrencyCode(), dated.CurrencyCode())
+
+	sa := Account{Account: *dated}
+	assert.Equal(t, date.New(2018, time.May, 4), sa.Opened())
+	assert.Equal(t, date.NullDate{Valid: true, Date: date.New(2018, time.May, 4)}, sa.Closed())
+}
+
+func TestAccount_JSONDates(t *testing.T) {
+	inner := accountingtest.NewAccount(
+		t,
+		"A",
+		accountingtest.NewCurrencyCode(t, "GBP"),
+		time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC),
+	)
+	bs, err := json.Marshal(Account{ID: 1, Account: *inner})
+	common.FatalIfError(t, err, "marshalling json")
+	assert.Contains(t, string(bs), `"Opened":"2018-05-04","Closed":null`)
+}
+
 func TestAccount_JSONLoop(t *testing.T) {
 	c, err := currency.NewCode("NEO")
 	common.FatalIfErrorf(t, err, "creating currency code")
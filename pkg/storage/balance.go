@@ -1,7 +1,13 @@
 package storage
 
 import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
 	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/pkg/errors"
 )
 
 // Balance holds logic for an Account item that is held within a go-money database.
@@ -18,6 +24,47 @@ func (b Balance) Equal(ob Balance) bool {
 	return true
 }
 
+// DateBalance returns a copy of a balance.Balance with its time truncated to
+// the calendar date that it falls on, which is how Balances are held within a
+// Storage.
+func DateBalance(b balance.Balance) balance.Balance {
+	b.Date = date.FromTime(b.Date).Time()
+	return b
+}
+
+// MarshalJSON marshals a Balance into a json blob, with the date of the
+// Balance held as a date rather than a time.
+func (b Balance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		ID     uint
+		Date   date.Date
+		Amount int
+	}{
+		ID:     b.ID,
+		Date:   date.FromTime(b.Date),
+		Amount: b.Amount,
+	})
+}
+
+// UnmarshalJSON unmarshals a json blob into a Balance, accepting a date that
+// is held as either a date or a time.
+func (b *Balance) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		ID     uint
+		Date   date.Date
+		Amount int
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return errors.Wrap(err, "unmarshalling into auxiliary balance struct")
+	}
+	*b = Balance{
+		ID:      aux.ID,
+		Balance: balance.Balance{Date: aux.Date.Time(), Amount: aux.Amount},
+	}
+	return nil
+}
+
 // Balances holds multiple Balance items
 type Balances []Balance
 
@@ -29,3 +76,94 @@ func (bs Balances) InnerBalances() balance.Balances {
 	}
 	return bbs
 }
+
+// BalancesOptions filters, orders and pages the Balances of an Account that
+// are selected with SelectAccountBalancesWithOptions. Options that are left as
+// their zero value do not filter or limit the Balances, which are ordered by
+// date and then by ID.
+type BalancesOptions struct {
+	// From includes only Balances on or after the date
+	From *date.Date
+	// To includes only Balances on or before the date
+	To *date.Date
+	// After includes only Balances that come after the BalanceCursor in the
+	// order of the Balances, to select the page that follows a previous page
+	After *BalanceCursor
+	// Limit is the maximum number of Balances to select, when it is not zero
+	Limit uint
+	// Descending orders the Balances from the latest to the earliest
+	Descending bool
+}
+
+// Select returns the Balances that match the BalancesOptions, in their order
+// and up to their limit. The given Balances must be ordered by date and then
+// by ID.
+func (o BalancesOptions) Select(bs Balances) Balances {
+	selected := Balances{}
+	for _, b := range bs {
+		d := date.FromTime(b.Date)
+		if o.From != nil && d.Before(*o.From) || o.To != nil && d.After(*o.To) {
+			continue
+		}
+		if o.After != nil && !o.After.before(b.Cursor(), o.Descending) {
+			continue
+		}
+		selected = append(selected, b)
+	}
+	if o.Descending {
+		for i, j := 0, len(selected)-1; i < j; i, j = i+1, j-1 {
+			selected[i], selected[j] = selected[j], selected[i]
+		}
+	}
+	if o.Limit != 0 && uint(len(selected)) > o.Limit {
+		selected = selected[:o.Limit]
+	}
+	return selected
+}
+
+// BalanceCursor is the position of a Balance within the Balances of an
+// Account, which are ordered by date and then by ID.
+type BalanceCursor struct {
+	Date date.Date
+	ID   uint
+}
+
+// Cursor returns the BalanceCursor of the Balance
+func (b Balance) Cursor() BalanceCursor {
+	return BalanceCursor{Date: date.FromTime(b.Date), ID: b.ID}
+}
+
+// before returns true if the BalanceCursor comes before the other in the
+// ascending, or descending, order of Balances.
+func (c BalanceCursor) before(o BalanceCursor, descending bool) bool {
+	if descending {
+		c, o = o, c
+	}
+	if !c.Date.Equal(o.Date) {
+		return c.Date.Before(o.Date)
+	}
+	return c.ID < o.ID
+}
+
+// String returns the BalanceCursor in the form yyyy-mm-dd_ID, which can be
+// parsed with ParseBalanceCursor.
+func (c BalanceCursor) String() string {
+	return c.Date.String() + "_" + strconv.FormatUint(uint64(c.ID), 10)
+}
+
+// ParseBalanceCursor parses a BalanceCursor that is in the form yyyy-mm-dd_ID
+func ParseBalanceCursor(value string) (BalanceCursor, error) {
+	i := strings.LastIndex(value, "_")
+	if i < 0 {
+		return BalanceCursor{}, errors.Errorf("balance cursor %q is not in the form yyyy-mm-dd_ID", value)
+	}
+	d, err := date.Parse(value[:i])
+	if err != nil {
+		return BalanceCursor{}, errors.Wrap(err, "parsing balance cursor date")
+	}
+	id, err := strconv.ParseUint(value[i+1:], 10, 64)
+	if err != nil {
+		return BalanceCursor{}, errors.Wrap(err, "parsing balance cursor ID")
+	}
+	return BalanceCursor{Date: d, ID: uint(id)}, nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/glynternet/go-accounting/balance"
 	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -59,6 +60,35 @@ func TestBalance_JSONLoop(t *testing.T) {
 	assert.Equal(t, b, actual)
 }
 
+func TestBalance_JSONDate(t *testing.T) {
+	b := Balance{
+		ID: 47,
+		Balance: balance.Balance{
+			Date:   time.Date(2018, time.May, 4, 13, 45, 0, 0, time.UTC),
+			Amount: 12,
+		},
+	}
+	bs, err := json.Marshal(b)
+	common.FatalIfError(t, err, "marshalling Balance json")
+	assert.JSONEq(t, `{"ID":47,"Date":"2018-05-04","Amount":12}`, string(bs))
+
+	var actual Balance
+	err = json.Unmarshal([]byte(`{"ID":47,"Date":"2018-05-04T13:45:00Z","Amount":12}`), &actual)
+	common.FatalIfError(t, err, "unmarshalling Balance json with time")
+	assert.Equal(t, DateBalance(b.Balance), actual.Balance)
+}
+
+func TestDateBalance(t *testing.T) {
+	b := balance.Balance{
+		Date:   time.Date(2018, time.May, 4, 13, 45, 0, 0, time.FixedZone("BST", 3600)),
+		Amount: 12,
+	}
+	assert.Equal(t, balance.Balance{
+		Date:   time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC),
+		Amount: 12,
+	}, DateBalance(b))
+}
+
 func TestBalances_InnerBalances(t *testing.T) {
 	for _, test := range []struct {
 		name string
@@ -101,3 +131,46 @@ func TestBalances_InnerBalances(t *testing.T) {
 		})
 	}
 }
+
+func TestBalancesOptions_Select(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	newBalance := func(id uint, days int) Balance {
+		return Balance{ID: id, Balance: balance.Balance{Date: d.AddDays(days).Time()}}
+	}
+	bs := Balances{newBalance(1, 0), newBalance(4, 1), newBalance(5, 1), newBalance(2, 2), newBalance(3, 3)}
+	from, to := d.AddDays(1), d.AddDays(2)
+	after := newBalance(4, 1).Cursor()
+
+	for _, test := range []struct {
+		name string
+		BalancesOptions
+		ids []uint
+	}{
+		{name: "zero-values", ids: []uint{1, 4, 5, 2, 3}},
+		{name: "from and to", BalancesOptions: BalancesOptions{From: &from, To: &to}, ids: []uint{4, 5, 2}},
+		{name: "descending with limit", BalancesOptions: BalancesOptions{Descending: true, Limit: 2}, ids: []uint{3, 2}},
+		{name: "after", BalancesOptions: BalancesOptions{After: &after, Limit: 2}, ids: []uint{5, 2}},
+		{name: "after descending", BalancesOptions: BalancesOptions{After: &after, Descending: true}, ids: []uint{1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ids := []uint{}
+			for _, b := range test.Select(bs) {
+				ids = append(ids, b.ID)
+			}
+			assert.Equal(t, test.ids, ids)
+		})
+	}
+}
+
+func TestParseBalanceCursor(t *testing.T) {
+	c := BalanceCursor{Date: date.New(2018, time.May, 4), ID: 12}
+	assert.Equal(t, "2018-05-04_12", c.String())
+	parsed, err := ParseBalanceCursor(c.String())
+	common.FatalIfError(t, err, "parsing cursor")
+	assert.Equal(t, c, parsed)
+
+	for _, value := range []string{"", "2018-05-04", "2018-05-04_", "04-05-2018_12", "2018-05-04_-1"} {
+		_, err := ParseBalanceCursor(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/balance"
+)
+
+// ContextStorage is a Storage that also has a version of each of its methods
+// that takes a context.Context, so that a slow operation can be cancelled or
+// given a deadline.
+type ContextStorage interface {
+	Storage
+	AvailableContext(ctx context.Context) bool
+	InsertAccountContext(ctx context.Context, a account.Account) (*Account, error)
+	SelectAccountContext(ctx context.Context, id uint) (*Account, error)
+	UpdateAccountContext(ctx context.Context, a *Account, updates *account.Account) (*Account, error)
+	SelectAccountsContext(ctx context.Context) (*Accounts, error)
+	DeleteAccountContext(ctx context.Context, id uint) error
+	//
+	InsertBalanceContext(ctx context.Context, a Account, b balance.Balance) (*Balance, error)
+	SelectAccountBalancesContext(ctx context.Context, a Account) (*Balances, error)
+	SelectAccountBalancesWithOptionsContext(ctx context.Context, a Account, o BalancesOptions) (*Balances, error)
+	UpdateBalanceContext(ctx context.Context, a Account, b *Balance, us balance.Balance) (*Balance, error)
+	DeleteBalanceContext(ctx context.Context, a Account, b *Balance) error
+	//
+	InsertTransactionContext(ctx context.Context, t Transaction) (*Transaction, error)
+	SelectTransactionsContext(ctx context.Context) (*Transactions, error)
+	SelectAccountTransactionsContext(ctx context.Context, a Account) (*Transactions, error)
+	//
+	InsertRateContext(ctx context.Context, r Rate) (*Rate, error)
+	SelectRatesContext(ctx context.Context) (*Rates, error)
+}
+
+// WithContext returns the Storage as a ContextStorage. A Storage that is not
+// already a ContextStorage is adapted so that each of its context methods
+// returns the error of the context.Context, without calling the Storage, once
+// the context.Context is done. An operation that has already started on such
+// a Storage cannot be cancelled.
+func WithContext(s Storage) ContextStorage {
+	if cs, ok := s.(ContextStorage); ok {
+		return cs
+	}
+	if bs, ok := s.(BalancesAtStorage); ok {
+		return balancesAtContextAdapter{contextAdapter: contextAdapter{Storage: s}, balancesAt: bs}
+	}
+	return contextAdapter{Storage: s}
+}
+
+type contextAdapter struct {
+	Storage
+}
+
+// balancesAtContextAdapter is a contextAdapter that keeps the
+// BalancesAtStorage methods of the Storage that it adapts
+type balancesAtContextAdapter struct {
+	contextAdapter
+	balancesAt BalancesAtStorage
+}
+
+func (c balancesAtContextAdapter) SelectBalancesAt(t time.Time) (map[uint]Balance, error) {
+	return c.balancesAt.SelectBalancesAt(t)
+}
+
+func (c balancesAtContextAdapter) SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]Balance, error) {
+	return c.balancesAt.SelectBalancesAtContext(ctx, t)
+}
+
+func (c contextAdapter) AvailableContext(ctx context.Context) bool {
+	return ctx.Err() == nil && c.Available()
+}
+
+func (c contextAdapter) InsertAccountContext(ctx context.Context, a account.Account) (*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.InsertAccount(a)
+}
+
+func (c contextAdapter) SelectAccountContext(ctx context.Context, id uint) (*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectAccount(id)
+}
+
+func (c contextAdapter) UpdateAccountContext(ctx context.Context, a *Account, updates *account.Account) (*Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.UpdateAccount(a, updates)
+}
+
+func (c contextAdapter) SelectAccountsContext(ctx context.Context) (*Accounts, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectAccounts()
+}
+
+func (c contextAdapter) DeleteAccountContext(ctx context.Context, id uint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.DeleteAccount(id)
+}
+
+func (c contextAdapter) InsertBalanceContext(ctx context.Context, a Account, b balance.Balance) (*Balance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.InsertBalance(a, b)
+}
+
+func (c contextAdapter) SelectAccountBalancesContext(ctx context.Context, a Account) (*Balances, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectAccountBalances(a)
+}
+
+func (c contextAdapter) SelectAccountBalancesWithOptionsContext(ctx context.Context, a Account, o BalancesOptions) (*Balances, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectAccountBalancesWithOptions(a, o)
+}
+
+func (c contextAdapter) UpdateBalanceContext(ctx context.Context, a Account, b *Balance, us balance.Balance) (*Balance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.UpdateBalance(a, b, us)
+}
+
+func (c contextAdapter) DeleteBalanceContext(ctx context.Context, a Account, b *Balance) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.DeleteBalance(a, b)
+}
+
+func (c contextAdapter) InsertTransactionContext(ctx context.Context, t Transaction) (*Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.InsertTransaction(t)
+}
+
+func (c contextAdapter) SelectTransactionsContext(ctx context.Context) (*Transactions, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectTransactions()
+}
+
+func (c contextAdapter) SelectAccountTransactionsContext(ctx context.Context, a Account) (*Transactions, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectAccountTransactions(a)
+}
+
+func (c contextAdapter) InsertRateContext(ctx context.Context, r Rate) (*Rate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.InsertRate(r)
+}
+
+func (c contextAdapter) SelectRatesContext(ctx context.Context) (*Rates, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SelectRates()
+}
@@ -0,0 +1,41 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// contextStorage is a storage.ContextStorage that is not an adapter
+type contextStorage struct {
+	storage.ContextStorage
+}
+
+func TestWithContext(t *testing.T) {
+	cs := contextStorage{}
+	assert.Equal(t, cs, storage.WithContext(cs))
+
+	expected := &storage.Accounts{{ID: 1}}
+	adapted := storage.WithContext(&storagetest.Storage{Accounts: expected, IsAvailable: true})
+	assert.True(t, adapted.AvailableContext(context.Background()))
+	as, err := adapted.SelectAccountsContext(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, as)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	as, err = adapted.SelectAccountsContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, as)
+	_, err = adapted.SelectAccountBalancesContext(ctx, storage.Account{})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, adapted.DeleteAccountContext(ctx, 1))
+	assert.False(t, adapted.AvailableContext(ctx))
+
+	_, ok := storage.WithContext(memory.New()).(storage.BalancesAtStorage)
+	assert.True(t, ok, "an adapted storage.BalancesAtStorage should still be one")
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// SelectAccounts returns all Accounts that have not been deleted, sorted by ID
+// in ascending order.
+func (m *memory) SelectAccounts() (*storage.Accounts, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	as := storage.Accounts{}
+	for _, a := range m.accounts {
+		if !a.Deleted() {
+			as = append(as, a)
+		}
+	}
+	return &as, nil
+}
+
+// SelectAllAccounts returns all Accounts, including those that have been
+// deleted, sorted by ID in ascending order.
+func (m *memory) SelectAllAccounts() (*storage.Accounts, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	as := make(storage.Accounts, len(m.accounts))
+	copy(as, m.accounts)
+	return &as, nil
+}
+
+// SelectAccount returns the Account with the given id. An error is returned if
+// no Account exists with the id or if the Account has been deleted.
+func (m *memory) SelectAccount(id uint) (*storage.Account, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	i, err := m.undeletedAccountIndex(id)
+	if err != nil {
+		return nil, err
+	}
+	a := m.accounts[i]
+	return &a, nil
+}
+
+// InsertAccount inserts an account.Account in the storage and returns it. The
+// opened and closed times of the account.Account are stored as dates.
+func (m *memory) InsertAccount(a account.Account) (*storage.Account, error) {
+	dated, err := storage.DateAccount(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "dating Account")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	m.lastAccountID++
+	inserted := storage.Account{ID: m.lastAccountID, Account: *dated}
+	m.accounts = append(m.accounts, inserted)
+	return &inserted, nil
+}
+
+// UpdateAccount updates a stored account to reflect the details of some other
+// account data. The updates will be verified to ensure that any data to be
+// used will be logically sound with the balances and other account details.
+func (m *memory) UpdateAccount(a *storage.Account, updates *account.Account) (*storage.Account, error) {
+	updates, err := storage.DateAccount(*updates)
+	if err != nil {
+		return nil, errors.Wrap(err, "dating Account updates")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	i, err := m.accountIndex(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range m.balances[a.ID] {
+		err := updates.ValidateBalance(b.Balance)
+		if err != nil {
+			return nil, fmt.Errorf("update would make balance invalid: %v", err)
+		}
+	}
+	m.accounts[i].Account = *updates
+	updated := m.accounts[i]
+	return &updated, nil
+}
+
+// DeleteAccount marks the Account with the given id as deleted. An error is
+// returned if the Account does not exist or has already been deleted.
+func (m *memory) DeleteAccount(id uint) error {
+	return m.DeleteAccountAt(id, time.Now())
+}
+
+// DeleteAccountAt marks the Account with the given id as deleted at the given
+// time. An error is returned if the Account does not exist or has already been
+// deleted.
+func (m *memory) DeleteAccountAt(id uint, t time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	i, err := m.undeletedAccountIndex(id)
+	if err != nil {
+		return errors.Wrap(err, "selecting account to delete")
+	}
+	return storage.DeletedAt(t)(&m.accounts[i])
+}
+
+// accountIndex returns the index of the account with the given id, whether
+// it has been deleted or not.
+// accountIndex expects the caller to hold the lock.
+func (m *memory) accountIndex(id uint) (int, error) {
+	for i := range m.accounts {
+		if m.accounts[i].ID == id {
+			return i, nil
+		}
+	}
+	return 0, errors.Wrapf(storage.ErrNoAccount, "account with id %d", id)
+}
+
+// undeletedAccountIndex returns the index of the account with the given id,
+// returning an error if the account has been deleted.
+// undeletedAccountIndex expects the caller to hold the lock.
+func (m *memory) undeletedAccountIndex(id uint) (int, error) {
+	i, err := m.accountIndex(id)
+	if err != nil {
+		return 0, err
+	}
+	if m.accounts[i].Deleted() {
+		return 0, errors.Wrapf(storage.ErrNoAccount, "account with id %d has been deleted", id)
+	}
+	return i, nil
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// SelectAccountBalances returns all Balances for a given Account. The Balances
+// are sorted by chronological order then by the id of the Balance.
+func (m *memory) SelectAccountBalances(a storage.Account) (*storage.Balances, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	bs := make(storage.Balances, len(m.balances[a.ID]))
+	copy(bs, m.balances[a.ID])
+	return &bs, nil
+}
+
+// SelectAccountBalancesWithOptions returns the Balances of a given Account that
+// match the given BalancesOptions, in their order and up to their limit.
+func (m *memory) SelectAccountBalancesWithOptions(a storage.Account, o storage.BalancesOptions) (*storage.Balances, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	bs := o.Select(m.balances[a.ID])
+	return &bs, nil
+}
+
+// SelectBalancesAt returns the Balance of every Account at the given time,
+// keyed by the ID of the Account.
+func (m *memory) SelectBalancesAt(t time.Time) (map[uint]storage.Balance, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	at := make(map[uint]storage.Balance)
+	for id, bs := range m.balances {
+		// bs is sorted by date then by ID, so the last Balance that is not
+		// after t is the Balance at t
+		for _, b := range bs {
+			if b.Date.After(t) {
+				break
+			}
+			at[id] = b
+		}
+	}
+	return at, nil
+}
+
+// SelectBalancesAtContext is the same as SelectBalancesAt but returns the error
+// of the context.Context, without selecting anything, once it is done.
+func (m *memory) SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]storage.Balance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.SelectBalancesAt(t)
+}
+
+// InsertBalance validates a balance.Balance against the given Account and, if
+// valid, stores it against the Account.
+func (m *memory) InsertBalance(a storage.Account, b balance.Balance) (*storage.Balance, error) {
+	b = storage.DateBalance(b)
+	err := a.Account.ValidateBalance(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating balance")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	m.lastBalanceID++
+	inserted := storage.Balance{ID: m.lastBalanceID, Balance: b}
+	bs := append(m.balances[a.ID], inserted)
+	sortBalances(bs)
+	m.balances[a.ID] = bs
+	return &inserted, nil
+}
+
+// UpdateBalance updates a Balance of the given Account to reflect the details
+// of some other balance data. The updates will be validated against the
+// Account to ensure that the updated Balance would be logically sound.
+func (m *memory) UpdateBalance(a storage.Account, b *storage.Balance, us balance.Balance) (*storage.Balance, error) {
+	us = storage.DateBalance(us)
+	err := a.Account.ValidateBalance(us)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating balance updates")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	bs := m.balances[a.ID]
+	i, err := balanceIndex(bs, b.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding balance for account with id %d", a.ID)
+	}
+	updated := storage.Balance{ID: b.ID, Balance: us}
+	bs[i] = updated
+	sortBalances(bs)
+	return &updated, nil
+}
+
+// DeleteBalance deletes a Balance of the given Account. An error will be
+// returned if the Balance does not exist for the Account.
+func (m *memory) DeleteBalance(a storage.Account, b *storage.Balance) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	bs := m.balances[a.ID]
+	i, err := balanceIndex(bs, b.ID)
+	if err != nil {
+		return errors.Wrapf(err, "finding balance for account with id %d", a.ID)
+	}
+	m.balances[a.ID] = append(bs[:i], bs[i+1:]...)
+	return nil
+}
+
+// balanceIndex returns the index of the Balance with the given id.
+func balanceIndex(bs storage.Balances, id uint) (int, error) {
+	for i := range bs {
+		if bs[i].ID == id {
+			return i, nil
+		}
+	}
+	return 0, errors.Errorf("no balance with id %d", id)
+}
+
+// sortBalances sorts Balances by chronological order then by ID
+func sortBalances(bs storage.Balances) {
+	sort.Slice(bs, func(i, j int) bool {
+		if !bs[i].Date.Equal(bs[j].Date) {
+			return bs[i].Date.Before(bs[j].Date)
+		}
+		return bs[i].ID < bs[j].ID
+	})
+}
@@ -0,0 +1,112 @@
+// Package memory provides a storage.Storage that holds all of its data in
+// memory. It is safe for concurrent use and is intended for testing and for
+// running mon locally without a database.
+package memory
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/glynternet/mon/pkg/storage"
+)
+
+var errClosed = errors.New("storage is closed")
+
+// New returns a new, empty in-memory Storage.
+func New() *memory {
+	return &memory{data: data{
+		balances: make(map[uint]storage.Balances),
+		access:   make(map[uint]map[uint]storage.Access),
+	}}
+}
+
+type memory struct {
+	sync.RWMutex
+	closed bool
+	data
+}
+
+// data holds everything that is stored by a memory
+type data struct {
+	accounts      storage.Accounts
+	lastAccountID uint
+
+	// balances holds the Balances of each account, keyed by account ID
+	balances      map[uint]storage.Balances
+	lastBalanceID uint
+
+	transactions      storage.Transactions
+	lastTransactionID uint
+
+	rates      storage.Rates
+	lastRateID uint
+
+	tokens      storage.Tokens
+	lastTokenID uint
+
+	users      storage.Users
+	lastUserID uint
+
+	// access holds the Access of each user to each account, keyed by user ID
+	// then by account ID
+	access map[uint]map[uint]storage.Access
+}
+
+// copy returns a copy of the data that shares none of its slices or maps, so
+// that changes to the copy leave the data unchanged.
+func (d data) copy() data {
+	c := d
+	c.accounts = append(storage.Accounts(nil), d.accounts...)
+	c.balances = make(map[uint]storage.Balances, len(d.balances))
+	for id, bs := range d.balances {
+		c.balances[id] = append(storage.Balances(nil), bs...)
+	}
+	c.transactions = append(storage.Transactions(nil), d.transactions...)
+	c.rates = append(storage.Rates(nil), d.rates...)
+	c.tokens = append(storage.Tokens(nil), d.tokens...)
+	c.users = append(storage.Users(nil), d.users...)
+	c.access = make(map[uint]map[uint]storage.Access, len(d.access))
+	for userID, as := range d.access {
+		c.access[userID] = make(map[uint]storage.Access, len(as))
+		for accountID, a := range as {
+			c.access[userID][accountID] = a
+		}
+	}
+	return c
+}
+
+// Available returns true if the Storage has not been closed
+func (m *memory) Available() bool {
+	m.RLock()
+	defer m.RUnlock()
+	return !m.closed
+}
+
+// Close closes the Storage. Any subsequent operations on the Storage will
+// return an error.
+func (m *memory) Close() error {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	m.closed = true
+	return nil
+}
+
+// InTransaction calls fn with a copy of the Storage, replacing the contents of
+// the Storage with those of the copy if fn returns nil. Every other operation
+// on the Storage waits until fn has returned.
+func (m *memory) InTransaction(fn func(storage.ArchiveStorage) error) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	tx := &memory{data: m.data.copy()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	m.data = tx.data
+	return nil
+}
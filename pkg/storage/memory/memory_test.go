@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// ensure that a memory can be used as a storage.ArchiveStorage
+var _ storage.ArchiveStorage = New()
+
+// ensure that a memory can be used as a storage.BalancesAtStorage
+var _ storage.BalancesAtStorage = New()
+
+// ensure that a memory can be used as a storage.UserStorage
+var _ storage.UserStorage = New()
+
+func TestSuite(t *testing.T) {
+	storagetest.Test(t, New())
+}
+
+func TestMemory_Close(t *testing.T) {
+	m := New()
+	assert.True(t, m.Available())
+	assert.NoError(t, m.Close())
+	assert.False(t, m.Available())
+	assert.Error(t, m.Close())
+
+	as, err := m.SelectAccounts()
+	assert.Equal(t, errClosed, err)
+	assert.Nil(t, as)
+}
+
+func TestMemory_concurrentInserts(t *testing.T) {
+	m := New()
+	a, err := m.InsertAccount(*accountingtest.NewAccount(
+		t, "A", accountingtest.NewCurrencyCode(t, "GBP"), time.Now(),
+	))
+	common.FatalIfError(t, err, "inserting account")
+
+	const count = 50
+	var wg sync.WaitGroup
+	wg.Add(count)
+	for i := 0; i < count; i++ {
+		go func(i int) {
+			defer wg.Done()
+			b, err := balance.New(a.Account.Opened().AddDate(0, 0, i), balance.Amount(i))
+			if !assert.NoError(t, err, "creating balance") {
+				return
+			}
+			_, err = m.InsertBalance(*a, *b)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	bs, err := m.SelectAccountBalances(*a)
+	common.FatalIfError(t, err, "selecting balances")
+	if !assert.Len(t, *bs, count) {
+		t.FailNow()
+	}
+	for i, b := range *bs {
+		assert.Equal(t, i, b.Amount, "balances should be in chronological order")
+	}
+}
+
+func TestMemory_noAccount(t *testing.T) {
+	store := New()
+	a, err := store.InsertAccount(*accountingtest.NewAccount(
+		t, "A", accountingtest.NewCurrencyCode(t, "GBP"), time.Now(),
+	))
+	common.FatalIfError(t, err, "inserting account")
+
+	_, err = store.SelectAccount(a.ID + 1)
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(store.DeleteAccount(a.ID+1)))
+
+	common.FatalIfError(t, store.DeleteAccount(a.ID), "deleting account")
+	_, err = store.SelectAccount(a.ID)
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(err), "a deleted account should not be found")
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(store.DeleteAccount(a.ID)))
+}
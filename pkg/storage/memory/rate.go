@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// InsertRate validates a Rate and, if valid, stores it. The ID of the given
+// Rate is ignored.
+func (m *memory) InsertRate(r storage.Rate) (*storage.Rate, error) {
+	err := storage.ValidateRate(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating rate")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	m.lastRateID++
+	r.ID = m.lastRateID
+	m.rates = append(m.rates, r)
+	sort.Slice(m.rates, func(i, j int) bool {
+		if !m.rates[i].Date.Equal(m.rates[j].Date) {
+			return m.rates[i].Date.Before(m.rates[j].Date)
+		}
+		return m.rates[i].ID < m.rates[j].ID
+	})
+	return &r, nil
+}
+
+// SelectRates returns all Rates, sorted by chronological order then by the id
+// of the Rate.
+func (m *memory) SelectRates() (*storage.Rates, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	rs := make(storage.Rates, len(m.rates))
+	copy(rs, m.rates)
+	return &rs, nil
+}
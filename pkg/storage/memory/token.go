@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// InsertToken stores a new Token with the given name, secret hash and User,
+// created at the current time.
+func (m *memory) InsertToken(name, hash string, userID uint) (*storage.Token, error) {
+	err := storage.ValidateTokenName(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating token name")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	if userID != 0 && m.user(userID) == nil {
+		return nil, fmt.Errorf("no user with id %d", userID)
+	}
+	m.lastTokenID++
+	t := storage.Token{ID: m.lastTokenID, Name: name, Hash: hash, UserID: userID, Created: time.Now()}
+	m.tokens = append(m.tokens, t)
+	return &t, nil
+}
+
+// SelectTokens returns all Tokens, including revoked Tokens, sorted by their
+// ID.
+func (m *memory) SelectTokens() (*storage.Tokens, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	ts := make(storage.Tokens, len(m.tokens))
+	copy(ts, m.tokens)
+	return &ts, nil
+}
+
+// SelectTokenByHash returns the Token with the given secret hash, or
+// storage.ErrNoToken if there is none.
+func (m *memory) SelectTokenByHash(hash string) (*storage.Token, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	for _, t := range m.tokens {
+		if t.Hash == hash {
+			return &t, nil
+		}
+	}
+	return nil, storage.ErrNoToken
+}
+
+// RevokeToken marks the Token with the given id as revoked at the current
+// time.
+func (m *memory) RevokeToken(id uint) error {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	for i := range m.tokens {
+		if m.tokens[i].ID != id {
+			continue
+		}
+		if m.tokens[i].Revoked != nil {
+			return fmt.Errorf("token with id %d has already been revoked", id)
+		}
+		now := time.Now()
+		m.tokens[i].Revoked = &now
+		return nil
+	}
+	return fmt.Errorf("no token with id %d", id)
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"sort"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// InsertTransaction validates a Transaction against its source and
+// destination Accounts and, if valid, stores it. The ID of the given
+// Transaction is ignored.
+func (m *memory) InsertTransaction(t storage.Transaction) (*storage.Transaction, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	si, err := m.undeletedAccountIndex(t.SourceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting source account")
+	}
+	di, err := m.undeletedAccountIndex(t.DestinationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting destination account")
+	}
+	err = storage.ValidateTransaction(t, m.accounts[si], m.accounts[di])
+	if err != nil {
+		return nil, errors.Wrap(err, "validating transaction")
+	}
+	m.lastTransactionID++
+	t.ID = m.lastTransactionID
+	m.transactions = append(m.transactions, t)
+	sortTransactions(m.transactions)
+	return &t, nil
+}
+
+// SelectTransactions returns all Transactions, sorted by chronological order
+// then by the id of the Transaction.
+func (m *memory) SelectTransactions() (*storage.Transactions, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	ts := make(storage.Transactions, len(m.transactions))
+	copy(ts, m.transactions)
+	return &ts, nil
+}
+
+// SelectAccountTransactions returns all Transactions that the given Account
+// is either the source or destination of, sorted by chronological order then
+// by the id of the Transaction.
+func (m *memory) SelectAccountTransactions(a storage.Account) (*storage.Transactions, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	ts := storage.Transactions{}
+	for _, t := range m.transactions {
+		if t.SourceID == a.ID || t.DestinationID == a.ID {
+			ts = append(ts, t)
+		}
+	}
+	return &ts, nil
+}
+
+// sortTransactions sorts Transactions by chronological order then by ID
+func sortTransactions(ts storage.Transactions) {
+	sort.Slice(ts, func(i, j int) bool {
+		if !ts[i].Date.Equal(ts[j].Date) {
+			return ts[i].Date.Before(ts[j].Date)
+		}
+		return ts[i].ID < ts[j].ID
+	})
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// InsertUser stores a new User with the given name, which must not already be
+// used by another User.
+func (m *memory) InsertUser(name string) (*storage.User, error) {
+	err := storage.ValidateUserName(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating user name")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	for _, u := range m.users {
+		if u.Name == name {
+			return nil, fmt.Errorf("user with name %q already exists", name)
+		}
+	}
+	m.lastUserID++
+	u := storage.User{ID: m.lastUserID, Name: name}
+	m.users = append(m.users, u)
+	return &u, nil
+}
+
+// SelectUsers returns all Users, sorted by their ID.
+func (m *memory) SelectUsers() (*storage.Users, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	us := make(storage.Users, len(m.users))
+	copy(us, m.users)
+	return &us, nil
+}
+
+// SelectUserByName returns the User with the given name, or
+// storage.ErrNoUser if there is none.
+func (m *memory) SelectUserByName(name string) (*storage.User, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	for _, u := range m.users {
+		if u.Name == name {
+			return &u, nil
+		}
+	}
+	return nil, storage.ErrNoUser
+}
+
+// SetAccountAccess gives a User an Access to an Account, replacing any Access
+// that the User already has to it.
+func (m *memory) SetAccountAccess(accountID, userID uint, a storage.Access) error {
+	if _, err := storage.ParseAccess(string(a)); err != nil {
+		return errors.Wrap(err, "validating access")
+	}
+	m.Lock()
+	defer m.Unlock()
+	if m.closed {
+		return errClosed
+	}
+	if _, err := m.undeletedAccountIndex(accountID); err != nil {
+		return errors.Wrap(err, "selecting account")
+	}
+	if m.user(userID) == nil {
+		return fmt.Errorf("no user with id %d", userID)
+	}
+	if m.access[userID] == nil {
+		m.access[userID] = make(map[uint]storage.Access)
+	}
+	m.access[userID][accountID] = a
+	return nil
+}
+
+// SelectUserAccess returns the Access of a User to each Account that they
+// have access to, keyed by Account ID.
+func (m *memory) SelectUserAccess(userID uint) (map[uint]storage.Access, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if m.closed {
+		return nil, errClosed
+	}
+	as := make(map[uint]storage.Access, len(m.access[userID]))
+	for id, a := range m.access[userID] {
+		as[id] = a
+	}
+	return as, nil
+}
+
+// user returns the User with the given id, or nil if there is none.
+// user expects the caller to hold the lock.
+func (m *memory) user(id uint) *storage.User {
+	for i := range m.users {
+		if m.users[i].ID == id {
+			return &m.users[i]
+		}
+	}
+	return nil
+}
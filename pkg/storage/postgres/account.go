@@ -1,12 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/glynternet/go-accounting/account"
 	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
@@ -46,6 +48,12 @@ var (
 		fieldDeleted,
 		fieldID)
 
+	querySelectAllAccounts = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC;",
+		fieldsSelect,
+		table,
+		fieldID)
+
 	querySelectAccount = fmt.Sprintf(
 		"SELECT %s FROM %s WHERE %s = $1 AND %s IS NULL;",
 		fieldsSelect,
@@ -81,18 +89,48 @@ var (
 // the given database along with any errors that occurred whilst attempting to
 // retrieve the Accounts.
 func (pg postgres) SelectAccounts() (*storage.Accounts, error) {
-	return queryAccounts(pg.db, querySelectAccounts)
+	return pg.SelectAccountsContext(context.Background())
+}
+
+// SelectAccountsContext is the same as SelectAccounts but can be cancelled
+// with the given context.Context.
+func (pg postgres) SelectAccountsContext(ctx context.Context) (*storage.Accounts, error) {
+	return queryAccounts(ctx, pg.db, querySelectAccounts)
+}
+
+// SelectAllAccounts returns an Accounts item holding all Account entries
+// within the given database, including those that have been deleted.
+func (pg postgres) SelectAllAccounts() (*storage.Accounts, error) {
+	return queryAccounts(context.Background(), pg.db, querySelectAllAccounts)
 }
 
 // SelectAccount returns an Account with the given id.
 func (pg postgres) SelectAccount(id uint) (*storage.Account, error) {
-	dba, err := queryAccount(pg.db, querySelectAccount, id)
+	return pg.SelectAccountContext(context.Background(), id)
+}
+
+// SelectAccountContext is the same as SelectAccount but can be cancelled with
+// the given context.Context.
+func (pg postgres) SelectAccountContext(ctx context.Context, id uint) (*storage.Account, error) {
+	dba, err := queryAccount(ctx, pg.db, querySelectAccount, id)
 	return dba, errors.Wrap(err, "querying Account")
 }
 
-// InsertAccount inserts an account.Account in the storage backend and returns it.
+// InsertAccount inserts an account.Account in the storage backend and returns
+// it. The opened and closed times of the account.Account are stored as dates.
 func (pg postgres) InsertAccount(a account.Account) (*storage.Account, error) {
-	dba, err := queryAccount(pg.db, queryInsertAccount, a.Name(), a.Opened(), pq.NullTime(a.Closed()), a.CurrencyCode())
+	return pg.InsertAccountContext(context.Background(), a)
+}
+
+// InsertAccountContext is the same as InsertAccount but can be cancelled with
+// the given context.Context.
+func (pg postgres) InsertAccountContext(ctx context.Context, a account.Account) (*storage.Account, error) {
+	dated, err := storage.DateAccount(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "dating Account")
+	}
+	sa := storage.Account{Account: *dated}
+	dba, err := queryAccount(ctx, pg.db, queryInsertAccount, a.Name(), sa.Opened(), sa.Closed(), a.CurrencyCode())
 	return dba, errors.Wrap(err, "querying Account")
 }
 
@@ -100,7 +138,17 @@ func (pg postgres) InsertAccount(a account.Account) (*storage.Account, error) {
 // account data. The updates will be verified to ensure that any data to be
 // used will be logically sound with the balances and other account details.
 func (pg postgres) UpdateAccount(a *storage.Account, updates *account.Account) (*storage.Account, error) {
-	bs, err := pg.SelectAccountBalances(*a)
+	return pg.UpdateAccountContext(context.Background(), a, updates)
+}
+
+// UpdateAccountContext is the same as UpdateAccount but can be cancelled with
+// the given context.Context.
+func (pg postgres) UpdateAccountContext(ctx context.Context, a *storage.Account, updates *account.Account) (*storage.Account, error) {
+	updates, err := storage.DateAccount(*updates)
+	if err != nil {
+		return nil, errors.Wrap(err, "dating Account updates")
+	}
+	bs, err := pg.SelectAccountBalancesContext(ctx, *a)
 	if err != nil {
 		return nil, errors.Wrap(err, "selecting Account Balances for update validation")
 	}
@@ -110,24 +158,45 @@ func (pg postgres) UpdateAccount(a *storage.Account, updates *account.Account) (
 			return nil, fmt.Errorf("update would make balance invalid: %v", err)
 		}
 	}
+	su := storage.Account{Account: *updates}
 	dba, err := queryAccount(
+		ctx,
 		pg.db,
 		queryUpdateAccount,
 		updates.Name(),
-		updates.Opened(),
-		pq.NullTime(updates.Closed()),
+		su.Opened(),
+		su.Closed(),
 		updates.CurrencyCode(),
 		a.ID,
 	)
 	return dba, errors.Wrap(err, "querying Account")
 }
 
+// DeleteAccount marks the Account with the given id as deleted. An error is
+// returned if the Account does not exist or has already been deleted.
 func (pg postgres) DeleteAccount(id uint) error {
-	_, err := pg.SelectAccount(id)
+	return pg.DeleteAccountContext(context.Background(), id)
+}
+
+// DeleteAccountContext is the same as DeleteAccount but can be cancelled with
+// the given context.Context.
+func (pg postgres) DeleteAccountContext(ctx context.Context, id uint) error {
+	return pg.deleteAccountAt(ctx, id, time.Now())
+}
+
+// DeleteAccountAt marks the Account with the given id as deleted at the given
+// time. An error is returned if the Account does not exist or has already been
+// deleted.
+func (pg postgres) DeleteAccountAt(id uint, t time.Time) error {
+	return pg.deleteAccountAt(context.Background(), id, t)
+}
+
+func (pg postgres) deleteAccountAt(ctx context.Context, id uint, t time.Time) error {
+	_, err := pg.SelectAccountContext(ctx, id)
 	if err != nil {
 		return errors.Wrap(err, "selecting account to delete")
 	}
-	r, err := pg.db.Exec(queryDeleteAccount, time.Now(), id)
+	r, err := pg.db.ExecContext(ctx, queryDeleteAccount, t, id)
 	if err != nil {
 		return errors.Wrap(err, "executing query")
 	}
@@ -141,14 +210,22 @@ func (pg postgres) DeleteAccount(id uint) error {
 	return nil
 }
 
-func queryAccount(db *sql.DB, queryString string, values ...interface{}) (*storage.Account, error) {
-	as, err := queryAccounts(db, queryString, values...)
+// InTransaction calls fn with a Storage that makes every query within a single
+// transaction, which is committed if fn returns nil and rolled back otherwise.
+func (pg postgres) InTransaction(fn func(storage.ArchiveStorage) error) error {
+	return inTransaction(context.Background(), pg.conn, func(tx *sql.Tx) error {
+		return fn(&postgres{conn: pg.conn, db: tx})
+	})
+}
+
+func queryAccount(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Account, error) {
+	as, err := queryAccounts(ctx, db, queryString, values...)
 	if err != nil {
 		return nil, errors.Wrap(err, "querying accounts")
 	}
 	resLen := len(*as)
 	if resLen == 0 {
-		return nil, errors.New("query returned no accounts")
+		return nil, storage.ErrNoAccount
 	}
 	if resLen > 1 {
 		return nil, fmt.Errorf("expected 1 account but query returned %d", resLen)
@@ -156,8 +233,8 @@ func queryAccount(db *sql.DB, queryString string, values ...interface{}) (*stora
 	return &(*as)[0], nil
 }
 
-func queryAccounts(db *sql.DB, queryString string, values ...interface{}) (*storage.Accounts, error) {
-	rows, err := db.Query(queryString, values...)
+func queryAccounts(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Accounts, error) {
+	rows, err := db.QueryContext(ctx, queryString, values...)
 	if err != nil {
 		return nil, err
 	}
@@ -172,8 +249,9 @@ func scanRowsForAccounts(rows *sql.Rows) (*storage.Accounts, error) {
 	for rows.Next() {
 		var id uint
 		var name, code string
-		var opened time.Time
-		var closed, deleted pq.NullTime
+		var opened date.Date
+		var closed date.NullDate
+		var deleted pq.NullTime
 		// 	fieldID, fieldName, fieldOpened, fieldClosed, fieldCurrency, fieldDeleted)
 		err := rows.Scan(&id, &name, &opened, &closed, &code, &deleted)
 		if err != nil {
@@ -183,12 +261,12 @@ func scanRowsForAccounts(rows *sql.Rows) (*storage.Accounts, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "generating new currency code")
 		}
-		innerAccount, err := account.New(name, *c, opened)
+		innerAccount, err := account.New(name, *c, opened.Time())
 		if err != nil {
 			return nil, errors.Wrap(err, "creating new inner account")
 		}
 		if closed.Valid {
-			err = account.CloseTime(closed.Time)(innerAccount)
+			err = account.CloseTime(closed.Date.Time())(innerAccount)
 			if err != nil {
 				return nil, errors.Wrap(err, "applying closed time to inner account")
 			}
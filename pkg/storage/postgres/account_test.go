@@ -12,6 +12,7 @@ import (
 	"github.com/glynternet/go-money/common"
 	"github.com/glynternet/go-money/currency"
 	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -76,6 +77,23 @@ func Test_InsertAccount_SelectAccount(t *testing.T) {
 	}
 }
 
+func TestPostgres_noAccount(t *testing.T) {
+	deleteTestDBIgnorantly(t)
+	store := createTestDB(t)
+	defer deleteTestDB(t)
+	defer nonReturningCloseStorage(store)
+	a, err := store.InsertAccount(newTestAccounts(t, 1)[0])
+	common.FatalIfError(t, err, "inserting account")
+
+	_, err = store.SelectAccount(a.ID + 1)
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+
+	common.FatalIfError(t, store.DeleteAccount(a.ID), "deleting account")
+	_, err = store.SelectAccount(a.ID)
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(err), "a deleted account should not be found")
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(store.DeleteAccount(a.ID)))
+}
+
 func checkAccountsSortedByIDAscending(t *testing.T, accounts storage.Accounts) {
 	for i := 0; i+1 < len(accounts); i++ {
 		account := accounts[i]
@@ -1,11 +1,15 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/pkg/errors"
 )
@@ -35,7 +39,18 @@ var (
 		balancesSelectPrefix,
 		balancesFieldAccountID,
 		balancesFieldTime,
-		balancesFieldAccountID)
+		balancesFieldID)
+
+	balancesSelectBalancesAt = fmt.Sprintf(
+		"SELECT DISTINCT ON (%s) %s, %s FROM %s WHERE %s <= $1 ORDER BY %s ASC, %s DESC, %s DESC;",
+		balancesFieldAccountID,
+		balancesFieldAccountID,
+		balancesSelectFields,
+		balancesTable,
+		balancesFieldTime,
+		balancesFieldAccountID,
+		balancesFieldTime,
+		balancesFieldID)
 
 	balancesInsertFields = fmt.Sprintf(
 		"%s, %s, %s",
@@ -48,21 +63,122 @@ var (
 		balancesTable,
 		balancesInsertFields,
 		balancesSelectFields)
+
+	balancesUpdateBalance = fmt.Sprintf(
+		`UPDATE %s SET %s = $1, %s = $2 WHERE %s = $3 AND %s = $4 RETURNING %s;`,
+		balancesTable,
+		balancesFieldTime,
+		balancesFieldAmount,
+		balancesFieldID,
+		balancesFieldAccountID,
+		balancesSelectFields)
+
+	balancesDeleteBalance = fmt.Sprintf(
+		`DELETE FROM %s WHERE %s = $1 AND %s = $2;`,
+		balancesTable,
+		balancesFieldID,
+		balancesFieldAccountID)
 )
 
 // SelectAccountBalances returns all Balances for a given Account and any
 // errors that occur whilst attempting to retrieve the Balances. The Balances
 // are sorted by chronological order then by the id of the Balance in the DB
 func (pg postgres) SelectAccountBalances(a storage.Account) (*storage.Balances, error) {
-	return pg.selectBalancesForAccountID(a.ID)
+	return pg.SelectAccountBalancesContext(context.Background(), a)
+}
+
+// SelectAccountBalancesContext is the same as SelectAccountBalances but can be
+// cancelled with the given context.Context.
+func (pg postgres) SelectAccountBalancesContext(ctx context.Context, a storage.Account) (*storage.Balances, error) {
+	return pg.selectBalancesForAccountID(ctx, a.ID)
 }
 
 // selectBalancesForAccountID returns all Balance items, as a single Balances
 // item, for a given account ID number in the given database, along with any
 // errors that occur whilst attempting to retrieve the Balances. The Balances
 // are sorted by chronological order then by the id of the Balance in the DB
-func (pg postgres) selectBalancesForAccountID(accountID uint) (*storage.Balances, error) {
-	return queryBalances(pg.db, balancesSelectBalancesForAccountID, accountID)
+func (pg postgres) selectBalancesForAccountID(ctx context.Context, accountID uint) (*storage.Balances, error) {
+	return queryBalances(ctx, pg.db, balancesSelectBalancesForAccountID, accountID)
+}
+
+// SelectBalancesAt returns the Balance of every Account at the given time,
+// keyed by the ID of the Account, using a single query.
+func (pg postgres) SelectBalancesAt(t time.Time) (map[uint]storage.Balance, error) {
+	return pg.SelectBalancesAtContext(context.Background(), t)
+}
+
+// SelectBalancesAtContext is the same as SelectBalancesAt but can be cancelled
+// with the given context.Context.
+func (pg postgres) SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]storage.Balance, error) {
+	rows, err := pg.db.QueryContext(ctx, balancesSelectBalancesAt, date.FromTime(t))
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	bs := make(map[uint]storage.Balance)
+	for rows.Next() {
+		var accountID, ID uint
+		var d date.Date
+		var amount float64
+		if err := rows.Scan(&accountID, &ID, &d, &amount); err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		bs[accountID] = storage.Balance{ID: ID, Balance: balance.Balance{Date: d.Time(), Amount: int(amount)}}
+	}
+	return bs, errors.Wrap(rows.Err(), "rows error")
+}
+
+// SelectAccountBalancesWithOptions returns the Balances of a given Account that
+// match the given BalancesOptions, in their order and up to their limit, using
+// a single query.
+func (pg postgres) SelectAccountBalancesWithOptions(a storage.Account, o storage.BalancesOptions) (*storage.Balances, error) {
+	return pg.SelectAccountBalancesWithOptionsContext(context.Background(), a, o)
+}
+
+// SelectAccountBalancesWithOptionsContext is the same as
+// SelectAccountBalancesWithOptions but can be cancelled with the given
+// context.Context.
+func (pg postgres) SelectAccountBalancesWithOptionsContext(ctx context.Context, a storage.Account, o storage.BalancesOptions) (*storage.Balances, error) {
+	query, values := balancesSelectWithOptions(a.ID, o)
+	return queryBalances(ctx, pg.db, query, values...)
+}
+
+// balancesSelectWithOptions returns the query, and the values for it, that
+// selects the Balances of an Account that match the BalancesOptions.
+func balancesSelectWithOptions(accountID uint, o storage.BalancesOptions) (string, []interface{}) {
+	var values []interface{}
+	placeholder := func(value interface{}) string {
+		values = append(values, value)
+		return "$" + strconv.Itoa(len(values))
+	}
+	conditions := []string{balancesFieldAccountID + " = " + placeholder(accountID)}
+	if o.From != nil {
+		conditions = append(conditions, balancesFieldTime+" >= "+placeholder(*o.From))
+	}
+	if o.To != nil {
+		conditions = append(conditions, balancesFieldTime+" <= "+placeholder(*o.To))
+	}
+	order, comparison := "ASC", ">"
+	if o.Descending {
+		order, comparison = "DESC", "<"
+	}
+	if o.After != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s %s %s OR (%s = %s AND %s %s %s))",
+			balancesFieldTime, comparison, placeholder(o.After.Date),
+			balancesFieldTime, placeholder(o.After.Date),
+			balancesFieldID, comparison, placeholder(o.After.ID)))
+	}
+	query := fmt.Sprintf(
+		"%s%s ORDER BY %s %s, %s %s",
+		balancesSelectPrefix,
+		strings.Join(conditions, " AND "),
+		balancesFieldTime, order,
+		balancesFieldID, order)
+	if o.Limit != 0 {
+		query += " LIMIT " + placeholder(o.Limit)
+	}
+	return query + ";", values
 }
 
 // SelectBalanceByAccountAndID selects a balance with a given ID within a given
@@ -82,32 +198,78 @@ func (pg postgres) SelectBalanceByAccountAndID(a storage.Account, balanceID uint
 }
 
 func (pg postgres) InsertBalance(a storage.Account, b balance.Balance) (*storage.Balance, error) {
+	return pg.InsertBalanceContext(context.Background(), a, b)
+}
+
+// InsertBalanceContext is the same as InsertBalance but can be cancelled with
+// the given context.Context.
+func (pg postgres) InsertBalanceContext(ctx context.Context, a storage.Account, b balance.Balance) (*storage.Balance, error) {
+	b = storage.DateBalance(b)
 	err := a.Account.ValidateBalance(b)
 	if err != nil {
 		return nil, errors.Wrap(err, "validating balance")
 	}
-	dbb, err := queryBalance(pg.db, balancesInsertBalance, a.ID, b.Date, b.Amount)
+	dbb, err := queryBalance(ctx, pg.db, balancesInsertBalance, a.ID, date.FromTime(b.Date), b.Amount)
 	return dbb, errors.Wrap(err, "querying balance")
 }
 
-// queryBalance returns an error if more than one result is returned from the query
-// queryBalance may or may not return an error if zero results are returned.
-func queryBalance(db *sql.DB, queryString string, values ...interface{}) (*storage.Balance, error) {
-	bs, err := queryBalances(db, queryString, values...)
+// UpdateBalance updates a Balance of the given Account to reflect the details
+// of some other balance data. The updates will be validated against the
+// Account to ensure that the updated Balance would be logically sound.
+func (pg postgres) UpdateBalance(a storage.Account, b *storage.Balance, us balance.Balance) (*storage.Balance, error) {
+	return pg.UpdateBalanceContext(context.Background(), a, b, us)
+}
+
+// UpdateBalanceContext is the same as UpdateBalance but can be cancelled with
+// the given context.Context.
+func (pg postgres) UpdateBalanceContext(ctx context.Context, a storage.Account, b *storage.Balance, us balance.Balance) (*storage.Balance, error) {
+	us = storage.DateBalance(us)
+	err := a.Account.ValidateBalance(us)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating balance updates")
+	}
+	dbb, err := queryBalance(ctx, pg.db, balancesUpdateBalance, date.FromTime(us.Date), us.Amount, b.ID, a.ID)
+	return dbb, errors.Wrap(err, "querying balance")
+}
+
+// DeleteBalance deletes a Balance of the given Account. An error will be
+// returned if the Balance does not exist for the Account.
+func (pg postgres) DeleteBalance(a storage.Account, b *storage.Balance) error {
+	return pg.DeleteBalanceContext(context.Background(), a, b)
+}
+
+// DeleteBalanceContext is the same as DeleteBalance but can be cancelled with
+// the given context.Context.
+func (pg postgres) DeleteBalanceContext(ctx context.Context, a storage.Account, b *storage.Balance) error {
+	r, err := pg.db.ExecContext(ctx, balancesDeleteBalance, b.ID, a.ID)
+	if err != nil {
+		return errors.Wrap(err, "executing query")
+	}
+	n, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "getting number of rows affected")
+	}
+	if n != 1 {
+		return fmt.Errorf("expected 1 affected row but got %d", n)
+	}
+	return nil
+}
+
+// queryBalance returns an error if anything other than a single result is
+// returned from the query.
+func queryBalance(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Balance, error) {
+	bs, err := queryBalances(ctx, db, queryString, values...)
 	if err != nil {
 		return nil, errors.Wrap(err, "querying balances")
 	}
-	var b *storage.Balance
-	if len(*bs) > 1 {
-		err = errors.New("query returned more than 1 result")
-	} else if bs != nil {
-		b = &(*bs)[0]
+	if len(*bs) != 1 {
+		return nil, fmt.Errorf("expected 1 balance but query returned %d", len(*bs))
 	}
-	return b, err
+	return &(*bs)[0], nil
 }
 
-func queryBalances(db *sql.DB, queryString string, values ...interface{}) (*storage.Balances, error) {
-	rows, err := db.Query(queryString, values...)
+func queryBalances(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Balances, error) {
+	rows, err := db.QueryContext(ctx, queryString, values...)
 	if err != nil {
 		return nil, errors.Wrap(err, "querying db")
 	}
@@ -121,14 +283,14 @@ func scanRowsForBalances(rows *sql.Rows) (bs *storage.Balances, err error) {
 	bs = &storage.Balances{}
 	for rows.Next() {
 		var ID uint
-		var date time.Time
+		var d date.Date
 		var amount float64
-		err = rows.Scan(&ID, &date, &amount)
+		err = rows.Scan(&ID, &d, &amount)
 		if err != nil {
 			return nil, errors.Wrap(err, "scanning rows")
 		}
 		var innerB *balance.Balance
-		innerB, err = balance.New(date, balance.Amount(int(amount)))
+		innerB, err = balance.New(d.Time(), balance.Amount(int(amount)))
 		if err != nil {
 			return nil, errors.Wrap(err, "creating new balance from scan results")
 		}
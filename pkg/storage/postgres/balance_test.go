@@ -3,6 +3,7 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -54,7 +55,7 @@ func TestPostgres_InsertBalance_selectBalanceByID(t *testing.T) {
 
 // TODO: Add selectBalanceByID to features
 func (pg postgres) selectBalanceByID(id uint) (*storage.Balance, error) {
-	b, err := queryBalance(pg.db, fmt.Sprintf(
+	b, err := queryBalance(context.Background(), pg.db, fmt.Sprintf(
 		`%s%s = $1;`,
 		balancesSelectPrefix,
 		balancesFieldID), id)
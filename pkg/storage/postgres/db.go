@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
@@ -21,11 +22,22 @@ func New(connectionString string) (*postgres, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "opening connection to backend")
 	}
-	return &postgres{db: db}, nil
+	return &postgres{conn: db, db: db}, nil
 }
 
 type postgres struct {
-	db *sql.DB
+	// conn is the pool of connections to the database
+	conn *sql.DB
+	// db is used for every query, and is either conn or a transaction on conn
+	db queryer
+}
+
+// queryer is the part of a *sql.DB or a *sql.Tx that is used to query the
+// database
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 // NewConnectionString creates a new connection string for the postgres db
@@ -77,8 +89,8 @@ func (w *failSafeWriter) writef(format string, args ...interface{}) {
 	_, w.error = w.Writer.Write(bs)
 }
 
-// CreateStorage will create all the necessary tables to use postgres as a
-// backend.
+// CreateStorage will create the database and migrate it to the latest schema
+// version so that it can be used as a backend.
 func CreateStorage(host, user, dbname, sslmode string) error {
 	adminConnect, err := NewConnectionString(host, user, "", sslmode)
 	if err != nil {
@@ -92,11 +104,12 @@ func CreateStorage(host, user, dbname, sslmode string) error {
 	if err != nil {
 		return errors.Wrap(err, "creating database")
 	}
-	err = createAccountsTable(userConnect)
+	pg, err := New(userConnect)
 	if err != nil {
-		return errors.Wrap(err, "creating accounts table")
+		return errors.Wrap(err, "opening connection to created database")
 	}
-	return errors.Wrap(createBalancesTable(userConnect), "creating balances table")
+	defer nonReturningClose(pg, "Storage")
+	return errors.Wrap(pg.MigrateUp(LatestSchemaVersion()), "migrating schema")
 }
 
 // TODO: functional tests
@@ -133,42 +146,6 @@ func createDatabase(connection, name, owner string) error {
 	return errors.Wrap(err, "executing create database query")
 }
 
-func createAccountsTable(connection string) error {
-	db, err := open(connection)
-	if err != nil {
-		return errors.Wrap(err, "opening DB connection")
-	}
-	defer nonReturningCloseDB(db)
-	_, err = db.Exec(`CREATE TABLE accounts (
-	id SERIAL PRIMARY KEY,
-	name varchar(100) NOT NULL,
-	currency char(3) NOT NULL,
-	opened timestamp with time zone NOT NULL,
-	closed timestamp with time zone,
-	deleted timestamp with time zone);`)
-	return err
-}
-
-func createBalancesTable(connection string) error {
-	db, err := open(connection)
-	if err != nil {
-		return errors.Wrap(err, "opening DB connection")
-	}
-	query := fmt.Sprintf(`CREATE TABLE %s (
-	%s SERIAL PRIMARY KEY,
-	%s integer NOT NULL,
-	%s timestamp with time zone NOT NULL,
-	%s bigint NOT NULL);`,
-		balancesTable,
-		balancesFieldID,
-		balancesFieldAccountID,
-		balancesFieldTime,
-		balancesFieldAmount)
-	defer nonReturningCloseDB(db)
-	_, err = db.Exec(query)
-	return errors.Wrap(err, "executing create Balances query")
-}
-
 // DeleteStorage deletes the database used for the backend.
 func DeleteStorage(host, user, name, sslmode string) error {
 	if len(strings.TrimSpace(name)) == 0 {
@@ -194,11 +171,17 @@ func open(connectionString string) (*sql.DB, error) {
 
 // Available returns true if the Storage is available
 func (pg *postgres) Available() bool {
-	return pg.db.Ping() == nil // Ping() returns an error if db  is unavailable
+	return pg.AvailableContext(context.Background())
+}
+
+// AvailableContext is the same as Available but can be cancelled with the
+// given context.Context.
+func (pg *postgres) AvailableContext(ctx context.Context) bool {
+	return pg.conn.PingContext(ctx) == nil // PingContext() returns an error if db  is unavailable
 }
 
 func (pg postgres) Close() error {
-	return pg.db.Close()
+	return pg.conn.Close()
 }
 
 func nonReturningClose(c io.Closer, name string) {
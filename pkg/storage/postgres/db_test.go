@@ -20,6 +20,14 @@ const (
 	ssl        = "disable"
 )
 
+// ensure that a postgres can be used as a storage.ContextStorage, a
+// storage.UserStorage and a storage.SchemaStorage
+var (
+	_ storage.ContextStorage = &postgres{}
+	_ storage.UserStorage    = &postgres{}
+	_ storage.SchemaStorage  = &postgres{}
+)
+
 func TestNewConnectionString(t *testing.T) {
 	c, err := NewConnectionString("localhost", "user", "dbname", "disable")
 	assert.Nil(t, err)
@@ -0,0 +1,373 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	schemaVersionTable            = "schema_version"
+	schemaVersionFieldVersion     = "version"
+	schemaVersionFieldDescription = "description"
+	schemaVersionFieldApplied     = "applied"
+)
+
+var (
+	schemaVersionCreateTable = fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s integer PRIMARY KEY,
+	%s text NOT NULL,
+	%s timestamp with time zone NOT NULL DEFAULT now());`,
+		schemaVersionTable,
+		schemaVersionFieldVersion,
+		schemaVersionFieldDescription,
+		schemaVersionFieldApplied)
+
+	schemaVersionTableExists = fmt.Sprintf(
+		`SELECT to_regclass('%s') IS NOT NULL;`,
+		schemaVersionTable)
+
+	schemaVersionSelectVersions = fmt.Sprintf(
+		`SELECT %s FROM %s ORDER BY %s ASC;`,
+		schemaVersionFieldVersion,
+		schemaVersionTable,
+		schemaVersionFieldVersion)
+
+	schemaVersionInsertVersion = fmt.Sprintf(
+		`INSERT INTO %s (%s, %s) VALUES ($1, $2);`,
+		schemaVersionTable,
+		schemaVersionFieldVersion,
+		schemaVersionFieldDescription)
+
+	schemaVersionDeleteVersion = fmt.Sprintf(
+		`DELETE FROM %s WHERE %s = $1;`,
+		schemaVersionTable,
+		schemaVersionFieldVersion)
+)
+
+// migration is a single change to the schema of a postgres Storage, holding
+// the statements to both apply and revert the change.
+type migration struct {
+	description string
+	up, down    string
+}
+
+// migrations holds every migration of the schema in the order that they are
+// applied. The version of a migration is its position in the list, starting
+// at 1, so new migrations must only ever be appended to the end of the list.
+var migrations = []migration{
+	{
+		description: "create accounts and balances tables",
+		// IF NOT EXISTS is used so that databases that were created before
+		// migrations existed can be brought under version control.
+		up: fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s SERIAL PRIMARY KEY,
+	%s varchar(100) NOT NULL,
+	%s char(3) NOT NULL,
+	%s timestamp with time zone NOT NULL,
+	%s timestamp with time zone,
+	%s timestamp with time zone);
+CREATE TABLE IF NOT EXISTS %s (
+	%s SERIAL PRIMARY KEY,
+	%s integer NOT NULL,
+	%s timestamp with time zone NOT NULL,
+	%s bigint NOT NULL);`,
+			table,
+			fieldID,
+			fieldName,
+			fieldCurrency,
+			fieldOpened,
+			fieldClosed,
+			fieldDeleted,
+			balancesTable,
+			balancesFieldID,
+			balancesFieldAccountID,
+			balancesFieldTime,
+			balancesFieldAmount),
+		down: fmt.Sprintf(`DROP TABLE %s; DROP TABLE %s;`, balancesTable, table),
+	},
+	{
+		description: "convert account and balance timestamps to dates",
+		// Timestamps are converted to the date that they fall on in UTC, which
+		// is the location that dates given to moncli were parsed in.
+		up: fmt.Sprintf(`ALTER TABLE %[1]s
+	ALTER COLUMN %[2]s TYPE date USING (%[2]s AT TIME ZONE 'UTC')::date,
+	ALTER COLUMN %[3]s TYPE date USING (%[3]s AT TIME ZONE 'UTC')::date;
+ALTER TABLE %[4]s
+	ALTER COLUMN %[5]s TYPE date USING (%[5]s AT TIME ZONE 'UTC')::date;`,
+			table,
+			fieldOpened,
+			fieldClosed,
+			balancesTable,
+			balancesFieldTime),
+		down: fmt.Sprintf(`ALTER TABLE %[1]s
+	ALTER COLUMN %[2]s TYPE timestamp with time zone USING %[2]s::timestamp AT TIME ZONE 'UTC',
+	ALTER COLUMN %[3]s TYPE timestamp with time zone USING %[3]s::timestamp AT TIME ZONE 'UTC';
+ALTER TABLE %[4]s
+	ALTER COLUMN %[5]s TYPE timestamp with time zone USING %[5]s::timestamp AT TIME ZONE 'UTC';`,
+			table,
+			fieldOpened,
+			fieldClosed,
+			balancesTable,
+			balancesFieldTime),
+	},
+	{
+		description: "create transactions table",
+		up: fmt.Sprintf(`CREATE TABLE %s (
+	%s SERIAL PRIMARY KEY,
+	%s date NOT NULL,
+	%s bigint NOT NULL,
+	%s text NOT NULL,
+	%s integer NOT NULL REFERENCES %s (%s),
+	%s integer NOT NULL REFERENCES %s (%s));`,
+			transactionsTable,
+			transactionsFieldID,
+			transactionsFieldDate,
+			transactionsFieldAmount,
+			transactionsFieldDescription,
+			transactionsFieldSourceID, table, fieldID,
+			transactionsFieldDestinationID, table, fieldID),
+		down: fmt.Sprintf(`DROP TABLE %s;`, transactionsTable),
+	},
+	{
+		description: "create rates table",
+		up: fmt.Sprintf(`CREATE TABLE %s (
+	%s SERIAL PRIMARY KEY,
+	%s date NOT NULL,
+	%s char(3) NOT NULL,
+	%s char(3) NOT NULL,
+	%s double precision NOT NULL);`,
+			ratesTable,
+			ratesFieldID,
+			ratesFieldDate,
+			ratesFieldFrom,
+			ratesFieldTo,
+			ratesFieldRate),
+		down: fmt.Sprintf(`DROP TABLE %s;`, ratesTable),
+	},
+	{
+		description: "create tokens table",
+		up: fmt.Sprintf(`CREATE TABLE %s (
+	%s SERIAL PRIMARY KEY,
+	%s varchar(100) NOT NULL,
+	%s char(64) NOT NULL UNIQUE,
+	%s timestamp with time zone NOT NULL,
+	%s timestamp with time zone);`,
+			tokensTable,
+			tokensFieldID,
+			tokensFieldName,
+			tokensFieldHash,
+			tokensFieldCreated,
+			tokensFieldRevoked),
+		down: fmt.Sprintf(`DROP TABLE %s;`, tokensTable),
+	},
+	{
+		description: "create users and account access tables",
+		up: fmt.Sprintf(`CREATE TABLE %[1]s (
+	%[2]s SERIAL PRIMARY KEY,
+	%[3]s varchar(100) NOT NULL UNIQUE);
+ALTER TABLE %[4]s ADD COLUMN %[5]s integer REFERENCES %[1]s (%[2]s);
+CREATE TABLE %[6]s (
+	%[7]s integer NOT NULL REFERENCES %[10]s (%[11]s),
+	%[8]s integer NOT NULL REFERENCES %[1]s (%[2]s),
+	%[9]s varchar(5) NOT NULL,
+	PRIMARY KEY (%[7]s, %[8]s));`,
+			usersTable,
+			usersFieldID,
+			usersFieldName,
+			tokensTable,
+			tokensFieldUserID,
+			accessTable,
+			accessFieldAccountID,
+			accessFieldUserID,
+			accessFieldAccess,
+			table,
+			fieldID),
+		down: fmt.Sprintf(`DROP TABLE %s; ALTER TABLE %s DROP COLUMN %s; DROP TABLE %s;`,
+			accessTable,
+			tokensTable,
+			tokensFieldUserID,
+			usersTable),
+	},
+}
+
+// LatestSchemaVersion returns the version of the schema that results from
+// applying every known migration.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// MigrationStatus describes a migration and whether it has been applied to a
+// Storage.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	Applied     bool
+}
+
+// SchemaVersion returns the version of the schema of the Storage, which is 0
+// if no migrations have been applied.
+func (pg postgres) SchemaVersion() (int, error) {
+	return pg.schemaVersion(context.Background())
+}
+
+func (pg postgres) schemaVersion(ctx context.Context) (int, error) {
+	vs, err := appliedVersions(ctx, pg.conn)
+	if err != nil {
+		return 0, errors.Wrap(err, "selecting applied versions")
+	}
+	if len(vs) == 0 {
+		return 0, nil
+	}
+	return vs[len(vs)-1], nil
+}
+
+// MigrationStatuses returns the status of every known migration for the
+// Storage, in the order that they are applied.
+func (pg postgres) MigrationStatuses() ([]MigrationStatus, error) {
+	v, err := pg.SchemaVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting schema version")
+	}
+	ss := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		ss[i] = MigrationStatus{
+			Version:     i + 1,
+			Description: m.description,
+			Applied:     i+1 <= v,
+		}
+	}
+	return ss, nil
+}
+
+// CheckSchemaVersion returns an error if the schema of the Storage is not at
+// the latest version.
+func (pg postgres) CheckSchemaVersion() error {
+	return pg.CheckSchemaVersionContext(context.Background())
+}
+
+// CheckSchemaVersionContext is the same as CheckSchemaVersion but can be
+// cancelled with the given context.Context.
+func (pg postgres) CheckSchemaVersionContext(ctx context.Context) error {
+	v, err := pg.schemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting schema version")
+	}
+	if latest := LatestSchemaVersion(); v != latest {
+		return fmt.Errorf("schema is at version %d but version %d is required, the schema must be migrated", v, latest)
+	}
+	return nil
+}
+
+// MigrateUp applies, in order, each migration that has not yet been applied
+// up to and including the migration with the given version.
+func (pg postgres) MigrateUp(to int) error {
+	if to < 0 || to > LatestSchemaVersion() {
+		return fmt.Errorf("version %d is out of range 0 to %d", to, LatestSchemaVersion())
+	}
+	ctx := context.Background()
+	_, err := pg.conn.ExecContext(ctx, schemaVersionCreateTable)
+	if err != nil {
+		return errors.Wrap(err, "creating schema version table")
+	}
+	from, err := pg.schemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting schema version")
+	}
+	if to < from {
+		return fmt.Errorf("cannot migrate up to version %d from version %d", to, from)
+	}
+	for v := from + 1; v <= to; v++ {
+		m := migrations[v-1]
+		err := inTransaction(ctx, pg.conn, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.up)
+			if err != nil {
+				return errors.Wrap(err, "executing migration")
+			}
+			_, err = tx.ExecContext(ctx, schemaVersionInsertVersion, v, m.description)
+			return errors.Wrap(err, "recording schema version")
+		})
+		if err != nil {
+			return errors.Wrapf(err, "applying migration %d (%s)", v, m.description)
+		}
+	}
+	return nil
+}
+
+// MigrateDown reverts, in reverse order, each applied migration with a
+// version greater than the given version.
+func (pg postgres) MigrateDown(to int) error {
+	if to < 0 || to > LatestSchemaVersion() {
+		return fmt.Errorf("version %d is out of range 0 to %d", to, LatestSchemaVersion())
+	}
+	ctx := context.Background()
+	from, err := pg.schemaVersion(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting schema version")
+	}
+	if to > from {
+		return fmt.Errorf("cannot migrate down to version %d from version %d", to, from)
+	}
+	for v := from; v > to; v-- {
+		m := migrations[v-1]
+		err := inTransaction(ctx, pg.conn, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, m.down)
+			if err != nil {
+				return errors.Wrap(err, "executing migration")
+			}
+			_, err = tx.ExecContext(ctx, schemaVersionDeleteVersion, v)
+			return errors.Wrap(err, "removing schema version")
+		})
+		if err != nil {
+			return errors.Wrapf(err, "reverting migration %d (%s)", v, m.description)
+		}
+	}
+	return nil
+}
+
+// appliedVersions returns the versions of all of the migrations that have
+// been applied to the db, in ascending order.
+func appliedVersions(ctx context.Context, db *sql.DB) ([]int, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, schemaVersionTableExists).Scan(&exists)
+	if err != nil {
+		return nil, errors.Wrap(err, "checking for schema version table")
+	}
+	if !exists {
+		return nil, nil
+	}
+	rows, err := db.QueryContext(ctx, schemaVersionSelectVersions)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	var vs []int
+	for rows.Next() {
+		var v int
+		err := rows.Scan(&v)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning row")
+		}
+		vs = append(vs, v)
+	}
+	return vs, errors.Wrap(rows.Err(), "rows error")
+}
+
+// inTransaction runs fn within a transaction, committing the transaction if
+// fn returns nil and rolling it back otherwise.
+func inTransaction(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	err = fn(tx)
+	if err != nil {
+		if rErr := tx.Rollback(); rErr != nil {
+			return errors.Wrapf(err, "rolling back transaction failed with error %v", rErr)
+		}
+		return err
+	}
+	return errors.Wrap(tx.Commit(), "committing transaction")
+}
@@ -0,0 +1,58 @@
+// +build integration
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgres_migrations(t *testing.T) {
+	store := createTestDB(t)
+	defer deleteTestDB(t)
+	defer nonReturningCloseStorage(store)
+	pg := store.(*postgres)
+
+	t.Run("created storage is at latest version", func(t *testing.T) {
+		assert.NoError(t, pg.CheckSchemaVersion())
+		ss, err := pg.MigrationStatuses()
+		common.FatalIfError(t, err, "getting migration statuses")
+		assert.Len(t, ss, LatestSchemaVersion())
+		for _, s := range ss {
+			assert.True(t, s.Applied, "migration %+v", s)
+		}
+	})
+
+	t.Run("migrating up beyond latest version", func(t *testing.T) {
+		assert.Error(t, pg.MigrateUp(LatestSchemaVersion()+1))
+	})
+
+	t.Run("down to zero then up to latest", func(t *testing.T) {
+		common.FatalIfError(t, pg.MigrateDown(0), "migrating down")
+		v, err := pg.SchemaVersion()
+		common.FatalIfError(t, err, "getting schema version")
+		assert.Equal(t, 0, v)
+		assert.Error(t, pg.CheckSchemaVersion())
+		_, err = pg.SelectAccounts()
+		assert.Error(t, err, "accounts table should not exist")
+		_, err = pg.SelectTransactions()
+		assert.Error(t, err, "transactions table should not exist")
+		_, err = pg.SelectRates()
+		assert.Error(t, err, "rates table should not exist")
+
+		common.FatalIfError(t, pg.MigrateUp(LatestSchemaVersion()), "migrating up")
+		assert.NoError(t, pg.CheckSchemaVersion())
+		_, err = pg.SelectAccounts()
+		assert.NoError(t, err)
+		_, err = pg.SelectTransactions()
+		assert.NoError(t, err)
+		_, err = pg.SelectRates()
+		assert.NoError(t, err)
+	})
+
+	t.Run("migrating down to a version above current", func(t *testing.T) {
+		assert.Error(t, pg.MigrateDown(LatestSchemaVersion()+1))
+	})
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	ratesFieldID   = "id"
+	ratesFieldDate = "date"
+	ratesFieldFrom = "from_currency"
+	ratesFieldTo   = "to_currency"
+	ratesFieldRate = "rate"
+	ratesTable     = "rates"
+)
+
+var (
+	ratesSelectFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s",
+		ratesFieldID,
+		ratesFieldDate,
+		ratesFieldFrom,
+		ratesFieldTo,
+		ratesFieldRate)
+
+	ratesSelectRates = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC, %s ASC;",
+		ratesSelectFields,
+		ratesTable,
+		ratesFieldDate,
+		ratesFieldID)
+
+	ratesInsertFields = fmt.Sprintf(
+		"%s, %s, %s, %s",
+		ratesFieldDate,
+		ratesFieldFrom,
+		ratesFieldTo,
+		ratesFieldRate)
+
+	ratesInsertRate = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES ($1, $2, $3, $4) RETURNING %s;`,
+		ratesTable,
+		ratesInsertFields,
+		ratesSelectFields)
+)
+
+// InsertRate validates a Rate and, if valid, inserts it in the storage
+// backend and returns it. The ID of the given Rate is ignored.
+func (pg postgres) InsertRate(r storage.Rate) (*storage.Rate, error) {
+	return pg.InsertRateContext(context.Background(), r)
+}
+
+// InsertRateContext is the same as InsertRate but can be cancelled with the
+// given context.Context.
+func (pg postgres) InsertRateContext(ctx context.Context, r storage.Rate) (*storage.Rate, error) {
+	err := storage.ValidateRate(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating rate")
+	}
+	rs, err := queryRates(ctx, pg.db, ratesInsertRate, r.Date, r.From, r.To, r.Rate)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying rates")
+	}
+	if len(*rs) != 1 {
+		return nil, fmt.Errorf("expected 1 rate but query returned %d", len(*rs))
+	}
+	return &(*rs)[0], nil
+}
+
+// SelectRates returns all Rates, sorted by chronological order then by the id
+// of the Rate in the DB.
+func (pg postgres) SelectRates() (*storage.Rates, error) {
+	return pg.SelectRatesContext(context.Background())
+}
+
+// SelectRatesContext is the same as SelectRates but can be cancelled with the
+// given context.Context.
+func (pg postgres) SelectRatesContext(ctx context.Context) (*storage.Rates, error) {
+	return queryRates(ctx, pg.db, ratesSelectRates)
+}
+
+func queryRates(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Rates, error) {
+	rows, err := db.QueryContext(ctx, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	return scanRowsForRates(rows)
+}
+
+// scanRowsForRates scans a sql.Rows for a Rates object and returns any error
+// occurring along the way.
+func scanRowsForRates(rows *sql.Rows) (*storage.Rates, error) {
+	rs := &storage.Rates{}
+	for rows.Next() {
+		var r storage.Rate
+		err := rows.Scan(&r.ID, &r.Date, &r.From, &r.To, &r.Rate)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		*rs = append(*rs, r)
+	}
+	return rs, errors.Wrap(rows.Err(), "rows error")
+}
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	transactionsFieldID            = "id"
+	transactionsFieldDate          = "date"
+	transactionsFieldAmount        = "amount"
+	transactionsFieldDescription   = "description"
+	transactionsFieldSourceID      = "source_account_id"
+	transactionsFieldDestinationID = "destination_account_id"
+	transactionsTable              = "transactions"
+)
+
+var (
+	transactionsSelectFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s, %s",
+		transactionsFieldID,
+		transactionsFieldDate,
+		transactionsFieldAmount,
+		transactionsFieldDescription,
+		transactionsFieldSourceID,
+		transactionsFieldDestinationID)
+
+	transactionsOrderBy = fmt.Sprintf(
+		"ORDER BY %s ASC, %s ASC",
+		transactionsFieldDate,
+		transactionsFieldID)
+
+	transactionsSelectTransactions = fmt.Sprintf(
+		"SELECT %s FROM %s %s;",
+		transactionsSelectFields,
+		transactionsTable,
+		transactionsOrderBy)
+
+	transactionsSelectTransactionsForAccountID = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = $1 OR %s = $1 %s;",
+		transactionsSelectFields,
+		transactionsTable,
+		transactionsFieldSourceID,
+		transactionsFieldDestinationID,
+		transactionsOrderBy)
+
+	transactionsInsertFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s",
+		transactionsFieldDate,
+		transactionsFieldAmount,
+		transactionsFieldDescription,
+		transactionsFieldSourceID,
+		transactionsFieldDestinationID)
+
+	transactionsInsertTransaction = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5) RETURNING %s;`,
+		transactionsTable,
+		transactionsInsertFields,
+		transactionsSelectFields)
+)
+
+// InsertTransaction validates a Transaction against its source and
+// destination Accounts and, if valid, inserts it in the storage backend and
+// returns it. The ID of the given Transaction is ignored.
+func (pg postgres) InsertTransaction(t storage.Transaction) (*storage.Transaction, error) {
+	return pg.InsertTransactionContext(context.Background(), t)
+}
+
+// InsertTransactionContext is the same as InsertTransaction but can be
+// cancelled with the given context.Context.
+func (pg postgres) InsertTransactionContext(ctx context.Context, t storage.Transaction) (*storage.Transaction, error) {
+	source, err := pg.SelectAccountContext(ctx, t.SourceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting source account")
+	}
+	destination, err := pg.SelectAccountContext(ctx, t.DestinationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting destination account")
+	}
+	err = storage.ValidateTransaction(t, *source, *destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating transaction")
+	}
+	ts, err := queryTransactions(
+		ctx,
+		pg.db,
+		transactionsInsertTransaction,
+		t.Date,
+		t.Amount,
+		t.Description,
+		t.SourceID,
+		t.DestinationID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transactions")
+	}
+	if len(*ts) != 1 {
+		return nil, fmt.Errorf("expected 1 transaction but query returned %d", len(*ts))
+	}
+	return &(*ts)[0], nil
+}
+
+// SelectTransactions returns all Transactions, sorted by chronological order
+// then by the id of the Transaction in the DB.
+func (pg postgres) SelectTransactions() (*storage.Transactions, error) {
+	return pg.SelectTransactionsContext(context.Background())
+}
+
+// SelectTransactionsContext is the same as SelectTransactions but can be
+// cancelled with the given context.Context.
+func (pg postgres) SelectTransactionsContext(ctx context.Context) (*storage.Transactions, error) {
+	return queryTransactions(ctx, pg.db, transactionsSelectTransactions)
+}
+
+// SelectAccountTransactions returns all Transactions that the given Account
+// is either the source or destination of, sorted by chronological order then
+// by the id of the Transaction in the DB.
+func (pg postgres) SelectAccountTransactions(a storage.Account) (*storage.Transactions, error) {
+	return pg.SelectAccountTransactionsContext(context.Background(), a)
+}
+
+// SelectAccountTransactionsContext is the same as SelectAccountTransactions
+// but can be cancelled with the given context.Context.
+func (pg postgres) SelectAccountTransactionsContext(ctx context.Context, a storage.Account) (*storage.Transactions, error) {
+	return queryTransactions(ctx, pg.db, transactionsSelectTransactionsForAccountID, a.ID)
+}
+
+func queryTransactions(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Transactions, error) {
+	rows, err := db.QueryContext(ctx, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	return scanRowsForTransactions(rows)
+}
+
+// scanRowsForTransactions scans a sql.Rows for a Transactions object and
+// returns any error occurring along the way.
+func scanRowsForTransactions(rows *sql.Rows) (*storage.Transactions, error) {
+	ts := &storage.Transactions{}
+	for rows.Next() {
+		var t storage.Transaction
+		err := rows.Scan(&t.ID, &t.Date, &t.Amount, &t.Description, &t.SourceID, &t.DestinationID)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		*ts = append(*ts, t)
+	}
+	return ts, errors.Wrap(rows.Err(), "rows error")
+}
@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	usersFieldID   = "id"
+	usersFieldName = "name"
+	usersTable     = "users"
+
+	accessFieldAccountID = "account_id"
+	accessFieldUserID    = "user_id"
+	accessFieldAccess    = "access"
+	accessTable          = "account_access"
+)
+
+var (
+	usersSelectFields = fmt.Sprintf("%s, %s", usersFieldID, usersFieldName)
+
+	usersSelectUsers = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC;",
+		usersSelectFields,
+		usersTable,
+		usersFieldID)
+
+	usersSelectUserByName = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = $1;",
+		usersSelectFields,
+		usersTable,
+		usersFieldName)
+
+	usersInsertUser = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES ($1) RETURNING %s;`,
+		usersTable,
+		usersFieldName,
+		usersSelectFields)
+
+	accessSetAccess = fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s) VALUES ($1, $2, $3)
+ON CONFLICT (%[2]s, %[3]s) DO UPDATE SET %[4]s = EXCLUDED.%[4]s;`,
+		accessTable,
+		accessFieldAccountID,
+		accessFieldUserID,
+		accessFieldAccess)
+
+	accessSelectUserAccess = fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE %s = $1;",
+		accessFieldAccountID,
+		accessFieldAccess,
+		accessTable,
+		accessFieldUserID)
+)
+
+// InsertUser inserts a new User with the given name, which must not already be
+// used by another User, and returns it.
+func (pg postgres) InsertUser(name string) (*storage.User, error) {
+	err := storage.ValidateUserName(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating user name")
+	}
+	u, err := queryUser(context.Background(), pg.db, usersInsertUser, name)
+	return u, errors.Wrap(err, "querying user")
+}
+
+// SelectUsers returns all Users, sorted by their id in the DB.
+func (pg postgres) SelectUsers() (*storage.Users, error) {
+	return queryUsers(context.Background(), pg.db, usersSelectUsers)
+}
+
+// SelectUserByName returns the User with the given name, or
+// storage.ErrNoUser if there is none.
+func (pg postgres) SelectUserByName(name string) (*storage.User, error) {
+	return queryUser(context.Background(), pg.db, usersSelectUserByName, name)
+}
+
+// SetAccountAccess gives a User an Access to an Account, replacing any Access
+// that the User already has to it. The Account and User are checked by the
+// foreign keys of the account access table.
+func (pg postgres) SetAccountAccess(accountID, userID uint, a storage.Access) error {
+	if _, err := storage.ParseAccess(string(a)); err != nil {
+		return errors.Wrap(err, "validating access")
+	}
+	if _, err := pg.SelectAccount(accountID); err != nil {
+		return errors.Wrap(err, "selecting account")
+	}
+	_, err := pg.db.ExecContext(context.Background(), accessSetAccess, accountID, userID, string(a))
+	return errors.Wrap(err, "executing query")
+}
+
+// SelectUserAccess returns the Access of a User to each Account that they
+// have access to, keyed by Account ID.
+func (pg postgres) SelectUserAccess(userID uint) (map[uint]storage.Access, error) {
+	rows, err := pg.db.QueryContext(context.Background(), accessSelectUserAccess, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	as := make(map[uint]storage.Access)
+	for rows.Next() {
+		var id uint
+		var a string
+		if err := rows.Scan(&id, &a); err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		as[id] = storage.Access(a)
+	}
+	return as, errors.Wrap(rows.Err(), "rows error")
+}
+
+// queryUser returns storage.ErrNoUser if the query returns no Users.
+func queryUser(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.User, error) {
+	us, err := queryUsers(ctx, db, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying users")
+	}
+	switch len(*us) {
+	case 0:
+		return nil, storage.ErrNoUser
+	case 1:
+		return &(*us)[0], nil
+	}
+	return nil, fmt.Errorf("expected 1 user but query returned %d", len(*us))
+}
+
+func queryUsers(ctx context.Context, db queryer, queryString string, values ...interface{}) (*storage.Users, error) {
+	rows, err := db.QueryContext(ctx, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	us := &storage.Users{}
+	for rows.Next() {
+		var u storage.User
+		if err := rows.Scan(&u.ID, &u.Name); err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		*us = append(*us, u)
+	}
+	return us, errors.Wrap(rows.Err(), "rows error")
+}
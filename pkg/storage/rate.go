@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/pkg/errors"
+)
+
+// Rate is the exchange rate between two currencies on a given date. An amount
+// of the From currency multiplied by the Rate gives the equivalent amount of
+// the To currency.
+type Rate struct {
+	ID   uint
+	Date date.Date
+	From string
+	To   string
+	Rate float64
+}
+
+// Equal returns true if two Rate items are logically identical
+func (r Rate) Equal(or Rate) bool {
+	return r.ID == or.ID &&
+		r.Date.Equal(or.Date) &&
+		r.From == or.From &&
+		r.To == or.To &&
+		r.Rate == or.Rate
+}
+
+// ValidateRate returns an error if the Rate is not between two different,
+// valid currency codes or if the Rate is not positive.
+func ValidateRate(r Rate) error {
+	if _, err := currency.NewCode(r.From); err != nil {
+		return errors.Wrap(err, "validating from currency")
+	}
+	if _, err := currency.NewCode(r.To); err != nil {
+		return errors.Wrap(err, "validating to currency")
+	}
+	if r.From == r.To {
+		return fmt.Errorf("from and to currencies must be different but both were %s", r.From)
+	}
+	if r.Rate <= 0 || math.IsInf(r.Rate, 0) || math.IsNaN(r.Rate) {
+		return fmt.Errorf("rate must be a positive number but was %v", r.Rate)
+	}
+	if r.Date.IsZero() {
+		return errors.New("rate must have a date")
+	}
+	return nil
+}
+
+// Rates holds multiple Rate items
+type Rates []Rate
+
+// At returns the rate to convert an amount of the from currency into the to
+// currency on the given date, using the Rate with the latest date that is on
+// or before the date. A Rate that is held in the opposite direction is
+// inverted. An error is returned if no such Rate exists.
+func (rs Rates) At(from, to string, d date.Date) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	var found bool
+	var latest Rate
+	for _, r := range rs {
+		if r.Date.After(d) || (found && r.Date.Before(latest.Date)) {
+			continue
+		}
+		switch {
+		case r.From == from && r.To == to:
+		case r.From == to && r.To == from:
+			r.Rate = 1 / r.Rate
+		default:
+			continue
+		}
+		latest, found = r, true
+	}
+	if !found {
+		return 0, fmt.Errorf("no rate from %s to %s on or before %s", from, to, d)
+	}
+	return latest.Rate, nil
+}
+
+// Convert converts an amount of the from currency into the to currency on
+// the given date, using the Rate given by At. The converted amount is rounded
+// to the nearest integer.
+func (rs Rates) Convert(amount int, from, to string, d date.Date) (int, error) {
+	r, err := rs.At(from, to, d)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(float64(amount) * r)), nil
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRate(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	for _, test := range []struct {
+		name string
+		Rate
+		valid bool
+	}{
+		{name: "valid", Rate: Rate{Date: d, From: "EUR", To: "GBP", Rate: 0.88}, valid: true},
+		{name: "invalid from", Rate: Rate{Date: d, From: "EU", To: "GBP", Rate: 0.88}},
+		{name: "invalid to", Rate: Rate{Date: d, From: "EUR", To: "GBPP", Rate: 0.88}},
+		{name: "same currencies", Rate: Rate{Date: d, From: "GBP", To: "GBP", Rate: 1}},
+		{name: "zero rate", Rate: Rate{Date: d, From: "EUR", To: "GBP"}},
+		{name: "negative rate", Rate: Rate{Date: d, From: "EUR", To: "GBP", Rate: -1}},
+		{name: "infinite rate", Rate: Rate{Date: d, From: "EUR", To: "GBP", Rate: math.Inf(1)}},
+		{name: "no date", Rate: Rate{From: "EUR", To: "GBP", Rate: 0.88}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateRate(test.Rate)
+			assert.Equal(t, test.valid, err == nil, "%v", err)
+		})
+	}
+}
+
+func TestRates_At(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	rs := Rates{
+		{Date: d, From: "EUR", To: "GBP", Rate: 0.8},
+		{Date: d.AddDays(10), From: "GBP", To: "EUR", Rate: 2},
+		{Date: d.AddDays(20), From: "EUR", To: "GBP", Rate: 0.9},
+		{Date: d.AddDays(5), From: "USD", To: "GBP", Rate: 0.75},
+	}
+	for _, test := range []struct {
+		name     string
+		from, to string
+		date.Date
+		expected float64
+		err      bool
+	}{
+		{name: "same currency", from: "JPY", to: "JPY", Date: d, expected: 1},
+		{name: "on date of rate", from: "EUR", to: "GBP", Date: d, expected: 0.8},
+		{name: "nearest earlier rate", from: "EUR", to: "GBP", Date: d.AddDays(9), expected: 0.8},
+		{name: "nearest earlier rate is inverted", from: "EUR", to: "GBP", Date: d.AddDays(15), expected: 0.5},
+		{name: "inverted", from: "GBP", to: "EUR", Date: d.AddDays(25), expected: 1 / 0.9},
+		{name: "before any rate", from: "EUR", to: "GBP", Date: d.AddDays(-1), err: true},
+		{name: "no rate for pair", from: "USD", to: "EUR", Date: d.AddDays(25), err: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := rs.At(test.from, test.to, test.Date)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+			assert.InDelta(t, test.expected, r, 1e-9)
+		})
+	}
+}
+
+func TestRates_Convert(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	rs := Rates{{Date: d, From: "EUR", To: "GBP", Rate: 0.885}}
+	c, err := rs.Convert(1000, "EUR", "GBP", d)
+	assert.NoError(t, err)
+	assert.Equal(t, 885, c)
+
+	c, err = rs.Convert(1000, "GBP", "EUR", d)
+	assert.NoError(t, err)
+	assert.Equal(t, 1130, c)
+
+	_, err = rs.Convert(1000, "GBP", "USD", d)
+	assert.Error(t, err)
+}
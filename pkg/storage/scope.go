@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/pkg/errors"
+)
+
+// ErrForbidden is the cause of the error returned when the User of an
+// operation can see an Account but does not have the Access to it that the
+// operation requires.
+var ErrForbidden = errors.New("access denied")
+
+// errUnscoped is returned by the methods of a user scoped ContextStorage that
+// do not take a context.Context, as they cannot be scoped to a User.
+var errUnscoped = errors.Wrap(ErrForbidden, "an operation on accounts without a context cannot be scoped to a user")
+
+// RequireAccess returns an error if the User held by the context.Context does
+// not have the required Access to an Account. An Account that the User has no
+// Access to is reported as not existing, and an Account that the User has too
+// little Access to gives an error caused by ErrForbidden. Any Access is
+// allowed when the context.Context holds no User.
+func RequireAccess(ctx context.Context, users UserStorage, accountID uint, required Access) error {
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	as, err := users.SelectUserAccess(userID)
+	if err != nil {
+		return errors.Wrap(err, "selecting user access")
+	}
+	return requireAccess(as, accountID, required)
+}
+
+func requireAccess(as map[uint]Access, accountID uint, required Access) error {
+	a, ok := as[accountID]
+	if !ok {
+		return errors.Wrapf(ErrNoAccount, "account with id %d", accountID)
+	}
+	if !a.Allows(required) {
+		return errors.Wrapf(ErrForbidden, "%s access to account %d is required", required, accountID)
+	}
+	return nil
+}
+
+// WithUserScope returns a ContextStorage whose context methods are scoped to
+// the User held by their context.Context. Accounts, and their Balances and
+// Transactions, are only selected when the User has access to them, changes
+// require the User to have the Access that they need, and inserted Accounts
+// are owned by the User. Operations are not scoped when their
+// context.Context holds no User, and Rates are shared by every User. As the
+// methods on Accounts that do not take a context.Context cannot be scoped,
+// they always return an error caused by ErrForbidden. The ContextStorage is
+// also a BalancesAtStorage when the given one is.
+func WithUserScope(s ContextStorage, users UserStorage) ContextStorage {
+	scope := userScope{ContextStorage: s, users: users}
+	if bs, ok := s.(BalancesAtStorage); ok {
+		return balancesAtUserScope{userScope: scope, balancesAt: bs}
+	}
+	return scope
+}
+
+type userScope struct {
+	ContextStorage
+	users UserStorage
+}
+
+type balancesAtUserScope struct {
+	userScope
+	balancesAt BalancesAtStorage
+}
+
+// access returns the Access of the User of the context.Context to each
+// Account, or nil if the context.Context holds no User.
+func (s userScope) access(ctx context.Context) (map[uint]Access, error) {
+	userID, ok := UserFromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	as, err := s.users.SelectUserAccess(userID)
+	return as, errors.Wrap(err, "selecting user access")
+}
+
+func (s userScope) require(ctx context.Context, accountID uint, required Access) error {
+	return RequireAccess(ctx, s.users, accountID, required)
+}
+
+func (s userScope) InsertAccountContext(ctx context.Context, a account.Account) (*Account, error) {
+	inserted, err := s.ContextStorage.InsertAccountContext(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	if userID, ok := UserFromContext(ctx); ok {
+		err = s.users.SetAccountAccess(inserted.ID, userID, AccessOwner)
+		if err != nil {
+			return nil, errors.Wrapf(err, "setting owner of account %d", inserted.ID)
+		}
+	}
+	return inserted, nil
+}
+
+func (s userScope) SelectAccountContext(ctx context.Context, id uint) (*Account, error) {
+	if err := s.require(ctx, id, AccessRead); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.SelectAccountContext(ctx, id)
+}
+
+func (s userScope) UpdateAccountContext(ctx context.Context, a *Account, updates *account.Account) (*Account, error) {
+	if err := s.require(ctx, a.ID, AccessWrite); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.UpdateAccountContext(ctx, a, updates)
+}
+
+func (s userScope) SelectAccountsContext(ctx context.Context) (*Accounts, error) {
+	as, err := s.access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.ContextStorage.SelectAccountsContext(ctx)
+	if err != nil || as == nil {
+		return accounts, err
+	}
+	visible := Accounts{}
+	for _, a := range *accounts {
+		if _, ok := as[a.ID]; ok {
+			visible = append(visible, a)
+		}
+	}
+	return &visible, nil
+}
+
+func (s userScope) DeleteAccountContext(ctx context.Context, id uint) error {
+	if err := s.require(ctx, id, AccessOwner); err != nil {
+		return err
+	}
+	return s.ContextStorage.DeleteAccountContext(ctx, id)
+}
+
+func (s userScope) InsertBalanceContext(ctx context.Context, a Account, b balance.Balance) (*Balance, error) {
+	if err := s.require(ctx, a.ID, AccessWrite); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.InsertBalanceContext(ctx, a, b)
+}
+
+func (s userScope) SelectAccountBalancesContext(ctx context.Context, a Account) (*Balances, error) {
+	if err := s.require(ctx, a.ID, AccessRead); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.SelectAccountBalancesContext(ctx, a)
+}
+
+func (s userScope) SelectAccountBalancesWithOptionsContext(ctx context.Context, a Account, o BalancesOptions) (*Balances, error) {
+	if err := s.require(ctx, a.ID, AccessRead); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.SelectAccountBalancesWithOptionsContext(ctx, a, o)
+}
+
+func (s userScope) UpdateBalanceContext(ctx context.Context, a Account, b *Balance, us balance.Balance) (*Balance, error) {
+	if err := s.require(ctx, a.ID, AccessWrite); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.UpdateBalanceContext(ctx, a, b, us)
+}
+
+func (s userScope) DeleteBalanceContext(ctx context.Context, a Account, b *Balance) error {
+	if err := s.require(ctx, a.ID, AccessWrite); err != nil {
+		return err
+	}
+	return s.ContextStorage.DeleteBalanceContext(ctx, a, b)
+}
+
+// InsertTransactionContext requires write access to both of the Accounts of
+// the Transaction, as it changes the balance of each of them.
+func (s userScope) InsertTransactionContext(ctx context.Context, t Transaction) (*Transaction, error) {
+	as, err := s.access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if as != nil {
+		for _, id := range []uint{t.SourceID, t.DestinationID} {
+			if err := requireAccess(as, id, AccessWrite); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.ContextStorage.InsertTransactionContext(ctx, t)
+}
+
+// SelectTransactionsContext selects the Transactions that the User has access
+// to either of the Accounts of.
+func (s userScope) SelectTransactionsContext(ctx context.Context) (*Transactions, error) {
+	as, err := s.access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.ContextStorage.SelectTransactionsContext(ctx)
+	if err != nil || as == nil {
+		return ts, err
+	}
+	visible := Transactions{}
+	for _, t := range *ts {
+		_, source := as[t.SourceID]
+		_, destination := as[t.DestinationID]
+		if source || destination {
+			visible = append(visible, t)
+		}
+	}
+	return &visible, nil
+}
+
+func (s userScope) SelectAccountTransactionsContext(ctx context.Context, a Account) (*Transactions, error) {
+	if err := s.require(ctx, a.ID, AccessRead); err != nil {
+		return nil, err
+	}
+	return s.ContextStorage.SelectAccountTransactionsContext(ctx, a)
+}
+
+func (s userScope) InsertAccount(account.Account) (*Account, error) { return nil, errUnscoped }
+
+func (s userScope) SelectAccount(uint) (*Account, error) { return nil, errUnscoped }
+
+func (s userScope) UpdateAccount(*Account, *account.Account) (*Account, error) {
+	return nil, errUnscoped
+}
+
+func (s userScope) SelectAccounts() (*Accounts, error) { return nil, errUnscoped }
+
+func (s userScope) DeleteAccount(uint) error { return errUnscoped }
+
+func (s userScope) InsertBalance(Account, balance.Balance) (*Balance, error) {
+	return nil, errUnscoped
+}
+
+func (s userScope) SelectAccountBalances(Account) (*Balances, error) { return nil, errUnscoped }
+
+func (s userScope) SelectAccountBalancesWithOptions(Account, BalancesOptions) (*Balances, error) {
+	return nil, errUnscoped
+}
+
+func (s userScope) UpdateBalance(Account, *Balance, balance.Balance) (*Balance, error) {
+	return nil, errUnscoped
+}
+
+func (s userScope) DeleteBalance(Account, *Balance) error { return errUnscoped }
+
+func (s userScope) InsertTransaction(Transaction) (*Transaction, error) { return nil, errUnscoped }
+
+func (s userScope) SelectTransactions() (*Transactions, error) { return nil, errUnscoped }
+
+func (s userScope) SelectAccountTransactions(Account) (*Transactions, error) {
+	return nil, errUnscoped
+}
+
+func (s balancesAtUserScope) SelectBalancesAt(time.Time) (map[uint]Balance, error) {
+	return nil, errUnscoped
+}
+
+// SelectBalancesAtContext only selects the Balances of the Accounts that the
+// User has access to.
+func (s balancesAtUserScope) SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]Balance, error) {
+	as, err := s.access(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := s.balancesAt.SelectBalancesAtContext(ctx, t)
+	if err != nil || as == nil {
+		return bs, err
+	}
+	for id := range bs {
+		if _, ok := as[id]; !ok {
+			delete(bs, id)
+		}
+	}
+	return bs, nil
+}
@@ -0,0 +1,103 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/memory"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithUserScope(t *testing.T) {
+	store := memory.New()
+	scoped := storage.WithUserScope(storage.WithContext(store), store)
+	alice, err := store.InsertUser("alice")
+	common.FatalIfError(t, err, "inserting user")
+	bob, err := store.InsertUser("bob")
+	common.FatalIfError(t, err, "inserting user")
+	asAlice := storage.ContextWithUser(context.Background(), alice.ID)
+	asBob := storage.ContextWithUser(context.Background(), bob.ID)
+
+	opened := time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)
+	insert := func(ctx context.Context, name string) *storage.Account {
+		a, err := scoped.InsertAccountContext(ctx, *accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), opened))
+		common.FatalIfError(t, err, "inserting account")
+		return a
+	}
+	current, savings, bobs := insert(asAlice, "current"), insert(asAlice, "savings"), insert(asBob, "bobs")
+
+	ids := func(ctx context.Context) []uint {
+		as, err := scoped.SelectAccountsContext(ctx)
+		common.FatalIfError(t, err, "selecting accounts")
+		var ids []uint
+		for _, a := range *as {
+			ids = append(ids, a.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []uint{current.ID, savings.ID, bobs.ID}, ids(context.Background()), "accounts should not be scoped without a user")
+	assert.Equal(t, []uint{current.ID, savings.ID}, ids(asAlice))
+	assert.Equal(t, []uint{bobs.ID}, ids(asBob))
+
+	_, err = scoped.SelectAccountContext(asAlice, bobs.ID)
+	assert.Error(t, err)
+	assert.NotEqual(t, storage.ErrForbidden, errors.Cause(err), "an account without access should not be found")
+
+	common.FatalIfError(t, store.SetAccountAccess(current.ID, bob.ID, storage.AccessRead), "sharing account")
+	assert.Equal(t, []uint{current.ID, bobs.ID}, ids(asBob))
+	_, err = scoped.SelectAccountBalancesContext(asBob, *current)
+	assert.NoError(t, err)
+	_, err = scoped.InsertBalanceContext(asBob, *current, balance.Balance{Date: opened})
+	assert.Equal(t, storage.ErrForbidden, errors.Cause(err))
+	assert.Equal(t, storage.ErrForbidden, errors.Cause(scoped.DeleteAccountContext(asBob, current.ID)))
+
+	common.FatalIfError(t, store.SetAccountAccess(current.ID, bob.ID, storage.AccessWrite), "sharing account")
+	_, err = scoped.InsertBalanceContext(asBob, *current, balance.Balance{Date: opened})
+	assert.NoError(t, err)
+	assert.Equal(t, storage.ErrForbidden, errors.Cause(scoped.DeleteAccountContext(asBob, current.ID)), "only the owner should be able to delete an account")
+
+	_, err = scoped.InsertTransactionContext(asAlice, storage.Transaction{Date: date.New(2018, time.June, 1), Amount: 100, SourceID: current.ID, DestinationID: savings.ID})
+	common.FatalIfError(t, err, "inserting transaction")
+	_, err = scoped.InsertTransactionContext(asBob, storage.Transaction{Date: date.New(2018, time.June, 1), Amount: 100, SourceID: current.ID, DestinationID: savings.ID})
+	assert.Error(t, err, "inserting a transaction should require access to both accounts")
+	for _, test := range []struct {
+		ctx context.Context
+		n   int
+	}{{ctx: asAlice, n: 1}, {ctx: asBob, n: 1}, {ctx: storage.ContextWithUser(context.Background(), bob.ID+1), n: 0}} {
+		ts, err := scoped.SelectTransactionsContext(test.ctx)
+		common.FatalIfError(t, err, "selecting transactions")
+		assert.Len(t, *ts, test.n)
+	}
+
+	balancesAt, ok := scoped.(storage.BalancesAtStorage)
+	if assert.True(t, ok, "scoped storage should select balances at a time when its storage can") {
+		bs, err := balancesAt.SelectBalancesAtContext(asBob, opened)
+		common.FatalIfError(t, err, "selecting balances at time")
+		_, ok := bs[current.ID]
+		assert.True(t, ok, "balance of a shared account should be selected")
+		_, err = scoped.InsertBalanceContext(asAlice, *savings, balance.Balance{Date: opened})
+		common.FatalIfError(t, err, "inserting balance")
+		bs, err = balancesAt.SelectBalancesAtContext(asBob, opened)
+		common.FatalIfError(t, err, "selecting balances at time")
+		_, ok = bs[savings.ID]
+		assert.False(t, ok, "balance of an account without access should not be selected")
+		_, err = balancesAt.SelectBalancesAt(opened)
+		assert.Equal(t, storage.ErrForbidden, errors.Cause(err))
+	}
+
+	_, err = scoped.SelectAccounts()
+	assert.Equal(t, storage.ErrForbidden, errors.Cause(err), "operations without a context should not be unscoped")
+	_, err = scoped.SelectAccountBalances(*current)
+	assert.Equal(t, storage.ErrForbidden, errors.Cause(err), "operations without a context should not be unscoped")
+	_, err = scoped.SelectTransactions()
+	assert.Equal(t, storage.ErrForbidden, errors.Cause(err), "operations without a context should not be unscoped")
+
+	assert.NoError(t, scoped.DeleteAccountContext(asAlice, savings.ID))
+}
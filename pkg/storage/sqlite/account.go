@@ -0,0 +1,285 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-money/currency"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	fieldID       = "id"
+	fieldName     = "name"
+	fieldOpened   = "opened"
+	fieldClosed   = "closed"
+	fieldCurrency = "currency"
+	fieldDeleted  = "deleted"
+	table         = "accounts"
+)
+
+var (
+	fieldsInsert = fmt.Sprintf(
+		"%s, %s, %s, %s",
+		fieldName,
+		fieldOpened,
+		fieldClosed,
+		fieldCurrency)
+
+	fieldsSelect = fmt.Sprintf(
+		"%s, %s, %s, %s, %s, %s",
+		fieldID,
+		fieldName,
+		fieldOpened,
+		fieldClosed,
+		fieldCurrency,
+		fieldDeleted)
+
+	querySelectAccounts = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s IS NULL ORDER BY %s ASC;",
+		fieldsSelect,
+		table,
+		fieldDeleted,
+		fieldID)
+
+	querySelectAllAccounts = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC;",
+		fieldsSelect,
+		table,
+		fieldID)
+
+	querySelectAccount = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ? AND %s IS NULL;",
+		fieldsSelect,
+		table,
+		fieldID,
+		fieldDeleted)
+
+	querySelectAccountIncludingDeleted = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		fieldsSelect,
+		table,
+		fieldID)
+
+	queryInsertAccount = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES (?, ?, ?, ?);`,
+		table,
+		fieldsInsert)
+
+	queryUpdateAccount = fmt.Sprintf(
+		`UPDATE %s SET %s = ?, %s = ?, %s = ?, %s = ? WHERE %s = ?;`,
+		table,
+		fieldName,
+		fieldOpened,
+		fieldClosed,
+		fieldCurrency,
+		fieldID)
+
+	queryDeleteAccount = fmt.Sprintf(
+		`UPDATE %s SET %s = ? WHERE %s = ? AND %s IS NULL;`,
+		table,
+		fieldDeleted,
+		fieldID,
+		fieldDeleted)
+)
+
+// SelectAccounts returns an Accounts item holding all Account entries within
+// the given database along with any errors that occurred whilst attempting to
+// retrieve the Accounts.
+func (s sqlite) SelectAccounts() (*storage.Accounts, error) {
+	return queryAccounts(s.db, querySelectAccounts)
+}
+
+// SelectAllAccounts returns an Accounts item holding all Account entries
+// within the given database, including those that have been deleted.
+func (s sqlite) SelectAllAccounts() (*storage.Accounts, error) {
+	return queryAccounts(s.db, querySelectAllAccounts)
+}
+
+// SelectAccount returns an Account with the given id.
+func (s sqlite) SelectAccount(id uint) (*storage.Account, error) {
+	dba, err := queryAccount(s.db, querySelectAccount, id)
+	return dba, errors.Wrap(err, "querying Account")
+}
+
+// InsertAccount inserts an account.Account in the storage backend and returns
+// it. The opened and closed times of the account.Account are stored as dates.
+func (s sqlite) InsertAccount(a account.Account) (*storage.Account, error) {
+	dated, err := storage.DateAccount(a)
+	if err != nil {
+		return nil, errors.Wrap(err, "dating Account")
+	}
+	sa := storage.Account{Account: *dated}
+	r, err := s.db.Exec(
+		queryInsertAccount,
+		a.Name(),
+		sa.Opened(),
+		sa.Closed(),
+		a.CurrencyCode().String(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing insert query")
+	}
+	id, err := r.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting id of inserted Account")
+	}
+	dba, err := queryAccount(s.db, querySelectAccountIncludingDeleted, id)
+	return dba, errors.Wrap(err, "querying Account")
+}
+
+// UpdateAccount updates a stored account to reflect the details of some other
+// account data. The updates will be verified to ensure that any data to be
+// used will be logically sound with the balances and other account details.
+func (s sqlite) UpdateAccount(a *storage.Account, updates *account.Account) (*storage.Account, error) {
+	updates, err := storage.DateAccount(*updates)
+	if err != nil {
+		return nil, errors.Wrap(err, "dating Account updates")
+	}
+	bs, err := s.SelectAccountBalances(*a)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting Account Balances for update validation")
+	}
+	for _, b := range *bs {
+		err := updates.ValidateBalance(b.Balance)
+		if err != nil {
+			return nil, fmt.Errorf("update would make balance invalid: %v", err)
+		}
+	}
+	su := storage.Account{Account: *updates}
+	err = execExpectingSingleRow(
+		s.db,
+		queryUpdateAccount,
+		updates.Name(),
+		su.Opened(),
+		su.Closed(),
+		updates.CurrencyCode().String(),
+		a.ID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating Account")
+	}
+	dba, err := queryAccount(s.db, querySelectAccountIncludingDeleted, a.ID)
+	return dba, errors.Wrap(err, "querying Account")
+}
+
+// DeleteAccount marks the Account with the given id as deleted. An error is
+// returned if the Account does not exist or has already been deleted.
+func (s sqlite) DeleteAccount(id uint) error {
+	return s.DeleteAccountAt(id, time.Now())
+}
+
+// DeleteAccountAt marks the Account with the given id as deleted at the given
+// time. An error is returned if the Account does not exist or has already been
+// deleted.
+func (s sqlite) DeleteAccountAt(id uint, t time.Time) error {
+	_, err := s.SelectAccount(id)
+	if err != nil {
+		return errors.Wrap(err, "selecting account to delete")
+	}
+	return execExpectingSingleRow(s.db, queryDeleteAccount, t.UTC(), id)
+}
+
+// InTransaction calls fn with a Storage that makes every query within a single
+// transaction, which is committed if fn returns nil and rolled back otherwise.
+// As the database only has a single connection, every other operation on the
+// Storage waits until the transaction has finished.
+func (s sqlite) InTransaction(fn func(storage.ArchiveStorage) error) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	err = fn(&sqlite{conn: s.conn, db: tx})
+	if err != nil {
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Printf("error rolling back transaction: %v", rErr)
+		}
+		return err
+	}
+	return errors.Wrap(tx.Commit(), "committing transaction")
+}
+
+func execExpectingSingleRow(db queryer, queryString string, values ...interface{}) error {
+	r, err := db.Exec(queryString, values...)
+	if err != nil {
+		return errors.Wrap(err, "executing query")
+	}
+	n, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "getting number of rows affected ")
+	}
+	if n != 1 {
+		return fmt.Errorf("expected 1 affected row but got %d", n)
+	}
+	return nil
+}
+
+func queryAccount(db queryer, queryString string, values ...interface{}) (*storage.Account, error) {
+	as, err := queryAccounts(db, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying accounts")
+	}
+	resLen := len(*as)
+	if resLen == 0 {
+		return nil, storage.ErrNoAccount
+	}
+	if resLen > 1 {
+		return nil, fmt.Errorf("expected 1 account but query returned %d", resLen)
+	}
+	return &(*as)[0], nil
+}
+
+func queryAccounts(db queryer, queryString string, values ...interface{}) (*storage.Accounts, error) {
+	rows, err := db.Query(queryString, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer nonReturningCloseRows(rows)
+	return scanRowsForAccounts(rows)
+}
+
+// scanRowsForAccounts scans an sql.Rows object for storage.Accounts objects
+// and returns then along with any error that occurs whilst attempting to scan.
+func scanRowsForAccounts(rows *sql.Rows) (*storage.Accounts, error) {
+	openAccounts := storage.Accounts{}
+	for rows.Next() {
+		var id uint
+		var name, code string
+		var opened date.Date
+		var closed date.NullDate
+		var deleted sql.NullTime
+		// 	fieldID, fieldName, fieldOpened, fieldClosed, fieldCurrency, fieldDeleted)
+		err := rows.Scan(&id, &name, &opened, &closed, &code, &deleted)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning row")
+		}
+		c, err := currency.NewCode(code)
+		if err != nil {
+			return nil, errors.Wrap(err, "generating new currency code")
+		}
+		innerAccount, err := account.New(name, *c, opened.Time())
+		if err != nil {
+			return nil, errors.Wrap(err, "creating new inner account")
+		}
+		if closed.Valid {
+			err = account.CloseTime(closed.Date.Time())(innerAccount)
+			if err != nil {
+				return nil, errors.Wrap(err, "applying closed time to inner account")
+			}
+		}
+		a := &storage.Account{ID: id, Account: *innerAccount}
+		if deleted.Valid {
+			err := storage.DeletedAt(deleted.Time)(a)
+			if err != nil {
+				return nil, errors.Wrap(err, "applying deleted time to inner account")
+			}
+		}
+		openAccounts = append(openAccounts, *a)
+	}
+	return &openAccounts, rows.Err()
+}
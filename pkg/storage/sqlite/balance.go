@@ -0,0 +1,257 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	balancesFieldAccountID = "account_id"
+	balancesFieldAmount    = "amount"
+	balancesFieldID        = "id"
+	balancesFieldTime      = "time"
+	balancesTable          = "balances"
+)
+
+var (
+	balancesSelectFields = fmt.Sprintf(
+		"%s, %s, %s",
+		balancesFieldID,
+		balancesFieldTime,
+		balancesFieldAmount)
+
+	balancesSelectPrefix = fmt.Sprintf(
+		`SELECT %s FROM %s WHERE `,
+		balancesSelectFields,
+		balancesTable)
+
+	balancesSelectBalancesForAccountID = fmt.Sprintf(
+		"%s%s = ? ORDER BY %s ASC, %s ASC;",
+		balancesSelectPrefix,
+		balancesFieldAccountID,
+		balancesFieldTime,
+		balancesFieldID)
+
+	balancesSelectBalanceByID = fmt.Sprintf(
+		"%s%s = ?;",
+		balancesSelectPrefix,
+		balancesFieldID)
+
+	// sqlite has no DISTINCT ON, so the Balance of each Account is found with
+	// a correlated subquery
+	balancesSelectBalancesAt = fmt.Sprintf(
+		`SELECT %s, %s FROM %s AS b WHERE %s = (
+	SELECT %s FROM %s WHERE %s = b.%s AND %s <= ? ORDER BY %s DESC, %s DESC LIMIT 1
+) ORDER BY %s ASC;`,
+		balancesFieldAccountID,
+		balancesSelectFields,
+		balancesTable,
+		balancesFieldID,
+		balancesFieldID,
+		balancesTable,
+		balancesFieldAccountID,
+		balancesFieldAccountID,
+		balancesFieldTime,
+		balancesFieldTime,
+		balancesFieldID,
+		balancesFieldAccountID)
+
+	balancesInsertFields = fmt.Sprintf(
+		"%s, %s, %s",
+		balancesFieldAccountID,
+		balancesFieldTime,
+		balancesFieldAmount)
+
+	balancesInsertBalance = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES (?, ?, ?);`,
+		balancesTable,
+		balancesInsertFields)
+
+	balancesUpdateBalance = fmt.Sprintf(
+		`UPDATE %s SET %s = ?, %s = ? WHERE %s = ? AND %s = ?;`,
+		balancesTable,
+		balancesFieldTime,
+		balancesFieldAmount,
+		balancesFieldID,
+		balancesFieldAccountID)
+
+	balancesDeleteBalance = fmt.Sprintf(
+		`DELETE FROM %s WHERE %s = ? AND %s = ?;`,
+		balancesTable,
+		balancesFieldID,
+		balancesFieldAccountID)
+)
+
+// SelectAccountBalances returns all Balances for a given Account and any
+// errors that occur whilst attempting to retrieve the Balances. The Balances
+// are sorted by chronological order then by the id of the Balance in the DB
+func (s sqlite) SelectAccountBalances(a storage.Account) (*storage.Balances, error) {
+	return queryBalances(s.db, balancesSelectBalancesForAccountID, a.ID)
+}
+
+// SelectAccountBalancesWithOptions returns the Balances of a given Account that
+// match the given BalancesOptions, in their order and up to their limit, using
+// a single query.
+func (s sqlite) SelectAccountBalancesWithOptions(a storage.Account, o storage.BalancesOptions) (*storage.Balances, error) {
+	query, values := balancesSelectWithOptions(a.ID, o)
+	return queryBalances(s.db, query, values...)
+}
+
+// balancesSelectWithOptions returns the query, and the values for it, that
+// selects the Balances of an Account that match the BalancesOptions.
+func balancesSelectWithOptions(accountID uint, o storage.BalancesOptions) (string, []interface{}) {
+	var values []interface{}
+	placeholder := func(value interface{}) string {
+		values = append(values, value)
+		return "?"
+	}
+	conditions := []string{balancesFieldAccountID + " = " + placeholder(accountID)}
+	if o.From != nil {
+		conditions = append(conditions, balancesFieldTime+" >= "+placeholder(*o.From))
+	}
+	if o.To != nil {
+		conditions = append(conditions, balancesFieldTime+" <= "+placeholder(*o.To))
+	}
+	order, comparison := "ASC", ">"
+	if o.Descending {
+		order, comparison = "DESC", "<"
+	}
+	if o.After != nil {
+		conditions = append(conditions, fmt.Sprintf(
+			"(%s %s %s OR (%s = %s AND %s %s %s))",
+			balancesFieldTime, comparison, placeholder(o.After.Date),
+			balancesFieldTime, placeholder(o.After.Date),
+			balancesFieldID, comparison, placeholder(o.After.ID)))
+	}
+	query := fmt.Sprintf(
+		"%s%s ORDER BY %s %s, %s %s",
+		balancesSelectPrefix,
+		strings.Join(conditions, " AND "),
+		balancesFieldTime, order,
+		balancesFieldID, order)
+	if o.Limit != 0 {
+		query += " LIMIT " + placeholder(o.Limit)
+	}
+	return query + ";", values
+}
+
+// SelectBalancesAt returns the Balance of every Account at the given time,
+// keyed by the ID of the Account, using a single query.
+func (s sqlite) SelectBalancesAt(t time.Time) (map[uint]storage.Balance, error) {
+	return s.SelectBalancesAtContext(context.Background(), t)
+}
+
+// SelectBalancesAtContext is the same as SelectBalancesAt but can be cancelled
+// with the given context.Context.
+func (s sqlite) SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]storage.Balance, error) {
+	rows, err := s.db.QueryContext(ctx, balancesSelectBalancesAt, date.FromTime(t))
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	bs := make(map[uint]storage.Balance)
+	for rows.Next() {
+		var accountID, ID uint
+		var d date.Date
+		var amount int
+		if err := rows.Scan(&accountID, &ID, &d, &amount); err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		bs[accountID] = storage.Balance{ID: ID, Balance: balance.Balance{Date: d.Time(), Amount: amount}}
+	}
+	return bs, errors.Wrap(rows.Err(), "rows error")
+}
+
+// InsertBalance validates a balance.Balance against the given Account and, if
+// valid, inserts it in the storage backend and returns it.
+func (s sqlite) InsertBalance(a storage.Account, b balance.Balance) (*storage.Balance, error) {
+	b = storage.DateBalance(b)
+	err := a.Account.ValidateBalance(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating balance")
+	}
+	r, err := s.db.Exec(balancesInsertBalance, a.ID, date.FromTime(b.Date), b.Amount)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing insert query")
+	}
+	id, err := r.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting id of inserted Balance")
+	}
+	dbb, err := queryBalance(s.db, balancesSelectBalanceByID, id)
+	return dbb, errors.Wrap(err, "querying balance")
+}
+
+// UpdateBalance updates a Balance of the given Account to reflect the details
+// of some other balance data. The updates will be validated against the
+// Account to ensure that the updated Balance would be logically sound.
+func (s sqlite) UpdateBalance(a storage.Account, b *storage.Balance, us balance.Balance) (*storage.Balance, error) {
+	us = storage.DateBalance(us)
+	err := a.Account.ValidateBalance(us)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating balance updates")
+	}
+	err = execExpectingSingleRow(s.db, balancesUpdateBalance, date.FromTime(us.Date), us.Amount, b.ID, a.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating Balance")
+	}
+	dbb, err := queryBalance(s.db, balancesSelectBalanceByID, b.ID)
+	return dbb, errors.Wrap(err, "querying balance")
+}
+
+// DeleteBalance deletes a Balance of the given Account. An error will be
+// returned if the Balance does not exist for the Account.
+func (s sqlite) DeleteBalance(a storage.Account, b *storage.Balance) error {
+	return execExpectingSingleRow(s.db, balancesDeleteBalance, b.ID, a.ID)
+}
+
+// queryBalance returns an error if anything other than a single result is
+// returned from the query.
+func queryBalance(db queryer, queryString string, values ...interface{}) (*storage.Balance, error) {
+	bs, err := queryBalances(db, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying balances")
+	}
+	if len(*bs) != 1 {
+		return nil, fmt.Errorf("expected 1 balance but query returned %d", len(*bs))
+	}
+	return &(*bs)[0], nil
+}
+
+func queryBalances(db queryer, queryString string, values ...interface{}) (*storage.Balances, error) {
+	rows, err := db.Query(queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	return scanRowsForBalances(rows)
+}
+
+// scanRowsForBalance scans a sql.Rows for a Balances object and returns any
+// error occurring along the way.
+func scanRowsForBalances(rows *sql.Rows) (*storage.Balances, error) {
+	bs := &storage.Balances{}
+	for rows.Next() {
+		var ID uint
+		var d date.Date
+		var amount int
+		err := rows.Scan(&ID, &d, &amount)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		innerB, err := balance.New(d.Time(), balance.Amount(amount))
+		if err != nil {
+			return nil, errors.Wrap(err, "creating new balance from scan results")
+		}
+		*bs = append(*bs, storage.Balance{ID: ID, Balance: *innerB})
+	}
+	return bs, errors.Wrap(rows.Err(), "rows error")
+}
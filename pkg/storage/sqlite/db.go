@@ -0,0 +1,300 @@
+// Package sqlite provides a storage.Storage that is backed by an embedded
+// SQLite database held in a single file, for running mon without a separate
+// database server.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	// register the sqlite3 database/sql driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+const driverName = "sqlite3"
+
+// New returns a connection to a sqlite Storage held in the file at the given
+// path along with any errors that occur whilst attempting to open the
+// connection. The file and the tables required by the Storage will be created
+// if they do not already exist. The path ":memory:" can be used to hold the
+// Storage in memory.
+func New(path string) (*sqlite, error) {
+	db, err := open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening connection to backend")
+	}
+	err = createTables(db)
+	if err != nil {
+		nonReturningCloseDB(db)
+		return nil, errors.Wrap(err, "creating tables")
+	}
+	err = migrate(db)
+	if err != nil {
+		nonReturningCloseDB(db)
+		return nil, errors.Wrap(err, "migrating tables")
+	}
+	return &sqlite{conn: db, db: db}, nil
+}
+
+type sqlite struct {
+	// conn is the connection to the database
+	conn *sql.DB
+	// db is used for every query, and is either conn or a transaction on conn
+	db queryer
+}
+
+// queryer is the part of a *sql.DB or a *sql.Tx that is used to query the
+// database
+type queryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// createTables creates the accounts, balances, transactions, rates, tokens,
+// users and account access tables, mirroring those of the postgres backend, if
+// they do not already exist.
+func createTables(db *sql.DB) error {
+	_, err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s varchar(100) NOT NULL,
+	%s char(3) NOT NULL,
+	%s date NOT NULL,
+	%s date,
+	%s timestamp);`,
+		table,
+		fieldID,
+		fieldName,
+		fieldCurrency,
+		fieldOpened,
+		fieldClosed,
+		fieldDeleted))
+	if err != nil {
+		return errors.Wrap(err, "executing create Accounts query")
+	}
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s integer NOT NULL,
+	%s date NOT NULL,
+	%s bigint NOT NULL);`,
+		balancesTable,
+		balancesFieldID,
+		balancesFieldAccountID,
+		balancesFieldTime,
+		balancesFieldAmount))
+	if err != nil {
+		return errors.Wrap(err, "executing create Balances query")
+	}
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s date NOT NULL,
+	%s bigint NOT NULL,
+	%s text NOT NULL,
+	%s integer NOT NULL REFERENCES %s (%s),
+	%s integer NOT NULL REFERENCES %s (%s));`,
+		transactionsTable,
+		transactionsFieldID,
+		transactionsFieldDate,
+		transactionsFieldAmount,
+		transactionsFieldDescription,
+		transactionsFieldSourceID, table, fieldID,
+		transactionsFieldDestinationID, table, fieldID))
+	if err != nil {
+		return errors.Wrap(err, "executing create Transactions query")
+	}
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s date NOT NULL,
+	%s char(3) NOT NULL,
+	%s char(3) NOT NULL,
+	%s double precision NOT NULL);`,
+		ratesTable,
+		ratesFieldID,
+		ratesFieldDate,
+		ratesFieldFrom,
+		ratesFieldTo,
+		ratesFieldRate))
+	if err != nil {
+		return errors.Wrap(err, "executing create Rates query")
+	}
+	// the user_id column of the tokens table is added by migrate
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s varchar(100) NOT NULL,
+	%s char(64) NOT NULL UNIQUE,
+	%s timestamp NOT NULL,
+	%s timestamp);`,
+		tokensTable,
+		tokensFieldID,
+		tokensFieldName,
+		tokensFieldHash,
+		tokensFieldCreated,
+		tokensFieldRevoked))
+	if err != nil {
+		return errors.Wrap(err, "executing create Tokens query")
+	}
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s INTEGER PRIMARY KEY AUTOINCREMENT,
+	%s varchar(100) NOT NULL UNIQUE);`,
+		usersTable,
+		usersFieldID,
+		usersFieldName))
+	if err != nil {
+		return errors.Wrap(err, "executing create Users query")
+	}
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	%s integer NOT NULL REFERENCES %s (%s),
+	%s integer NOT NULL REFERENCES %s (%s),
+	%s varchar(5) NOT NULL,
+	PRIMARY KEY (%s, %s));`,
+		accessTable,
+		accessFieldAccountID, table, fieldID,
+		accessFieldUserID, usersTable, usersFieldID,
+		accessFieldAccess,
+		accessFieldAccountID, accessFieldUserID))
+	return errors.Wrap(err, "executing create Account Access query")
+}
+
+// schemaVersion is the version of the schema that migrate brings a database
+// to. The version of a database is held in its user_version pragma.
+const schemaVersion = 2
+
+// migrate brings the data of a database that was created by an earlier
+// version of the backend in line with the current schema.
+func migrate(db *sql.DB) error {
+	var v int
+	err := db.QueryRow(`PRAGMA user_version;`).Scan(&v)
+	if err != nil {
+		return errors.Wrap(err, "selecting schema version")
+	}
+	if v >= schemaVersion {
+		return nil
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	for _, m := range []struct {
+		version     int
+		description string
+		query       string
+	}{
+		// Version 1 stores dates rather than times. Existing times are stored
+		// in UTC, so they are converted to the date that they fall on in UTC.
+		{
+			version:     1,
+			description: "converting times to dates",
+			query: fmt.Sprintf(
+				`UPDATE %s SET %s = date(%s), %s = date(%s);
+UPDATE %s SET %s = date(%s);`,
+				table, fieldOpened, fieldOpened, fieldClosed, fieldClosed,
+				balancesTable, balancesFieldTime, balancesFieldTime),
+		},
+		// Version 2 gives each token the user that it authenticates.
+		{
+			version:     2,
+			description: "adding token users",
+			query: fmt.Sprintf(
+				`ALTER TABLE %s ADD COLUMN %s integer REFERENCES %s (%s);`,
+				tokensTable, tokensFieldUserID, usersTable, usersFieldID),
+		},
+	} {
+		if v >= m.version {
+			continue
+		}
+		_, err = tx.Exec(m.query)
+		if err != nil {
+			if rErr := tx.Rollback(); rErr != nil {
+				log.Printf("error rolling back transaction: %v", rErr)
+			}
+			return errors.Wrap(err, m.description)
+		}
+	}
+	_, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d;`, schemaVersion))
+	if err != nil {
+		if rErr := tx.Rollback(); rErr != nil {
+			log.Printf("error rolling back transaction: %v", rErr)
+		}
+		return errors.Wrap(err, "setting schema version")
+	}
+	return errors.Wrap(tx.Commit(), "committing transaction")
+}
+
+// CheckSchemaVersion returns an error if the schema of the Storage is not at
+// the version that migrate brings a database to.
+func (s sqlite) CheckSchemaVersion() error {
+	return s.CheckSchemaVersionContext(context.Background())
+}
+
+// CheckSchemaVersionContext is the same as CheckSchemaVersion but can be
+// cancelled with the given context.Context.
+func (s sqlite) CheckSchemaVersionContext(ctx context.Context) error {
+	var v int
+	err := s.db.QueryRowContext(ctx, `PRAGMA user_version;`).Scan(&v)
+	if err != nil {
+		return errors.Wrap(err, "selecting schema version")
+	}
+	if v != schemaVersion {
+		return fmt.Errorf("schema is at version %d but version %d is required", v, schemaVersion)
+	}
+	return nil
+}
+
+func open(path string) (*sql.DB, error) {
+	if len(strings.TrimSpace(path)) == 0 {
+		return nil, errors.New("storage path must be non-whitespace and longer than 0 characters")
+	}
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite only supports a single writer at a time and an in-memory
+	// database only exists for the lifetime of a single connection, so only
+	// ever use a single connection.
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Available returns true if the Storage is available
+func (s *sqlite) Available() bool {
+	return s.AvailableContext(context.Background())
+}
+
+// AvailableContext is the same as Available but can be cancelled with the
+// given context.Context.
+func (s *sqlite) AvailableContext(ctx context.Context) bool {
+	return s.conn.PingContext(ctx) == nil // PingContext() returns an error if db  is unavailable
+}
+
+func (s sqlite) Close() error {
+	return s.conn.Close()
+}
+
+func nonReturningClose(c io.Closer, name string) {
+	var nameInsert string
+	if name != "" {
+		nameInsert = fmt.Sprintf("(%s) ", name)
+	}
+	if c == nil {
+		log.Printf("Attempted to close io.Closer %sbut it was nil.", nameInsert)
+		return
+	}
+	err := c.Close()
+	if err != nil {
+		log.Printf("Error closing io.Closer %s%v", nameInsert, c)
+	}
+}
+
+func nonReturningCloseDB(db *sql.DB) {
+	nonReturningClose(db, "DB")
+}
+
+func nonReturningCloseRows(rows *sql.Rows) {
+	nonReturningClose(rows, "Rows")
+}
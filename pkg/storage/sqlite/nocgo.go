@@ -0,0 +1,7 @@
+// +build !cgo
+
+package sqlite
+
+// Supported is true when the sqlite backend can be used, which requires the
+// binary to be built with cgo.
+const Supported = false
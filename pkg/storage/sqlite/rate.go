@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	ratesFieldID   = "id"
+	ratesFieldDate = "date"
+	ratesFieldFrom = "from_currency"
+	ratesFieldTo   = "to_currency"
+	ratesFieldRate = "rate"
+	ratesTable     = "rates"
+)
+
+var (
+	ratesSelectFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s",
+		ratesFieldID,
+		ratesFieldDate,
+		ratesFieldFrom,
+		ratesFieldTo,
+		ratesFieldRate)
+
+	ratesSelectRates = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC, %s ASC;",
+		ratesSelectFields,
+		ratesTable,
+		ratesFieldDate,
+		ratesFieldID)
+
+	ratesInsertFields = fmt.Sprintf(
+		"%s, %s, %s, %s",
+		ratesFieldDate,
+		ratesFieldFrom,
+		ratesFieldTo,
+		ratesFieldRate)
+
+	ratesInsertRate = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES (?, ?, ?, ?);`,
+		ratesTable,
+		ratesInsertFields)
+
+	ratesSelectRateByID = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		ratesSelectFields,
+		ratesTable,
+		ratesFieldID)
+)
+
+// InsertRate validates a Rate and, if valid, inserts it in the storage
+// backend and returns it. The ID of the given Rate is ignored.
+func (s sqlite) InsertRate(r storage.Rate) (*storage.Rate, error) {
+	err := storage.ValidateRate(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating rate")
+	}
+	res, err := s.db.Exec(ratesInsertRate, r.Date, r.From, r.To, r.Rate)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing insert query")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting id of inserted Rate")
+	}
+	rs, err := queryRates(s.db, ratesSelectRateByID, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying rates")
+	}
+	if len(*rs) != 1 {
+		return nil, fmt.Errorf("expected 1 rate but query returned %d", len(*rs))
+	}
+	return &(*rs)[0], nil
+}
+
+// SelectRates returns all Rates, sorted by chronological order then by the id
+// of the Rate in the DB.
+func (s sqlite) SelectRates() (*storage.Rates, error) {
+	return queryRates(s.db, ratesSelectRates)
+}
+
+func queryRates(db queryer, queryString string, values ...interface{}) (*storage.Rates, error) {
+	rows, err := db.Query(queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	return scanRowsForRates(rows)
+}
+
+// scanRowsForRates scans a sql.Rows for a Rates object and returns any error
+// occurring along the way.
+func scanRowsForRates(rows *sql.Rows) (*storage.Rates, error) {
+	rs := &storage.Rates{}
+	for rows.Next() {
+		var r storage.Rate
+		err := rows.Scan(&r.ID, &r.Date, &r.From, &r.To, &r.Rate)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		*rs = append(*rs, r)
+	}
+	return rs, errors.Wrap(rows.Err(), "rows error")
+}
@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/glynternet/mon/pkg/storage/storagetest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuite(t *testing.T) {
+	store := newTestStorage(t, ":memory:")
+	defer nonReturningCloseStorage(store)
+	storagetest.Test(t, store)
+}
+
+func TestNew(t *testing.T) {
+	t.Run("empty path", func(t *testing.T) {
+		store, err := New("   ")
+		assert.Error(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("file is created and data persists", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "mon-sqlite")
+		common.FatalIfError(t, err, "creating temporary directory")
+		defer func() {
+			common.FatalIfError(t, os.RemoveAll(dir), "removing temporary directory")
+		}()
+		path := filepath.Join(dir, "mon.db")
+
+		store := newTestStorage(t, path)
+		inserted, err := store.InsertAccount(*accountingtest.NewAccount(
+			t, "A", accountingtest.NewCurrencyCode(t, "GBP"), time.Now(),
+		))
+		common.FatalIfError(t, err, "inserting account")
+		common.FatalIfError(t, store.Close(), "closing storage")
+
+		_, err = os.Stat(path)
+		common.FatalIfError(t, err, "checking storage file")
+
+		store = newTestStorage(t, path)
+		defer nonReturningCloseStorage(store)
+		selected, err := store.SelectAccount(inserted.ID)
+		common.FatalIfError(t, err, "selecting account")
+		equal, err := inserted.Equal(*selected)
+		common.FatalIfError(t, err, "equaling inserted and selected")
+		assert.True(t, equal)
+	})
+}
+
+func TestSqlite_CheckSchemaVersion(t *testing.T) {
+	store := newTestStorage(t, ":memory:")
+	defer nonReturningCloseStorage(store)
+	ss, ok := store.(storage.SchemaStorage)
+	if !assert.True(t, ok, "store should be a storage.SchemaStorage") {
+		t.FailNow()
+	}
+	assert.NoError(t, ss.CheckSchemaVersion())
+
+	_, err := store.(*sqlite).db.Exec(`PRAGMA user_version = 1;`)
+	common.FatalIfError(t, err, "setting schema version")
+	assert.Error(t, ss.CheckSchemaVersion())
+}
+
+func newTestStorage(t *testing.T, path string) storage.Storage {
+	store, err := New(path)
+	common.FatalIfError(t, err, "creating storage")
+	if !assert.True(t, store.Available(), "store should be available") {
+		t.FailNow()
+	}
+	return store
+}
+
+func nonReturningCloseStorage(s storage.Storage) {
+	nonReturningClose(s, "Storage")
+}
+
+func TestSqlite_noAccount(t *testing.T) {
+	store := newTestStorage(t, ":memory:")
+	defer nonReturningCloseStorage(store)
+	a, err := store.InsertAccount(*accountingtest.NewAccount(
+		t, "A", accountingtest.NewCurrencyCode(t, "GBP"), time.Now(),
+	))
+	common.FatalIfError(t, err, "inserting account")
+
+	_, err = store.SelectAccount(a.ID + 1)
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(err))
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(store.DeleteAccount(a.ID+1)))
+
+	common.FatalIfError(t, store.DeleteAccount(a.ID), "deleting account")
+	_, err = store.SelectAccount(a.ID)
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(err), "a deleted account should not be found")
+	assert.Equal(t, storage.ErrNoAccount, errors.Cause(store.DeleteAccount(a.ID)))
+}
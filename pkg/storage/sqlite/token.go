@@ -0,0 +1,161 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	tokensFieldID      = "id"
+	tokensFieldName    = "name"
+	tokensFieldHash    = "hash"
+	tokensFieldUserID  = "user_id"
+	tokensFieldCreated = "created"
+	tokensFieldRevoked = "revoked"
+	tokensTable        = "tokens"
+)
+
+var (
+	tokensSelectFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s, %s",
+		tokensFieldID,
+		tokensFieldName,
+		tokensFieldHash,
+		tokensFieldUserID,
+		tokensFieldCreated,
+		tokensFieldRevoked)
+
+	tokensSelectTokens = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC;",
+		tokensSelectFields,
+		tokensTable,
+		tokensFieldID)
+
+	tokensSelectTokenByID = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		tokensSelectFields,
+		tokensTable,
+		tokensFieldID)
+
+	tokensSelectTokenByHash = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		tokensSelectFields,
+		tokensTable,
+		tokensFieldHash)
+
+	tokensInsertToken = fmt.Sprintf(
+		`INSERT INTO %s (%s, %s, %s, %s) VALUES (?, ?, ?, ?);`,
+		tokensTable,
+		tokensFieldName,
+		tokensFieldHash,
+		tokensFieldUserID,
+		tokensFieldCreated)
+
+	tokensRevokeToken = fmt.Sprintf(
+		`UPDATE %s SET %s = ? WHERE %s = ? AND %s IS NULL;`,
+		tokensTable,
+		tokensFieldRevoked,
+		tokensFieldID,
+		tokensFieldRevoked)
+)
+
+// InsertToken inserts a new Token with the given name, secret hash and User,
+// created at the current time, and returns it.
+func (s sqlite) InsertToken(name, hash string, userID uint) (*storage.Token, error) {
+	err := storage.ValidateTokenName(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating token name")
+	}
+	res, err := s.db.Exec(tokensInsertToken, name, hash, nullableID(userID), time.Now().UTC())
+	if err != nil {
+		return nil, errors.Wrap(err, "executing insert query")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting id of inserted Token")
+	}
+	return queryToken(s.db, tokensSelectTokenByID, id)
+}
+
+// SelectTokens returns all Tokens, including revoked Tokens, sorted by their
+// id in the DB.
+func (s sqlite) SelectTokens() (*storage.Tokens, error) {
+	return queryTokens(s.db, tokensSelectTokens)
+}
+
+// SelectTokenByHash returns the Token with the given secret hash, or
+// storage.ErrNoToken if there is none.
+func (s sqlite) SelectTokenByHash(hash string) (*storage.Token, error) {
+	return queryToken(s.db, tokensSelectTokenByHash, hash)
+}
+
+// RevokeToken marks the Token with the given id as revoked at the current
+// time. An error is returned if the Token does not exist or has already been
+// revoked.
+func (s sqlite) RevokeToken(id uint) error {
+	r, err := s.db.Exec(tokensRevokeToken, time.Now().UTC(), id)
+	if err != nil {
+		return errors.Wrap(err, "executing query")
+	}
+	n, err := r.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "getting number of rows affected")
+	}
+	if n != 1 {
+		return fmt.Errorf("no unrevoked token with id %d", id)
+	}
+	return nil
+}
+
+// queryToken returns storage.ErrNoToken if the query returns no Tokens and an
+// error if it returns more than one.
+func queryToken(db queryer, queryString string, values ...interface{}) (*storage.Token, error) {
+	ts, err := queryTokens(db, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying tokens")
+	}
+	switch len(*ts) {
+	case 0:
+		return nil, storage.ErrNoToken
+	case 1:
+		return &(*ts)[0], nil
+	}
+	return nil, fmt.Errorf("expected 1 token but query returned %d", len(*ts))
+}
+
+func queryTokens(db queryer, queryString string, values ...interface{}) (*storage.Tokens, error) {
+	rows, err := db.Query(queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	ts := &storage.Tokens{}
+	for rows.Next() {
+		var t storage.Token
+		var userID sql.NullInt64
+		var revoked sql.NullTime
+		err := rows.Scan(&t.ID, &t.Name, &t.Hash, &userID, &t.Created, &revoked)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		t.UserID = uint(userID.Int64)
+		if revoked.Valid {
+			t.Revoked = &revoked.Time
+		}
+		*ts = append(*ts, t)
+	}
+	return ts, errors.Wrap(rows.Err(), "rows error")
+}
+
+// nullableID returns the value to store for an optional ID, which is NULL when
+// the ID is 0.
+func nullableID(id uint) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
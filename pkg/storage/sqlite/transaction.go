@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	transactionsFieldID            = "id"
+	transactionsFieldDate          = "date"
+	transactionsFieldAmount        = "amount"
+	transactionsFieldDescription   = "description"
+	transactionsFieldSourceID      = "source_account_id"
+	transactionsFieldDestinationID = "destination_account_id"
+	transactionsTable              = "transactions"
+)
+
+var (
+	transactionsSelectFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s, %s",
+		transactionsFieldID,
+		transactionsFieldDate,
+		transactionsFieldAmount,
+		transactionsFieldDescription,
+		transactionsFieldSourceID,
+		transactionsFieldDestinationID)
+
+	transactionsOrderBy = fmt.Sprintf(
+		"ORDER BY %s ASC, %s ASC",
+		transactionsFieldDate,
+		transactionsFieldID)
+
+	transactionsSelectTransactions = fmt.Sprintf(
+		"SELECT %s FROM %s %s;",
+		transactionsSelectFields,
+		transactionsTable,
+		transactionsOrderBy)
+
+	transactionsSelectTransactionsForAccountID = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ? OR %s = ? %s;",
+		transactionsSelectFields,
+		transactionsTable,
+		transactionsFieldSourceID,
+		transactionsFieldDestinationID,
+		transactionsOrderBy)
+
+	transactionsInsertFields = fmt.Sprintf(
+		"%s, %s, %s, %s, %s",
+		transactionsFieldDate,
+		transactionsFieldAmount,
+		transactionsFieldDescription,
+		transactionsFieldSourceID,
+		transactionsFieldDestinationID)
+
+	transactionsInsertTransaction = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES (?, ?, ?, ?, ?);`,
+		transactionsTable,
+		transactionsInsertFields)
+
+	transactionsSelectTransactionByID = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		transactionsSelectFields,
+		transactionsTable,
+		transactionsFieldID)
+)
+
+// InsertTransaction validates a Transaction against its source and
+// destination Accounts and, if valid, inserts it in the storage backend and
+// returns it. The ID of the given Transaction is ignored.
+func (s sqlite) InsertTransaction(t storage.Transaction) (*storage.Transaction, error) {
+	source, err := s.SelectAccount(t.SourceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting source account")
+	}
+	destination, err := s.SelectAccount(t.DestinationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "selecting destination account")
+	}
+	err = storage.ValidateTransaction(t, *source, *destination)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating transaction")
+	}
+	r, err := s.db.Exec(
+		transactionsInsertTransaction,
+		t.Date,
+		t.Amount,
+		t.Description,
+		t.SourceID,
+		t.DestinationID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing insert query")
+	}
+	id, err := r.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting id of inserted Transaction")
+	}
+	ts, err := queryTransactions(s.db, transactionsSelectTransactionByID, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying transactions")
+	}
+	if len(*ts) != 1 {
+		return nil, fmt.Errorf("expected 1 transaction but query returned %d", len(*ts))
+	}
+	return &(*ts)[0], nil
+}
+
+// SelectTransactions returns all Transactions, sorted by chronological order
+// then by the id of the Transaction in the DB.
+func (s sqlite) SelectTransactions() (*storage.Transactions, error) {
+	return queryTransactions(s.db, transactionsSelectTransactions)
+}
+
+// SelectAccountTransactions returns all Transactions that the given Account
+// is either the source or destination of, sorted by chronological order then
+// by the id of the Transaction in the DB.
+func (s sqlite) SelectAccountTransactions(a storage.Account) (*storage.Transactions, error) {
+	return queryTransactions(s.db, transactionsSelectTransactionsForAccountID, a.ID, a.ID)
+}
+
+func queryTransactions(db queryer, queryString string, values ...interface{}) (*storage.Transactions, error) {
+	rows, err := db.Query(queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	return scanRowsForTransactions(rows)
+}
+
+// scanRowsForTransactions scans a sql.Rows for a Transactions object and
+// returns any error occurring along the way.
+func scanRowsForTransactions(rows *sql.Rows) (*storage.Transactions, error) {
+	ts := &storage.Transactions{}
+	for rows.Next() {
+		var t storage.Transaction
+		err := rows.Scan(&t.ID, &t.Date, &t.Amount, &t.Description, &t.SourceID, &t.DestinationID)
+		if err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		*ts = append(*ts, t)
+	}
+	return ts, errors.Wrap(rows.Err(), "rows error")
+}
@@ -0,0 +1,158 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+const (
+	usersFieldID   = "id"
+	usersFieldName = "name"
+	usersTable     = "users"
+
+	accessFieldAccountID = "account_id"
+	accessFieldUserID    = "user_id"
+	accessFieldAccess    = "access"
+	accessTable          = "account_access"
+)
+
+var (
+	usersSelectFields = fmt.Sprintf("%s, %s", usersFieldID, usersFieldName)
+
+	usersSelectUsers = fmt.Sprintf(
+		"SELECT %s FROM %s ORDER BY %s ASC;",
+		usersSelectFields,
+		usersTable,
+		usersFieldID)
+
+	usersSelectUserByID = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		usersSelectFields,
+		usersTable,
+		usersFieldID)
+
+	usersSelectUserByName = fmt.Sprintf(
+		"SELECT %s FROM %s WHERE %s = ?;",
+		usersSelectFields,
+		usersTable,
+		usersFieldName)
+
+	usersInsertUser = fmt.Sprintf(
+		`INSERT INTO %s (%s) VALUES (?);`,
+		usersTable,
+		usersFieldName)
+
+	accessSetAccess = fmt.Sprintf(
+		`INSERT INTO %[1]s (%[2]s, %[3]s, %[4]s) VALUES (?, ?, ?)
+ON CONFLICT (%[2]s, %[3]s) DO UPDATE SET %[4]s = excluded.%[4]s;`,
+		accessTable,
+		accessFieldAccountID,
+		accessFieldUserID,
+		accessFieldAccess)
+
+	accessSelectUserAccess = fmt.Sprintf(
+		"SELECT %s, %s FROM %s WHERE %s = ?;",
+		accessFieldAccountID,
+		accessFieldAccess,
+		accessTable,
+		accessFieldUserID)
+)
+
+// InsertUser inserts a new User with the given name, which must not already be
+// used by another User, and returns it.
+func (s sqlite) InsertUser(name string) (*storage.User, error) {
+	err := storage.ValidateUserName(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating user name")
+	}
+	res, err := s.db.Exec(usersInsertUser, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "executing insert query")
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting id of inserted User")
+	}
+	return queryUser(s.db, usersSelectUserByID, id)
+}
+
+// SelectUsers returns all Users, sorted by their id in the DB.
+func (s sqlite) SelectUsers() (*storage.Users, error) {
+	return queryUsers(s.db, usersSelectUsers)
+}
+
+// SelectUserByName returns the User with the given name, or
+// storage.ErrNoUser if there is none.
+func (s sqlite) SelectUserByName(name string) (*storage.User, error) {
+	return queryUser(s.db, usersSelectUserByName, name)
+}
+
+// SetAccountAccess gives a User an Access to an Account, replacing any Access
+// that the User already has to it.
+func (s sqlite) SetAccountAccess(accountID, userID uint, a storage.Access) error {
+	if _, err := storage.ParseAccess(string(a)); err != nil {
+		return errors.Wrap(err, "validating access")
+	}
+	if _, err := s.SelectAccount(accountID); err != nil {
+		return errors.Wrap(err, "selecting account")
+	}
+	if _, err := queryUser(s.db, usersSelectUserByID, userID); err != nil {
+		return errors.Wrapf(err, "selecting user with id %d", userID)
+	}
+	_, err := s.db.Exec(accessSetAccess, accountID, userID, string(a))
+	return errors.Wrap(err, "executing query")
+}
+
+// SelectUserAccess returns the Access of a User to each Account that they
+// have access to, keyed by Account ID.
+func (s sqlite) SelectUserAccess(userID uint) (map[uint]storage.Access, error) {
+	rows, err := s.db.Query(accessSelectUserAccess, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	as := make(map[uint]storage.Access)
+	for rows.Next() {
+		var id uint
+		var a string
+		if err := rows.Scan(&id, &a); err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		as[id] = storage.Access(a)
+	}
+	return as, errors.Wrap(rows.Err(), "rows error")
+}
+
+// queryUser returns storage.ErrNoUser if the query returns no Users.
+func queryUser(db queryer, queryString string, values ...interface{}) (*storage.User, error) {
+	us, err := queryUsers(db, queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying users")
+	}
+	switch len(*us) {
+	case 0:
+		return nil, storage.ErrNoUser
+	case 1:
+		return &(*us)[0], nil
+	}
+	return nil, fmt.Errorf("expected 1 user but query returned %d", len(*us))
+}
+
+func queryUsers(db queryer, queryString string, values ...interface{}) (*storage.Users, error) {
+	rows, err := db.Query(queryString, values...)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying db")
+	}
+	defer nonReturningCloseRows(rows)
+	us := &storage.Users{}
+	for rows.Next() {
+		var u storage.User
+		if err := rows.Scan(&u.ID, &u.Name); err != nil {
+			return nil, errors.Wrap(err, "scanning rows")
+		}
+		*us = append(*us, u)
+	}
+	return us, errors.Wrap(rows.Err(), "rows error")
+}
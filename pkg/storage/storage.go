@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"time"
+
 	"github.com/glynternet/go-accounting/account"
 	"github.com/glynternet/go-accounting/balance"
 )
@@ -17,6 +20,50 @@ type Storage interface {
 	//
 	InsertBalance(a Account, b balance.Balance) (*Balance, error)
 	SelectAccountBalances(Account) (*Balances, error)
-	//UpdateBalance(a Account, b *Balance, us balance.Balance) error
-	//DeleteBalance(a Account, b *Balance) error
+	SelectAccountBalancesWithOptions(Account, BalancesOptions) (*Balances, error)
+	UpdateBalance(a Account, b *Balance, us balance.Balance) (*Balance, error)
+	DeleteBalance(a Account, b *Balance) error
+	//
+	InsertTransaction(t Transaction) (*Transaction, error)
+	SelectTransactions() (*Transactions, error)
+	SelectAccountTransactions(a Account) (*Transactions, error)
+	//
+	InsertRate(r Rate) (*Rate, error)
+	SelectRates() (*Rates, error)
+}
+
+// ArchiveStorage is a Storage that can also select the Accounts that have been
+// deleted and delete an Account at a given time, so that the whole of the
+// Storage can be exported and restored. InTransaction calls fn with an
+// ArchiveStorage that makes all of its changes in a single transaction, which
+// is kept if fn returns nil and discarded otherwise. The ArchiveStorage given
+// to fn must not be closed or used once fn has returned.
+type ArchiveStorage interface {
+	Storage
+	SelectAllAccounts() (*Accounts, error)
+	DeleteAccountAt(id uint, t time.Time) error
+	InTransaction(fn func(ArchiveStorage) error) error
+}
+
+// BalancesAtStorage is a Storage that can select the Balance of every Account
+// at a given time in a single operation. The Balance of an Account at a time is
+// its latest Balance that is at or before the time, and the Balance with the
+// greatest ID when an Account has multiple Balances on that date. Balances are
+// keyed by the ID of their Account, and Accounts without a Balance at or before
+// the time have no entry. SelectBalancesAtContext is the same as
+// SelectBalancesAt but can be cancelled with the given context.Context.
+type BalancesAtStorage interface {
+	Storage
+	SelectBalancesAt(t time.Time) (map[uint]Balance, error)
+	SelectBalancesAtContext(ctx context.Context, t time.Time) (map[uint]Balance, error)
+}
+
+// SchemaStorage is a Storage with a versioned schema, which can check that its
+// schema is at the version that it requires. CheckSchemaVersionContext is the
+// same as CheckSchemaVersion but can be cancelled with the given
+// context.Context.
+type SchemaStorage interface {
+	Storage
+	CheckSchemaVersion() error
+	CheckSchemaVersionContext(ctx context.Context) error
 }
@@ -2,6 +2,8 @@
 package storagetest
 
 import (
+	"time"
+
 	"github.com/glynternet/go-accounting/account"
 	"github.com/glynternet/go-accounting/balance"
 	"github.com/glynternet/mon/pkg/storage"
@@ -22,6 +24,18 @@ type Storage struct {
 
 	*storage.Balances
 	BalancesErr error
+
+	*storage.Transaction
+	TransactionErr error
+
+	*storage.Transactions
+	TransactionsErr error
+
+	*storage.Rate
+	RateErr error
+
+	*storage.Rates
+	RatesErr error
 }
 
 // Available stubs storage.Available method
@@ -49,6 +63,16 @@ func (s *Storage) SelectAccounts() (*storage.Accounts, error) { return s.Account
 // DeleteAccount stubs the storage.DeleteAccount method
 func (s *Storage) DeleteAccount(uint) error { return s.AccountErr }
 
+// SelectAllAccounts stubs the storage.ArchiveStorage SelectAllAccounts method
+func (s *Storage) SelectAllAccounts() (*storage.Accounts, error) { return s.Accounts, s.Err }
+
+// DeleteAccountAt stubs the storage.ArchiveStorage DeleteAccountAt method
+func (s *Storage) DeleteAccountAt(uint, time.Time) error { return s.AccountErr }
+
+// InTransaction stubs the storage.ArchiveStorage InTransaction method by
+// calling fn with the Storage
+func (s *Storage) InTransaction(fn func(storage.ArchiveStorage) error) error { return fn(s) }
+
 // InsertBalance stubs the storage.InsertBalance method
 func (s *Storage) InsertBalance(storage.Account, balance.Balance) (*storage.Balance, error) {
 	return s.Balance, s.BalanceErr
@@ -58,3 +82,44 @@ func (s *Storage) InsertBalance(storage.Account, balance.Balance) (*storage.Bala
 func (s *Storage) SelectAccountBalances(storage.Account) (*storage.Balances, error) {
 	return s.Balances, s.BalancesErr
 }
+
+// SelectAccountBalancesWithOptions stubs the
+// storage.SelectAccountBalancesWithOptions method
+func (s *Storage) SelectAccountBalancesWithOptions(storage.Account, storage.BalancesOptions) (*storage.Balances, error) {
+	return s.Balances, s.BalancesErr
+}
+
+// UpdateBalance stubs the storage.UpdateBalance method
+func (s *Storage) UpdateBalance(storage.Account, *storage.Balance, balance.Balance) (*storage.Balance, error) {
+	return s.Balance, s.BalanceErr
+}
+
+// DeleteBalance stubs the storage.DeleteBalance method
+func (s *Storage) DeleteBalance(storage.Account, *storage.Balance) error {
+	return s.BalanceErr
+}
+
+// InsertTransaction stubs the storage.InsertTransaction method
+func (s *Storage) InsertTransaction(storage.Transaction) (*storage.Transaction, error) {
+	return s.Transaction, s.TransactionErr
+}
+
+// SelectTransactions stubs the storage.SelectTransactions method
+func (s *Storage) SelectTransactions() (*storage.Transactions, error) {
+	return s.Transactions, s.TransactionsErr
+}
+
+// SelectAccountTransactions stubs the storage.SelectAccountTransactions method
+func (s *Storage) SelectAccountTransactions(storage.Account) (*storage.Transactions, error) {
+	return s.Transactions, s.TransactionsErr
+}
+
+// InsertRate stubs the storage.InsertRate method
+func (s *Storage) InsertRate(storage.Rate) (*storage.Rate, error) {
+	return s.Rate, s.RateErr
+}
+
+// SelectRates stubs the storage.SelectRates method
+func (s *Storage) SelectRates() (*storage.Rates, error) {
+	return s.Rates, s.RatesErr
+}
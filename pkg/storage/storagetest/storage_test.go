@@ -6,3 +6,7 @@ import "github.com/glynternet/mon/pkg/storage"
 // variable and, therefore, ensures that *Storage satisfies the storage.Storage
 // interface
 var _ storage.Storage = &Storage{}
+
+// This line ensures that *Storage also satisfies the storage.ArchiveStorage
+// interface
+var _ storage.ArchiveStorage = &Storage{}
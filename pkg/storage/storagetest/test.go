@@ -1,6 +1,7 @@
 package storagetest
 
 import (
+	"context"
 	"strconv"
 	"testing"
 	"time"
@@ -9,12 +10,16 @@ import (
 	"github.com/glynternet/go-accounting/accountingtest"
 	"github.com/glynternet/go-accounting/balance"
 	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/date"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
-const numOfAccounts = 2
+const (
+	numOfAccounts = 2
+	day           = 24 * time.Hour
+)
 
 // Test will run a suite of tests again a given Storage
 func Test(t *testing.T, store storage.Storage) {
@@ -34,10 +39,50 @@ func Test(t *testing.T, store storage.Storage) {
 			title: "update account",
 			run:   updateAccounts,
 		},
+		{
+			title: "update balances",
+			run:   updateBalances,
+		},
+		{
+			title: "delete balances",
+			run:   deleteBalances,
+		},
+		{
+			title: "insert and select transactions",
+			run:   insertAndSelectTransactions,
+		},
+		{
+			title: "insert and select rates",
+			run:   insertAndSelectRates,
+		},
 		{
 			title: "insert and delete accounts",
 			run:   insertAndDeleteAccounts,
 		},
+		{
+			title: "archive deleted accounts",
+			run:   archiveDeletedAccounts,
+		},
+		{
+			title: "archive in transaction",
+			run:   archiveInTransaction,
+		},
+		{
+			title: "select balances at time",
+			run:   selectBalancesAt,
+		},
+		{
+			title: "select balances with options",
+			run:   selectBalancesWithOptions,
+		},
+		{
+			title: "insert, select and revoke tokens",
+			run:   insertSelectAndRevokeTokens,
+		},
+		{
+			title: "insert users and set account access",
+			run:   insertUsersAndSetAccountAccess,
+		},
 	}
 	for _, test := range tests {
 		success := t.Run(test.title, func(t *testing.T) {
@@ -177,7 +222,7 @@ func insertAndRetrieveBalances(t *testing.T, store storage.Storage) {
 		assert.Len(t, *bs, 1)
 		abs[i].Balances = *bs
 
-		invalidBalance, err := balance.New(abs[i].Account.Account.Opened().Add(-time.Second))
+		invalidBalance, err := balance.New(abs[i].Account.Account.Opened().Add(-day))
 		common.FatalIfError(t, err, "creating new invalid Balance")
 		inserted, err = store.InsertBalance(abs[i].Account, *invalidBalance)
 		if !assert.Error(t, err, "inserting Balance") {
@@ -194,16 +239,11 @@ func updateAccounts(t *testing.T, store storage.Storage) {
 		inserted, err := store.InsertAccount(*initial)
 		common.FatalIfError(t, err, "inserting account to store")
 
-		// Here we truncate to the closest second to avoid the issue where
-		// postgres stores times down to only the closest millisecond or so
-		// TODO: Sort out rounding of times logic and document it properly. For
-		// TODO: the moment, it is assumed that accounts won't be updated and
-		// TODO: then compared against their original down to such a fine grain
 		updates := accountingtest.NewAccount(t,
 			"B",
 			accountingtest.NewCurrencyCode(t, "GBP"),
-			time.Now().Truncate(time.Second),
-			account.CloseTime(time.Now().Add(24*time.Hour).Truncate(time.Second)),
+			time.Now(),
+			account.CloseTime(time.Now().Add(24*time.Hour)),
 		)
 
 		updatedA, err := store.UpdateAccount(inserted, updates)
@@ -212,62 +252,64 @@ func updateAccounts(t *testing.T, store storage.Storage) {
 			t.FailNow()
 		}
 		assert.Equal(t, updatedA.ID, inserted.ID)
-		assert.True(t,
-			updatedA.Account.Equal(*updates),
-			"inserted: %+v\nupdates: %+v\nupdatedA: %+v", inserted.Account, updates, updatedA.Account,
-		)
+		assertAccountDatedEqual(t, *updates, updatedA.Account)
 	})
 
 	t.Run("valid with balances", func(t *testing.T) {
 		inserted, err := store.InsertAccount(*initial)
 		common.FatalIfError(t, err, "inserting account to store")
 
-		for _, b := range newTestBalances(t, 10, inserted.Account.Opened(), time.Hour) {
+		for _, b := range newTestBalances(t, 10, inserted.Account.Opened(), day) {
 			_, err := store.InsertBalance(*inserted, b)
 			common.FatalIfError(t, err, "inserting balance")
 		}
 
-		// Here we truncate to the closest second to avoid the issue where
-		// postgres stores times down to only the closest millisecond or so
-		// TODO: Sort out rounding of times logic and document it properly. For
-		// TODO: the moment, it is assumed that accounts won't be updated and
-		// TODO: then compared against their original down to such a fine grain
 		updates := accountingtest.NewAccount(t,
 			"B",
 			accountingtest.NewCurrencyCode(t, "GBP"),
-			time.Now().Add(-time.Hour).Truncate(time.Second),
-			account.CloseTime(time.Now().Add(200*time.Hour).Truncate(time.Second)),
+			time.Now().Add(-day),
+			account.CloseTime(time.Now().Add(20*day)),
 		)
 
 		updatedA, err := store.UpdateAccount(inserted, updates)
 		common.FatalIfError(t, err, "updating account")
 		assert.Equal(t, updatedA.ID, inserted.ID)
-		assert.True(t,
-			updatedA.Account.Equal(*updates),
-			"inserted: %+v\nupdates: %+v\nupdatedA: %+v",
-			inserted.Account, updates, updatedA.Account,
+		assertAccountDatedEqual(t, *updates, updatedA.Account)
+	})
+
+	t.Run("valid closing on the date of the latest balance", func(t *testing.T) {
+		inserted, err := store.InsertAccount(*initial)
+		common.FatalIfError(t, err, "inserting account to store")
+
+		_, err = store.InsertBalance(*inserted, newTestBalance(t, time.Now()))
+		common.FatalIfError(t, err, "inserting balance")
+
+		updates := accountingtest.NewAccount(t,
+			"B",
+			accountingtest.NewCurrencyCode(t, "GBP"),
+			inserted.Account.Opened(),
+			account.CloseTime(inserted.Account.Opened()),
 		)
+
+		updatedA, err := store.UpdateAccount(inserted, updates)
+		common.FatalIfError(t, err, "updating account")
+		assertAccountDatedEqual(t, *updates, updatedA.Account)
 	})
 
 	t.Run("invalid with balances", func(t *testing.T) {
 		inserted, err := store.InsertAccount(*initial)
 		common.FatalIfError(t, err, "inserting account to store")
 
-		for _, b := range newTestBalances(t, 10, inserted.Account.Opened(), time.Hour) {
+		for _, b := range newTestBalances(t, 10, inserted.Account.Opened(), day) {
 			_, err := store.InsertBalance(*inserted, b)
 			common.FatalIfError(t, err, "inserting balance")
 		}
 
-		// Here we truncate to the closest second to avoid the issue where
-		// postgres stores times down to only the closest millisecond or so
-		// TODO: Sort out rounding of times logic and document it properly. For
-		// TODO: the moment, it is assumed that accounts won't be updated and
-		// TODO: then compared against their original down to such a fine grain
 		updates := accountingtest.NewAccount(t,
 			"B",
 			accountingtest.NewCurrencyCode(t, "GBP"),
-			time.Now().Add(-time.Hour).Truncate(time.Second),
-			account.CloseTime(time.Now().Add(time.Hour).Truncate(time.Second)),
+			time.Now().Add(-day),
+			account.CloseTime(time.Now().Add(day)),
 		)
 
 		updatedA, err := store.UpdateAccount(inserted, updates)
@@ -276,6 +318,196 @@ func updateAccounts(t *testing.T, store storage.Storage) {
 	})
 }
 
+func updateBalances(t *testing.T, store storage.Storage) {
+	a := accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "YEN"), time.Now())
+	inserted, err := store.InsertAccount(*a)
+	common.FatalIfError(t, err, "inserting account")
+
+	for _, b := range newTestBalances(t, 3, inserted.Account.Opened(), day) {
+		_, err := store.InsertBalance(*inserted, b)
+		common.FatalIfError(t, err, "inserting balance")
+	}
+	bs, err := store.SelectAccountBalances(*inserted)
+	common.FatalIfError(t, err, "selecting account balances")
+	original := (*bs)[0]
+
+	t.Run("valid", func(t *testing.T) {
+		us := newTestBalance(t,
+			inserted.Account.Opened().Add(10*day),
+			balance.Amount(987),
+		)
+		updated, err := store.UpdateBalance(*inserted, &original, us)
+		common.FatalIfError(t, err, "updating balance")
+		if !assert.NotNil(t, updated) {
+			t.FailNow()
+		}
+		assert.Equal(t, original.ID, updated.ID)
+		assert.True(t, us.Equal(updated.Balance), "updates: %+v\nupdated: %+v", us, updated.Balance)
+
+		after, err := store.SelectAccountBalances(*inserted)
+		common.FatalIfError(t, err, "selecting account balances")
+		if !assert.Len(t, *after, len(*bs)) {
+			t.FailNow()
+		}
+		last := (*after)[len(*after)-1]
+		assert.Equal(t, original.ID, last.ID, "updated balance should be ordered chronologically")
+		assert.True(t, us.Equal(last.Balance))
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		us := newTestBalance(t, inserted.Account.Opened().Add(-day))
+		updated, err := store.UpdateBalance(*inserted, &original, us)
+		assert.Error(t, err)
+		assert.Nil(t, updated)
+	})
+
+	t.Run("nonexistent balance", func(t *testing.T) {
+		us := newTestBalance(t, inserted.Account.Opened())
+		updated, err := store.UpdateBalance(*inserted, &storage.Balance{ID: 999999}, us)
+		assert.Error(t, err)
+		assert.Nil(t, updated)
+	})
+}
+
+func deleteBalances(t *testing.T, store storage.Storage) {
+	a := accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "YEN"), time.Now())
+	inserted, err := store.InsertAccount(*a)
+	common.FatalIfError(t, err, "inserting account")
+
+	const numInserted = 3
+	for _, b := range newTestBalances(t, numInserted, inserted.Account.Opened(), day) {
+		_, err := store.InsertBalance(*inserted, b)
+		common.FatalIfError(t, err, "inserting balance")
+	}
+	bs, err := store.SelectAccountBalances(*inserted)
+	common.FatalIfError(t, err, "selecting account balances")
+
+	for i, b := range *bs {
+		b := b
+		t.Run("deleting balance (i:"+strconv.Itoa(i)+") should reduce balances count by 1", func(t *testing.T) {
+			err := store.DeleteBalance(*inserted, &b)
+			common.FatalIfError(t, err, "deleting balance")
+			after, err := store.SelectAccountBalances(*inserted)
+			common.FatalIfError(t, err, "selecting account balances")
+			assert.Len(t, *after, numInserted-(i+1))
+			for _, ab := range *after {
+				assert.NotEqual(t, b.ID, ab.ID)
+			}
+		})
+
+		t.Run("deleting same balance should return error", func(t *testing.T) {
+			err := store.DeleteBalance(*inserted, &b)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func insertAndSelectTransactions(t *testing.T, store storage.Storage) {
+	before, err := store.SelectTransactions()
+	common.FatalIfError(t, err, "selecting transactions")
+
+	var as [3]*storage.Account
+	for i, cc := range []string{"GBP", "GBP", "EUR"} {
+		a := accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, cc), time.Now())
+		as[i], err = store.InsertAccount(*a)
+		common.FatalIfError(t, err, "inserting account")
+	}
+	source, destination, other := as[0], as[1], as[2]
+	opened := date.FromTime(source.Account.Opened())
+
+	var inserted storage.Transactions
+	for _, tx := range []storage.Transaction{
+		{Date: opened.AddDays(2), Amount: 30, Description: "later", SourceID: source.ID, DestinationID: destination.ID},
+		{Date: opened, Amount: 10, Description: "earlier", SourceID: destination.ID, DestinationID: source.ID},
+		{Date: opened.AddDays(2), Amount: 20, SourceID: source.ID, DestinationID: destination.ID},
+	} {
+		i, err := store.InsertTransaction(tx)
+		common.FatalIfError(t, err, "inserting transaction")
+		tx.ID = i.ID
+		assert.True(t, tx.Equal(*i), "expected: %+v\ninserted: %+v", tx, *i)
+		inserted = append(inserted, *i)
+	}
+
+	t.Run("account transactions are ordered chronologically", func(t *testing.T) {
+		for _, a := range []*storage.Account{source, destination} {
+			ts, err := store.SelectAccountTransactions(*a)
+			common.FatalIfError(t, err, "selecting account transactions")
+			assert.Equal(t, storage.Transactions{inserted[1], inserted[0], inserted[2]}, *ts)
+		}
+		ts, err := store.SelectAccountTransactions(*other)
+		common.FatalIfError(t, err, "selecting account transactions")
+		assert.Len(t, *ts, 0)
+	})
+
+	t.Run("transactions adjust both accounts", func(t *testing.T) {
+		ts, err := store.SelectTransactions()
+		common.FatalIfError(t, err, "selecting transactions")
+		assert.Len(t, *ts, len(*before)+len(inserted))
+		from, to := opened.AddDays(-1), opened.AddDays(2)
+		assert.Equal(t, 10-30-20, ts.Delta(source.ID, from, to))
+		assert.Equal(t, -10+30+20, ts.Delta(destination.ID, from, to))
+	})
+
+	for _, test := range []struct {
+		name string
+		storage.Transaction
+	}{
+		{
+			name:        "different currencies",
+			Transaction: storage.Transaction{Date: opened, Amount: 1, SourceID: source.ID, DestinationID: other.ID},
+		},
+		{
+			name:        "same account",
+			Transaction: storage.Transaction{Date: opened, Amount: 1, SourceID: source.ID, DestinationID: source.ID},
+		},
+		{
+			name:        "before account opened",
+			Transaction: storage.Transaction{Date: opened.AddDays(-1), Amount: 1, SourceID: source.ID, DestinationID: destination.ID},
+		},
+		{
+			name:        "nonexistent account",
+			Transaction: storage.Transaction{Date: opened, Amount: 1, SourceID: source.ID, DestinationID: 999999},
+		},
+	} {
+		t.Run("invalid transaction "+test.name, func(t *testing.T) {
+			i, err := store.InsertTransaction(test.Transaction)
+			assert.Error(t, err)
+			assert.Nil(t, i)
+		})
+	}
+}
+
+func insertAndSelectRates(t *testing.T, store storage.Storage) {
+	before, err := store.SelectRates()
+	common.FatalIfError(t, err, "selecting rates")
+
+	d := date.Today()
+	var inserted storage.Rates
+	for _, r := range []storage.Rate{
+		{Date: d, From: "EUR", To: "GBP", Rate: 0.875},
+		{Date: d.AddDays(-1), From: "USD", To: "GBP", Rate: 0.75},
+	} {
+		i, err := store.InsertRate(r)
+		common.FatalIfError(t, err, "inserting rate")
+		r.ID = i.ID
+		assert.True(t, r.Equal(*i), "expected: %+v\ninserted: %+v", r, *i)
+		inserted = append(inserted, *i)
+	}
+
+	rs, err := store.SelectRates()
+	common.FatalIfError(t, err, "selecting rates")
+	if !assert.Len(t, *rs, len(*before)+len(inserted)) {
+		t.FailNow()
+	}
+	assert.Equal(t, storage.Rates{inserted[1], inserted[0]}, (*rs)[len(*before):], "rates should be ordered chronologically")
+
+	t.Run("invalid rate", func(t *testing.T) {
+		i, err := store.InsertRate(storage.Rate{Date: d, From: "EUR", To: "EUR", Rate: 1})
+		assert.Error(t, err)
+		assert.Nil(t, i)
+	})
+}
+
 func insertAndDeleteAccounts(t *testing.T, store storage.Storage) {
 	selectedBefore := selectAccounts(t, store)
 
@@ -346,6 +578,18 @@ func insertAndDeleteAccounts(t *testing.T, store storage.Storage) {
 	})
 }
 
+// assertAccountDatedEqual asserts that an account.Account held by a Storage is
+// equal to the account.Account that it was created from, once the times of the
+// original have been truncated to dates.
+func assertAccountDatedEqual(t *testing.T, original, stored account.Account) {
+	expected, err := storage.DateAccount(original)
+	common.FatalIfError(t, err, "dating account")
+	assert.True(t,
+		stored.Equal(*expected),
+		"expected: %+v\nstored: %+v", *expected, stored,
+	)
+}
+
 func selectAccounts(t *testing.T, store storage.Storage) *storage.Accounts {
 	as, err := store.SelectAccounts()
 	common.FatalIfError(t, err, "selecting accounts after inserting one")
@@ -367,3 +611,286 @@ func newTestBalances(
 	}
 	return bs
 }
+
+// archiveDeletedAccounts tests the methods of a storage.ArchiveStorage, if the
+// store is one.
+func archiveDeletedAccounts(t *testing.T, store storage.Storage) {
+	archive, ok := store.(storage.ArchiveStorage)
+	if !ok {
+		t.Skip("store is not a storage.ArchiveStorage")
+	}
+	a, err := store.InsertAccount(*accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "GBP"), time.Now()))
+	common.FatalIfError(t, err, "inserting account")
+	deletedAt := time.Date(2018, time.May, 4, 12, 0, 0, 0, time.UTC)
+	common.FatalIfError(t, archive.DeleteAccountAt(a.ID, deletedAt), "deleting account")
+	assert.Error(t, archive.DeleteAccountAt(a.ID, deletedAt), "deleting a deleted account should return an error")
+
+	undeleted, err := store.SelectAccounts()
+	common.FatalIfError(t, err, "selecting accounts")
+	all, err := archive.SelectAllAccounts()
+	common.FatalIfError(t, err, "selecting all accounts")
+	if !assert.Len(t, *all, len(*undeleted)+countDeleted(*all)) {
+		t.FailNow()
+	}
+	last := (*all)[len(*all)-1]
+	assert.Equal(t, a.ID, last.ID, "all accounts should be ordered by ID")
+	at, deleted := last.DeletedTime()
+	assert.True(t, deleted)
+	assert.True(t, deletedAt.Equal(at), "expected deleted at %s but got %s", deletedAt, at)
+}
+
+// archiveInTransaction tests that the changes made in a transaction of a
+// storage.ArchiveStorage are only kept when the transaction succeeds, if the
+// store is one.
+func archiveInTransaction(t *testing.T, store storage.Storage) {
+	archive, ok := store.(storage.ArchiveStorage)
+	if !ok {
+		t.Skip("store is not a storage.ArchiveStorage")
+	}
+	before, err := archive.SelectAllAccounts()
+	common.FatalIfError(t, err, "selecting all accounts")
+	insert := func(tx storage.ArchiveStorage) (*storage.Account, error) {
+		a, err := tx.InsertAccount(*accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "GBP"), time.Now()))
+		if err != nil {
+			return nil, errors.Wrap(err, "inserting account")
+		}
+		_, err = tx.InsertBalance(*a, balance.Balance{Date: time.Now(), Amount: 1})
+		return a, errors.Wrap(err, "inserting balance")
+	}
+
+	expected := errors.New("transaction error")
+	err = archive.InTransaction(func(tx storage.ArchiveStorage) error {
+		if _, err := insert(tx); err != nil {
+			return err
+		}
+		return expected
+	})
+	assert.Equal(t, expected, errors.Cause(err))
+	after, err := archive.SelectAllAccounts()
+	common.FatalIfError(t, err, "selecting all accounts")
+	assert.Len(t, *after, len(*before), "changes of a failed transaction should be discarded")
+
+	var inserted *storage.Account
+	err = archive.InTransaction(func(tx storage.ArchiveStorage) error {
+		var err error
+		inserted, err = insert(tx)
+		return err
+	})
+	common.FatalIfError(t, err, "running transaction")
+	after, err = archive.SelectAllAccounts()
+	common.FatalIfError(t, err, "selecting all accounts")
+	assert.Len(t, *after, len(*before)+1, "changes of a successful transaction should be kept")
+	bs, err := store.SelectAccountBalances(*inserted)
+	common.FatalIfError(t, err, "selecting balances")
+	assert.Len(t, *bs, 1)
+}
+
+// selectBalancesAt tests the method of a storage.BalancesAtStorage, if the
+// store is one.
+func selectBalancesAt(t *testing.T, store storage.Storage) {
+	balancesAt, ok := store.(storage.BalancesAtStorage)
+	if !ok {
+		t.Skip("store is not a storage.BalancesAtStorage")
+	}
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	insertAccount := func(name string) *storage.Account {
+		a, err := store.InsertAccount(*accountingtest.NewAccount(t, name, accountingtest.NewCurrencyCode(t, "GBP"), opened))
+		common.FatalIfError(t, err, "inserting account")
+		return a
+	}
+	insertBalance := func(a *storage.Account, d time.Time, amount int) *storage.Balance {
+		b, err := store.InsertBalance(*a, balance.Balance{Date: d, Amount: amount})
+		common.FatalIfError(t, err, "inserting balance")
+		return b
+	}
+
+	a := insertAccount("A")
+	insertBalance(a, opened, 1)
+	tied := insertBalance(a, opened.Add(10*day), 2)
+	latest := insertBalance(a, opened.Add(10*day), 3)
+	insertBalance(a, opened.Add(20*day), 4)
+	b := insertAccount("B")
+	insertBalance(b, opened.Add(15*day), 5)
+	withoutBalances := insertAccount("C")
+
+	bs, err := balancesAt.SelectBalancesAt(opened.Add(12 * day))
+	common.FatalIfError(t, err, "selecting balances at time")
+	assert.True(t, latest.ID > tied.ID)
+	assert.Equal(t, latest.ID, bs[a.ID].ID, "the balance with the greatest ID should be selected for a date")
+	assert.Equal(t, 3, bs[a.ID].Amount)
+	assert.True(t, opened.Add(10*day).Equal(bs[a.ID].Date))
+	_, ok = bs[b.ID]
+	assert.False(t, ok, "an account with only later balances should have no entry")
+	_, ok = bs[withoutBalances.ID]
+	assert.False(t, ok, "an account without balances should have no entry")
+
+	bs, err = balancesAt.SelectBalancesAt(opened.Add(15*day + 12*time.Hour))
+	common.FatalIfError(t, err, "selecting balances at time")
+	assert.Equal(t, 3, bs[a.ID].Amount)
+	assert.Equal(t, 5, bs[b.ID].Amount, "a balance on the date of the time should be selected")
+
+	bs, err = balancesAt.SelectBalancesAtContext(context.Background(), opened.Add(12*day))
+	common.FatalIfError(t, err, "selecting balances at time with context")
+	assert.Equal(t, 3, bs[a.ID].Amount)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bs, err = balancesAt.SelectBalancesAtContext(ctx, opened.Add(12*day))
+	assert.Equal(t, context.Canceled, errors.Cause(err))
+	assert.Nil(t, bs)
+}
+
+func selectBalancesWithOptions(t *testing.T, store storage.Storage) {
+	opened := date.New(2018, time.May, 4)
+	a, err := store.InsertAccount(*accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, "GBP"), opened.Time()))
+	common.FatalIfError(t, err, "inserting account")
+	var inserted storage.Balances
+	for _, days := range []int{3, 0, 1, 1, 2} {
+		b, err := store.InsertBalance(*a, balance.Balance{Date: opened.AddDays(days).Time(), Amount: days})
+		common.FatalIfError(t, err, "inserting balance")
+		inserted = append(inserted, *b)
+	}
+	// ordered by date and then by ID
+	ordered := storage.Balances{inserted[1], inserted[2], inserted[3], inserted[4], inserted[0]}
+
+	from, to := opened.AddDays(1), opened.AddDays(2)
+	after := inserted[2].Cursor()
+	for _, o := range []storage.BalancesOptions{
+		{},
+		{From: &from},
+		{To: &to},
+		{From: &from, To: &to},
+		{Limit: 2},
+		{Descending: true},
+		{Descending: true, Limit: 3},
+		{After: &after},
+		{After: &after, Limit: 1},
+		{After: &after, Descending: true},
+		{From: &from, After: &after, Descending: true},
+	} {
+		bs, err := store.SelectAccountBalancesWithOptions(*a, o)
+		common.FatalIfError(t, err, "selecting balances with options")
+		expected := o.Select(ordered)
+		if assert.Len(t, *bs, len(expected), "%+v", o) {
+			for i := range expected {
+				assert.True(t, expected[i].Equal((*bs)[i]), "%+v: expected %+v but got %+v", o, expected[i], (*bs)[i])
+			}
+		}
+	}
+}
+
+// insertSelectAndRevokeTokens tests the methods of a storage.TokenStorage, if
+// the store is one.
+func insertSelectAndRevokeTokens(t *testing.T, store storage.Storage) {
+	tokens, ok := store.(storage.TokenStorage)
+	if !ok {
+		t.Skip("store is not a storage.TokenStorage")
+	}
+	_, err := tokens.InsertToken(" ", storage.HashTokenSecret("empty"), 0)
+	assert.Error(t, err, "inserting a token without a name should return an error")
+
+	before := time.Now().Add(-time.Minute)
+	a, err := tokens.InsertToken("laptop", storage.HashTokenSecret("mon_a"), 0)
+	common.FatalIfError(t, err, "inserting token")
+	b, err := tokens.InsertToken("phone", storage.HashTokenSecret("mon_b"), 0)
+	common.FatalIfError(t, err, "inserting token")
+	assert.Equal(t, "laptop", a.Name)
+	assert.Equal(t, storage.HashTokenSecret("mon_a"), a.Hash)
+	assert.True(t, a.Created.After(before), "token should be created at the current time")
+	assert.Nil(t, a.Revoked)
+	assert.Zero(t, a.UserID)
+	assert.True(t, b.ID > a.ID)
+
+	selected, err := tokens.SelectTokenByHash(storage.HashTokenSecret("mon_b"))
+	common.FatalIfError(t, err, "selecting token by hash")
+	assert.Equal(t, b.ID, selected.ID)
+	assert.Equal(t, "phone", selected.Name)
+	_, err = tokens.SelectTokenByHash(storage.HashTokenSecret("mon_c"))
+	assert.Equal(t, storage.ErrNoToken, errors.Cause(err))
+
+	common.FatalIfError(t, tokens.RevokeToken(a.ID), "revoking token")
+	assert.Error(t, tokens.RevokeToken(a.ID), "revoking a revoked token should return an error")
+	assert.Error(t, tokens.RevokeToken(b.ID+1), "revoking a nonexistent token should return an error")
+	selected, err = tokens.SelectTokenByHash(storage.HashTokenSecret("mon_a"))
+	common.FatalIfError(t, err, "selecting revoked token by hash")
+	assert.NotNil(t, selected.Revoked)
+
+	ts, err := tokens.SelectTokens()
+	common.FatalIfError(t, err, "selecting tokens")
+	if assert.Len(t, *ts, 2) {
+		assert.Equal(t, a.ID, (*ts)[0].ID, "tokens should be ordered by ID")
+		assert.NotNil(t, (*ts)[0].Revoked)
+		assert.Equal(t, b.ID, (*ts)[1].ID)
+		assert.Nil(t, (*ts)[1].Revoked)
+	}
+}
+
+// insertUsersAndSetAccountAccess tests the methods of a storage.UserStorage,
+// if the store is one.
+func insertUsersAndSetAccountAccess(t *testing.T, store storage.Storage) {
+	users, ok := store.(storage.UserStorage)
+	if !ok {
+		t.Skip("store is not a storage.UserStorage")
+	}
+	_, err := users.InsertUser("")
+	assert.Error(t, err, "inserting a user without a name should return an error")
+	_, err = users.InsertUser("has space")
+	assert.Error(t, err, "inserting a user with whitespace in their name should return an error")
+
+	alice, err := users.InsertUser("alice")
+	common.FatalIfError(t, err, "inserting user")
+	bob, err := users.InsertUser("bob")
+	common.FatalIfError(t, err, "inserting user")
+	assert.Equal(t, "alice", alice.Name)
+	assert.True(t, bob.ID > alice.ID)
+	_, err = users.InsertUser("alice")
+	assert.Error(t, err, "inserting a user with an existing name should return an error")
+
+	selected, err := users.SelectUserByName("bob")
+	common.FatalIfError(t, err, "selecting user by name")
+	assert.Equal(t, *bob, *selected)
+	_, err = users.SelectUserByName("carol")
+	assert.Equal(t, storage.ErrNoUser, errors.Cause(err))
+
+	us, err := users.SelectUsers()
+	common.FatalIfError(t, err, "selecting users")
+	assert.Equal(t, storage.Users{*alice, *bob}, *us)
+
+	token, err := users.InsertToken("alice laptop", storage.HashTokenSecret("mon_alice"), alice.ID)
+	common.FatalIfError(t, err, "inserting token for user")
+	assert.Equal(t, alice.ID, token.UserID)
+	selectedToken, err := users.SelectTokenByHash(storage.HashTokenSecret("mon_alice"))
+	common.FatalIfError(t, err, "selecting token by hash")
+	assert.Equal(t, alice.ID, selectedToken.UserID)
+
+	a, err := users.InsertAccount(*accountingtest.NewAccount(t, "shared", accountingtest.NewCurrencyCode(t, "GBP"), time.Now()))
+	common.FatalIfError(t, err, "inserting account")
+	b, err := users.InsertAccount(*accountingtest.NewAccount(t, "private", accountingtest.NewCurrencyCode(t, "GBP"), time.Now()))
+	common.FatalIfError(t, err, "inserting account")
+	common.FatalIfError(t, users.SetAccountAccess(a.ID, alice.ID, storage.AccessOwner), "setting access")
+	common.FatalIfError(t, users.SetAccountAccess(b.ID, alice.ID, storage.AccessOwner), "setting access")
+	common.FatalIfError(t, users.SetAccountAccess(a.ID, bob.ID, storage.AccessRead), "setting access")
+	common.FatalIfError(t, users.SetAccountAccess(a.ID, bob.ID, storage.AccessWrite), "replacing access")
+	assert.Error(t, users.SetAccountAccess(a.ID, bob.ID, storage.Access("admin")), "setting an unknown access should return an error")
+	assert.Error(t, users.SetAccountAccess(b.ID+1, bob.ID, storage.AccessRead), "setting access to a nonexistent account should return an error")
+
+	as, err := users.SelectUserAccess(alice.ID)
+	common.FatalIfError(t, err, "selecting user access")
+	assert.Equal(t, map[uint]storage.Access{a.ID: storage.AccessOwner, b.ID: storage.AccessOwner}, as)
+	as, err = users.SelectUserAccess(bob.ID)
+	common.FatalIfError(t, err, "selecting user access")
+	assert.Equal(t, map[uint]storage.Access{a.ID: storage.AccessWrite}, as)
+	as, err = users.SelectUserAccess(bob.ID + 1)
+	common.FatalIfError(t, err, "selecting access of nonexistent user")
+	assert.Empty(t, as)
+}
+
+func countDeleted(as storage.Accounts) int {
+	var n int
+	for _, a := range as {
+		if a.Deleted() {
+			n++
+		}
+	}
+	return n
+}
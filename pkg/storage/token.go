@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TokenSecretPrefix is the prefix of every Token secret, which makes secrets
+// easy to recognise.
+const TokenSecretPrefix = "mon_"
+
+// ErrNoToken is returned by a TokenStorage when it holds no Token that
+// matches a lookup.
+var ErrNoToken = errors.New("no such token")
+
+// Token is an API token that authenticates requests to the server. Only the
+// hash of the secret of a Token is stored, so the secret cannot be recovered
+// from the Storage. UserID is the ID of the User that the Token authenticates,
+// or 0 for a Token that is not for any User and so can see every Account.
+// Revoked is nil while the Token can still be used.
+type Token struct {
+	ID      uint
+	Name    string
+	Hash    string
+	UserID  uint
+	Created time.Time
+	Revoked *time.Time
+}
+
+// Tokens holds multiple Token items
+type Tokens []Token
+
+// TokenStorage is a Storage that can also store Tokens. SelectTokenByHash
+// returns ErrNoToken when no Token has the hash, and RevokeToken returns an
+// error when no Token has the id or the Token has already been revoked.
+type TokenStorage interface {
+	Storage
+	InsertToken(name, hash string, userID uint) (*Token, error)
+	SelectTokens() (*Tokens, error)
+	SelectTokenByHash(hash string) (*Token, error)
+	RevokeToken(id uint) error
+}
+
+// NewTokenSecret generates a random secret for a new Token
+func NewTokenSecret() (string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", errors.Wrap(err, "generating random bytes")
+	}
+	return TokenSecretPrefix + hex.EncodeToString(bs), nil
+}
+
+// HashTokenSecret returns the hash of a Token secret, which is what is held
+// by a TokenStorage in place of the secret.
+func HashTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateTokenName returns an error if a Token name is empty or only holds
+// whitespace.
+func ValidateTokenName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("token name must not be empty")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glynternet/go-money/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTokenSecret(t *testing.T) {
+	a, err := NewTokenSecret()
+	common.FatalIfError(t, err, "generating secret")
+	b, err := NewTokenSecret()
+	common.FatalIfError(t, err, "generating secret")
+	assert.True(t, strings.HasPrefix(a, TokenSecretPrefix))
+	assert.Len(t, a, len(TokenSecretPrefix)+64)
+	assert.NotEqual(t, a, b)
+}
+
+func TestHashTokenSecret(t *testing.T) {
+	assert.Equal(t, HashTokenSecret("mon_abc"), HashTokenSecret("mon_abc"))
+	assert.NotEqual(t, HashTokenSecret("mon_abc"), HashTokenSecret("mon_abd"))
+	assert.Len(t, HashTokenSecret("mon_abc"), 64)
+	assert.NotContains(t, HashTokenSecret("mon_abc"), "abc")
+}
+
+func TestValidateTokenName(t *testing.T) {
+	assert.NoError(t, ValidateTokenName("laptop"))
+	assert.Error(t, ValidateTokenName(""))
+	assert.Error(t, ValidateTokenName(" \t"))
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/pkg/errors"
+)
+
+// Transaction is a movement of an amount of money from a source Account to a
+// destination Account on a given date.
+type Transaction struct {
+	ID            uint
+	Date          date.Date
+	Amount        int
+	Description   string
+	SourceID      uint
+	DestinationID uint
+}
+
+// Equal returns true if two Transaction items are logically identical
+func (t Transaction) Equal(ot Transaction) bool {
+	return t.ID == ot.ID &&
+		t.Date.Equal(ot.Date) &&
+		t.Amount == ot.Amount &&
+		t.Description == ot.Description &&
+		t.SourceID == ot.SourceID &&
+		t.DestinationID == ot.DestinationID
+}
+
+// Delta returns the change to the balance of the Account with the given id
+// that is caused by the Transaction. The amount of the Transaction is taken
+// from the source Account and added to the destination Account, so the delta
+// for any other Account is 0.
+func (t Transaction) Delta(accountID uint) int {
+	switch accountID {
+	case t.SourceID:
+		return -t.Amount
+	case t.DestinationID:
+		return t.Amount
+	}
+	return 0
+}
+
+// ValidateTransaction returns an error if the Transaction could not have taken
+// place between the given source and destination Accounts. The Accounts must
+// be different, hold the same currency and both be open on the date of the
+// Transaction, and the amount of the Transaction must be positive.
+func ValidateTransaction(t Transaction, source, destination Account) error {
+	if t.Amount <= 0 {
+		return fmt.Errorf("transaction amount must be positive but was %d", t.Amount)
+	}
+	if source.ID == destination.ID {
+		return fmt.Errorf("source and destination must be different accounts but both were account with id %d", source.ID)
+	}
+	sc, dc := source.Account.CurrencyCode().String(), destination.Account.CurrencyCode().String()
+	if sc != dc {
+		return fmt.Errorf("source account currency %s does not match destination account currency %s", sc, dc)
+	}
+	// A Transaction can only take place on a date that a Balance could be held
+	// for each Account.
+	b := balance.Balance{Date: t.Date.Time()}
+	if err := source.Account.ValidateBalance(b); err != nil {
+		return errors.Wrap(err, "validating date against source account")
+	}
+	return errors.Wrap(destination.Account.ValidateBalance(b), "validating date against destination account")
+}
+
+// Transactions holds multiple Transaction items
+type Transactions []Transaction
+
+// Delta returns the total change to the balance of the Account with the given
+// id that is caused by the Transactions that occurred after the from date, up
+// to and including the to date.
+func (ts Transactions) Delta(accountID uint, from, to date.Date) int {
+	var delta int
+	for _, t := range ts {
+		if t.Date.After(from) && !t.Date.After(to) {
+			delta += t.Delta(accountID)
+		}
+	}
+	return delta
+}
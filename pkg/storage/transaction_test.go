@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/date"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction_Delta(t *testing.T) {
+	tx := Transaction{Amount: 25, SourceID: 1, DestinationID: 2}
+	assert.Equal(t, -25, tx.Delta(1))
+	assert.Equal(t, 25, tx.Delta(2))
+	assert.Equal(t, 0, tx.Delta(3))
+}
+
+func TestTransactions_Delta(t *testing.T) {
+	d := date.New(2018, time.May, 4)
+	ts := Transactions{
+		{Date: d, Amount: 1, SourceID: 1, DestinationID: 2},
+		{Date: d.AddDays(1), Amount: 10, SourceID: 2, DestinationID: 1},
+		{Date: d.AddDays(2), Amount: 100, SourceID: 1, DestinationID: 3},
+		{Date: d.AddDays(3), Amount: 1000, SourceID: 3, DestinationID: 1},
+	}
+	assert.Equal(t, 10-100, ts.Delta(1, d, d.AddDays(2)))
+	assert.Equal(t, -1+10-100+1000, ts.Delta(1, d.AddDays(-1), d.AddDays(3)))
+	assert.Equal(t, 0, ts.Delta(1, d, d))
+	assert.Equal(t, -10, ts.Delta(2, d, d.AddDays(3)))
+}
+
+func TestValidateTransaction(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	newAccount := func(id uint, cc string, os ...account.Option) Account {
+		return Account{
+			ID:      id,
+			Account: *accountingtest.NewAccount(t, "A", accountingtest.NewCurrencyCode(t, cc), opened, os...),
+		}
+	}
+	gbp1, gbp2, eur := newAccount(1, "GBP"), newAccount(2, "GBP"), newAccount(3, "EUR")
+	closed := newAccount(4, "GBP", account.CloseTime(opened.AddDate(0, 0, 5)))
+	valid := Transaction{Date: date.FromTime(opened).AddDays(1), Amount: 10}
+
+	for _, test := range []struct {
+		name                string
+		tx                  Transaction
+		source, destination Account
+		valid               bool
+	}{
+		{name: "valid", tx: valid, source: gbp1, destination: gbp2, valid: true},
+		{name: "valid on closing date", tx: Transaction{Date: date.FromTime(opened).AddDays(5), Amount: 10}, source: gbp1, destination: closed, valid: true},
+		{name: "zero amount", tx: Transaction{Date: valid.Date}, source: gbp1, destination: gbp2},
+		{name: "negative amount", tx: Transaction{Date: valid.Date, Amount: -1}, source: gbp1, destination: gbp2},
+		{name: "same account", tx: valid, source: gbp1, destination: gbp1},
+		{name: "different currencies", tx: valid, source: gbp1, destination: eur},
+		{name: "before opened", tx: Transaction{Date: date.FromTime(opened).AddDays(-1), Amount: 10}, source: gbp1, destination: gbp2},
+		{name: "after closed", tx: Transaction{Date: date.FromTime(opened).AddDays(6), Amount: 10}, source: closed, destination: gbp2},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateTransaction(test.tx, test.source, test.destination)
+			assert.Equal(t, test.valid, err == nil, "%v", err)
+		})
+	}
+}
+
+func TestTransaction_JSONLoop(t *testing.T) {
+	tx := Transaction{
+		ID:            12,
+		Date:          date.New(2018, time.May, 4),
+		Amount:        345,
+		Description:   "rent",
+		SourceID:      1,
+		DestinationID: 2,
+	}
+	bs, err := json.Marshal(tx)
+	common.FatalIfError(t, err, "marshalling Transaction json")
+	var actual Transaction
+	common.FatalIfError(t, json.Unmarshal(bs, &actual), "unmarshalling Transaction json")
+	assert.True(t, tx.Equal(actual))
+}
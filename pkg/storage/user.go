@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoUser is returned by a UserStorage when it holds no User that matches a
+// lookup.
+var ErrNoUser = errors.New("no such user")
+
+// User is a person that shares a Storage with other Users, and who can only
+// see the Accounts that they own or that have been shared with them.
+type User struct {
+	ID   uint
+	Name string
+}
+
+// Users holds multiple User items
+type Users []User
+
+// Access is the access that a User has to an Account
+type Access string
+
+const (
+	// AccessRead allows a User to see an Account along with its Balances and
+	// Transactions
+	AccessRead Access = "read"
+	// AccessWrite also allows a User to update an Account and to change its
+	// Balances and Transactions
+	AccessWrite Access = "write"
+	// AccessOwner also allows a User to delete an Account and to share it
+	// with other Users
+	AccessOwner Access = "owner"
+)
+
+var accessLevels = map[Access]int{
+	AccessRead:  1,
+	AccessWrite: 2,
+	AccessOwner: 3,
+}
+
+// ParseAccess parses one of read, write or owner into an Access
+func ParseAccess(s string) (Access, error) {
+	a := Access(strings.ToLower(s))
+	if _, ok := accessLevels[a]; !ok {
+		return "", fmt.Errorf("unsupported access %q, must be one of %s, %s or %s", s, AccessRead, AccessWrite, AccessOwner)
+	}
+	return a, nil
+}
+
+// Allows returns true if the Access includes the required Access
+func (a Access) Allows(required Access) bool {
+	return accessLevels[a] > 0 && accessLevels[a] >= accessLevels[required]
+}
+
+// UserStorage is a TokenStorage that can also store Users and the Access that
+// each User has to each Account. A Token with a UserID authenticates its
+// User. SelectUserByName returns ErrNoUser when no User has the name.
+type UserStorage interface {
+	TokenStorage
+	InsertUser(name string) (*User, error)
+	SelectUsers() (*Users, error)
+	SelectUserByName(name string) (*User, error)
+	// SetAccountAccess gives a User an Access to an Account, replacing any
+	// Access that the User already has to it.
+	SetAccountAccess(accountID, userID uint, a Access) error
+	// SelectUserAccess returns the Access of a User to each Account that they
+	// have access to, keyed by Account ID.
+	SelectUserAccess(userID uint) (map[uint]Access, error)
+}
+
+// ValidateUserName returns an error if a User name is empty or holds any
+// whitespace.
+func ValidateUserName(name string) error {
+	if name == "" {
+		return errors.New("user name must not be empty")
+	}
+	if strings.ContainsAny(name, " \t\r\n") {
+		return errors.New("user name must not contain whitespace")
+	}
+	return nil
+}
+
+type userKey struct{}
+
+// ContextWithUser returns a copy of the context.Context that holds the ID of
+// the User that operations are made for.
+func ContextWithUser(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// UserFromContext returns the ID of the User held by the context.Context and
+// whether it holds one.
+func UserFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(userKey{}).(uint)
+	return id, ok
+}
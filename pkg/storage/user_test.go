@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccess(t *testing.T) {
+	for _, s := range []string{"read", "write", "owner", "WRITE"} {
+		a, err := ParseAccess(s)
+		assert.NoError(t, err, s)
+		assert.NotEmpty(t, a)
+	}
+	_, err := ParseAccess("admin")
+	assert.Error(t, err)
+	_, err = ParseAccess("")
+	assert.Error(t, err)
+}
+
+func TestAccess_Allows(t *testing.T) {
+	assert.True(t, AccessRead.Allows(AccessRead))
+	assert.False(t, AccessRead.Allows(AccessWrite))
+	assert.True(t, AccessWrite.Allows(AccessRead))
+	assert.False(t, AccessWrite.Allows(AccessOwner))
+	assert.True(t, AccessOwner.Allows(AccessWrite))
+	assert.False(t, Access("").Allows(AccessRead))
+	assert.False(t, Access("admin").Allows(AccessRead))
+}
+
+func TestValidateUserName(t *testing.T) {
+	assert.NoError(t, ValidateUserName("alice"))
+	assert.Error(t, ValidateUserName(""))
+	assert.Error(t, ValidateUserName("alice smith"))
+}
+
+func TestUserFromContext(t *testing.T) {
+	_, ok := UserFromContext(context.Background())
+	assert.False(t, ok)
+	id, ok := UserFromContext(ContextWithUser(context.Background(), 3))
+	assert.True(t, ok)
+	assert.Equal(t, uint(3), id)
+}
@@ -5,81 +5,237 @@ import (
 	"io"
 	"strconv"
 
-	"github.com/glynternet/go-time"
 	"github.com/glynternet/mon/internal/accountbalance"
+	"github.com/glynternet/mon/internal/router"
+	"github.com/glynternet/mon/pkg/backup"
+	"github.com/glynternet/mon/pkg/reconcile"
+	"github.com/glynternet/mon/pkg/report"
 	"github.com/glynternet/mon/pkg/storage"
 	"github.com/olekukonko/tablewriter"
 )
 
 const dateFormat = `02-01-2006`
 
-// Accounts writes a table for a set of Accounts to a given io.Writer
-func Accounts(as storage.Accounts, w io.Writer) {
-	t := newDefaultTable(w)
-	t.SetHeader([]string{"ID", "Name", "Opened", "Closed", "Currency"})
+var accountColumns = []column{
+	{header: "ID", key: "id"},
+	{header: "Name", key: "name"},
+	{header: "Opened", key: "opened"},
+	{header: "Closed", key: "closed"},
+	{header: "Currency", key: "currency"},
+}
 
+// Accounts writes a table for a set of Accounts to a given io.Writer in the
+// given Format, with the keys id, name, opened, closed and currency.
+func Accounts(as storage.Accounts, w io.Writer, f Format) error {
+	g := grid{columns: accountColumns}
 	for _, a := range as {
-		t.Append([]string{
-			strconv.FormatUint(uint64(a.ID), 10),
-			a.Account.Name(),
-			a.Account.Opened().Format(dateFormat),
-			closedString(a.Account.Closed()),
-			a.Account.CurrencyCode().String(),
-		})
+		g.append(
+			idCell(a.ID),
+			textCell(a.Account.Name()),
+			dateCell(a.Account.Opened()),
+			nullDateCell(a.Account.Closed()),
+			textCell(a.Account.CurrencyCode().String()),
+		)
 	}
-	t.Render() // Send output
+	return g.write(w, f)
 }
 
 // AccountsWithBalance writes a table for a set of Accounts with corresponding
-// Balances to a given io.Writer
-func AccountsWithBalance(abs []accountbalance.AccountBalance, w io.Writer) {
-	t := newDefaultTable(w)
-	t.SetHeader([]string{
-		"ID", "Name", "Opened", "Closed", "Currency", "Balance Date", "Balance Amount",
-	})
-
+// Balances to a given io.Writer in the given Format, with the keys of Accounts
+// followed by balance_date and balance_amount.
+func AccountsWithBalance(abs []accountbalance.AccountBalance, w io.Writer, f Format) error {
+	g := grid{columns: append(append([]column{}, accountColumns...),
+		column{header: "Balance Date", key: "balance_date"},
+		column{header: "Balance Amount", key: "balance_amount"},
+	)}
 	for _, ab := range abs {
-		t.Append([]string{
-			strconv.FormatUint(uint64(ab.Account.ID), 10),
-			ab.Account.Account.Name(),
-			ab.Account.Account.Opened().Format(dateFormat),
-			closedString(ab.Account.Account.Closed()),
-			ab.Account.Account.CurrencyCode().String(),
-			ab.Date.Format(dateFormat),
-			strconv.Itoa(ab.Amount),
-		})
+		g.append(
+			idCell(ab.Account.ID),
+			textCell(ab.Account.Account.Name()),
+			dateCell(ab.Account.Account.Opened()),
+			nullDateCell(ab.Account.Account.Closed()),
+			textCell(ab.Account.Account.CurrencyCode().String()),
+			dateCell(ab.Date),
+			intCell(ab.Amount),
+		)
 	}
-	t.Render() // Send output
+	return g.write(w, f)
 }
 
-// Balances writes a table for a given set of storage.Balances to a given io.Writer
-func Balances(bs storage.Balances, w io.Writer) {
-	t := newDefaultTable(w)
-	t.SetHeader([]string{"ID", "Amount", "Date"})
-
+// Balances writes a table for a given set of storage.Balances to a given
+// io.Writer in the given Format, with the keys id, amount and date.
+func Balances(bs storage.Balances, w io.Writer, f Format) error {
+	g := grid{columns: []column{
+		{header: "ID", key: "id"},
+		{header: "Amount", key: "amount"},
+		{header: "Date", key: "date"},
+	}}
 	for _, b := range bs {
-		t.Append([]string{
-			strconv.FormatUint(uint64(b.ID), 10),
-			strconv.Itoa(b.Amount),
-			b.Date.Format(dateFormat),
-		})
+		g.append(idCell(b.ID), intCell(b.Amount), dateCell(b.Date))
 	}
+	return g.write(w, f)
+}
 
-	t.Render()
+// Transactions writes a table for a given set of storage.Transactions to a
+// given io.Writer in the given Format, with the keys id, date, amount,
+// source_id, destination_id and description.
+func Transactions(ts storage.Transactions, w io.Writer, f Format) error {
+	g := grid{columns: []column{
+		{header: "ID", key: "id"},
+		{header: "Date", key: "date"},
+		{header: "Amount", key: "amount"},
+		{header: "Source", key: "source_id"},
+		{header: "Destination", key: "destination_id"},
+		{header: "Description", key: "description"},
+	}}
+	for _, tx := range ts {
+		g.append(
+			idCell(tx.ID),
+			dateCell(tx.Date.Time()),
+			intCell(tx.Amount),
+			idCell(tx.SourceID),
+			idCell(tx.DestinationID),
+			textCell(tx.Description),
+		)
+	}
+	return g.write(w, f)
 }
 
-// Basic writes grid of string data to a given io.Writer
-func Basic(data [][]string, w io.Writer) error {
+// Rates writes a table for a given set of storage.Rates to a given io.Writer
+// in the given Format, with the keys id, date, from, to and rate.
+func Rates(rs storage.Rates, w io.Writer, f Format) error {
+	g := grid{columns: []column{
+		{header: "ID", key: "id"},
+		{header: "Date", key: "date"},
+		{header: "From", key: "from"},
+		{header: "To", key: "to"},
+		{header: "Rate", key: "rate"},
+	}}
+	for _, r := range rs {
+		g.append(idCell(r.ID), dateCell(r.Date.Time()), textCell(r.From), textCell(r.To), floatCell(r.Rate))
+	}
+	return g.write(w, f)
+}
+
+// Reconciliation writes a table for a given set of reconcile.Intervals to a
+// given io.Writer in the given Format, flagging any Interval that is not
+// reconciled. The keys are from, to, from_amount, to_amount, recorded_change,
+// expected_change, unexplained and reconciled, which is a boolean.
+func Reconciliation(is []reconcile.Interval, w io.Writer, f Format) error {
+	g := grid{columns: []column{
+		{header: "From", key: "from"},
+		{header: "To", key: "to"},
+		{header: "From Amount", key: "from_amount"},
+		{header: "To Amount", key: "to_amount"},
+		{header: "Recorded Change", key: "recorded_change"},
+		{header: "Expected Change", key: "expected_change"},
+		{header: "Unexplained", key: "unexplained"},
+		{header: "Status", key: "reconciled"},
+	}}
+	for _, i := range is {
+		status := cell{value: i.Reconciled()}
+		if !i.Reconciled() {
+			status.text = "UNEXPLAINED"
+		}
+		g.append(
+			dateCell(i.From.Date),
+			dateCell(i.To.Date),
+			intCell(i.From.Amount),
+			intCell(i.To.Amount),
+			intCell(i.Recorded),
+			intCell(i.Expected),
+			intCell(i.Unexplained()),
+			status,
+		)
+	}
+	return g.write(w, f)
+}
+
+// NetWorth writes a table for a given set of report.Series to a given
+// io.Writer in the given Format, with a row for each date and a column for
+// each currency. The keys are date followed by each currency code. All of the
+// Series must hold Points for the same dates.
+func NetWorth(ss []report.Series, w io.Writer, f Format) error {
+	g := grid{columns: []column{{header: "Date", key: "date"}}}
+	for _, s := range ss {
+		g.columns = append(g.columns, column{header: s.Currency, key: s.Currency})
+	}
+	if len(ss) > 0 {
+		for i, p := range ss[0].Points {
+			row := []cell{dateCell(p.Date.Time())}
+			for _, s := range ss {
+				row = append(row, intCell(s.Points[i].Amount))
+			}
+			g.append(row...)
+		}
+	}
+	return g.write(w, f)
+}
+
+// BackupSummary writes a table of the number of each item of a backup to a
+// given io.Writer in the given Format, with the keys item and count.
+func BackupSummary(s backup.Summary, w io.Writer, f Format) error {
+	g := grid{columns: []column{{header: "Item", key: "item"}, {header: "Count", key: "count"}}}
+	for _, row := range []struct {
+		item  string
+		count int
+	}{
+		{item: "Accounts", count: s.Accounts},
+		{item: "Deleted accounts", count: s.DeletedAccounts},
+		{item: "Balances", count: s.Balances},
+		{item: "Transactions", count: s.Transactions},
+		{item: "Rates", count: s.Rates},
+		{item: "Users", count: s.Users},
+	} {
+		g.append(cell{text: row.item, value: key(row.item)}, intCell(row.count))
+	}
+	return g.write(w, f)
+}
+
+// Status writes a table of the checks of a router.Status to a given io.Writer
+// in the given Format, with the keys check, status, duration_ms and message,
+// which is null for a check that passed.
+func Status(s router.Status, w io.Writer, f Format) error {
+	g := grid{columns: []column{
+		{header: "Check", key: "check"},
+		{header: "Status", key: "status"},
+		{header: "Duration (ms)", key: "duration_ms"},
+		{header: "Message", key: "message"},
+	}}
+	for _, c := range s.Checks {
+		message := textCell(c.Message)
+		if c.Message == "" {
+			message = cell{}
+		}
+		g.append(
+			textCell(c.Name),
+			textCell(c.Status),
+			cell{text: strconv.FormatFloat(c.DurationMS, 'f', 3, 64), value: c.DurationMS},
+			message,
+		)
+	}
+	return g.write(w, f)
+}
+
+// Basic writes grid of string data to a given io.Writer in the given Format.
+// The first row is the header, which gives the keys as snake_case, and every
+// value is written as a string.
+func Basic(data [][]string, w io.Writer, f Format) error {
 	if len(data) < 2 {
 		return fmt.Errorf("requires at least 2 rows of data")
 	}
-	t := newDefaultTable(w)
-	t.SetHeader(data[0])
+	var g grid
+	for _, header := range data[0] {
+		g.columns = append(g.columns, column{header: header, key: key(header)})
+	}
 	for _, row := range data[1:] {
-		t.Append(row)
+		cs := make([]cell, len(row))
+		for i, text := range row {
+			cs[i] = textCell(text)
+		}
+		g.append(cs...)
 	}
-	t.Render()
-	return nil
+	return g.write(w, f)
 }
 
 func newDefaultTable(w io.Writer) *tablewriter.Table {
@@ -87,10 +243,3 @@ func newDefaultTable(w io.Writer) *tablewriter.Table {
 	table.SetAutoWrapText(false)
 	return table
 }
-
-func closedString(t time.NullTime) string {
-	if !t.Valid {
-		return ""
-	}
-	return t.Time.Format(dateFormat)
-}
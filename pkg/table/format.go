@@ -0,0 +1,218 @@
+package table
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	gtime "github.com/glynternet/go-time"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Format is a format that a table can be written in
+type Format string
+
+// The Formats that a table can be written in. Table is the default and is
+// intended to be read by people. The other Formats are intended to be read by
+// programs: each row of a table is written as an object in JSON and YAML, with
+// keys in the order of the columns, or as a record in CSV and TSV, following a
+// header record of the keys. Keys are in snake_case, dates are written as
+// yyyy-mm-dd and missing values are written as null, or as empty fields in
+// CSV and TSV.
+const (
+	Table Format = "table"
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	YAML  Format = "yaml"
+	TSV   Format = "tsv"
+)
+
+// Formats holds every supported Format
+var Formats = []Format{Table, JSON, CSV, YAML, TSV}
+
+// ParseFormat returns the Format with the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range Formats {
+		if Format(name) == f {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("unsupported output format %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// machineDateFormat is the format of dates in the machine readable Formats
+const machineDateFormat = "2006-01-02"
+
+// column is a column of a table, with a header that is written in the Table
+// Format and a key that is used in the other Formats.
+type column struct {
+	header, key string
+}
+
+// cell holds the text that is written for a value in the Table Format and the
+// value that is written in the other Formats.
+type cell struct {
+	text  string
+	value interface{}
+}
+
+func textCell(s string) cell {
+	return cell{text: s, value: s}
+}
+
+func intCell(i int) cell {
+	return cell{text: strconv.Itoa(i), value: i}
+}
+
+func idCell(id uint) cell {
+	return cell{text: strconv.FormatUint(uint64(id), 10), value: id}
+}
+
+func floatCell(f float64) cell {
+	return cell{text: strconv.FormatFloat(f, 'f', -1, 64), value: f}
+}
+
+func dateCell(t time.Time) cell {
+	return cell{text: t.Format(dateFormat), value: t.Format(machineDateFormat)}
+}
+
+func nullDateCell(t gtime.NullTime) cell {
+	if !t.Valid {
+		return cell{}
+	}
+	return dateCell(t.Time)
+}
+
+// grid is a table of cells that can be written in any Format
+type grid struct {
+	columns []column
+	rows    [][]cell
+}
+
+func (g *grid) append(cs ...cell) {
+	g.rows = append(g.rows, cs)
+}
+
+// write writes the grid in the given Format to the io.Writer
+func (g grid) write(w io.Writer, f Format) error {
+	switch f {
+	case Table, "":
+		t := newDefaultTable(w)
+		headers := make([]string, len(g.columns))
+		for i, c := range g.columns {
+			headers[i] = c.header
+		}
+		t.SetHeader(headers)
+		for _, row := range g.rows {
+			texts := make([]string, len(row))
+			for i, c := range row {
+				texts[i] = c.text
+			}
+			t.Append(texts)
+		}
+		t.Render()
+		return nil
+	case JSON:
+		objects := make([]object, len(g.rows))
+		for i, row := range g.rows {
+			objects[i] = object{columns: g.columns, cells: row}
+		}
+		data, err := json.MarshalIndent(objects, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshalling json")
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case YAML:
+		items := make([]yaml.MapSlice, len(g.rows))
+		for i, row := range g.rows {
+			item := make(yaml.MapSlice, len(row))
+			for j, c := range row {
+				item[j] = yaml.MapItem{Key: g.columns[j].key, Value: c.value}
+			}
+			items[i] = item
+		}
+		data, err := yaml.Marshal(items)
+		if err != nil {
+			return errors.Wrap(err, "marshalling yaml")
+		}
+		_, err = w.Write(data)
+		return err
+	case CSV, TSV:
+		cw := csv.NewWriter(w)
+		if f == TSV {
+			cw.Comma = '\t'
+		}
+		keys := make([]string, len(g.columns))
+		for i, c := range g.columns {
+			keys[i] = c.key
+		}
+		records := [][]string{keys}
+		for _, row := range g.rows {
+			record := make([]string, len(row))
+			for i, c := range row {
+				record[i] = fieldString(c.value)
+			}
+			records = append(records, record)
+		}
+		return errors.Wrap(cw.WriteAll(records), "writing records")
+	}
+	return fmt.Errorf("unsupported output format %q", f)
+}
+
+// object is a row of a grid that is marshalled into a json object with its
+// keys in the order of the columns
+type object struct {
+	columns []column
+	cells   []cell
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, c := range o.cells {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(o.columns[i].key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(c.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshalling value of %s", o.columns[i].key)
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// fieldString returns a value as it is written in a CSV or TSV field
+func fieldString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// key returns a header as a snake_case key
+func key(header string) string {
+	return strings.Join(strings.Fields(strings.ToLower(header)), "_")
+}
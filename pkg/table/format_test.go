@@ -0,0 +1,125 @@
+package table
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats {
+		parsed, err := ParseFormat(string(f))
+		assert.NoError(t, err)
+		assert.Equal(t, f, parsed)
+	}
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestAccounts(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	as := storage.Accounts{
+		{ID: 1, Account: *accountingtest.NewAccount(t, "current, joint", accountingtest.NewCurrencyCode(t, "GBP"), opened)},
+		{ID: 2, Account: *accountingtest.NewAccount(t, "savings", accountingtest.NewCurrencyCode(t, "EUR"), opened,
+			account.CloseTime(opened.AddDate(0, 1, 0)))},
+	}
+
+	for _, test := range []struct {
+		Format
+		expected string
+	}{
+		{
+			Format: JSON,
+			expected: `[
+  {
+    "id": 1,
+    "name": "current, joint",
+    "opened": "2018-05-04",
+    "closed": null,
+    "currency": "GBP"
+  },
+  {
+    "id": 2,
+    "name": "savings",
+    "opened": "2018-05-04",
+    "closed": "2018-06-04",
+    "currency": "EUR"
+  }
+]
+`,
+		},
+		{
+			Format: YAML,
+			expected: `- id: 1
+  name: current, joint
+  opened: "2018-05-04"
+  closed: null
+  currency: GBP
+- id: 2
+  name: savings
+  opened: "2018-05-04"
+  closed: "2018-06-04"
+  currency: EUR
+`,
+		},
+		{
+			Format: CSV,
+			expected: `id,name,opened,closed,currency
+1,"current, joint",2018-05-04,,GBP
+2,savings,2018-05-04,2018-06-04,EUR
+`,
+		},
+		{
+			Format: TSV,
+			expected: "id\tname\topened\tclosed\tcurrency\n" +
+				"1\tcurrent, joint\t2018-05-04\t\tGBP\n" +
+				"2\tsavings\t2018-05-04\t2018-06-04\tEUR\n",
+		},
+		{
+			Format: Table,
+			expected: `+----+----------------+------------+------------+----------+
+| ID |      NAME      |   OPENED   |   CLOSED   | CURRENCY |
++----+----------------+------------+------------+----------+
+|  1 | current, joint | 04-05-2018 |            | GBP      |
+|  2 | savings        | 04-05-2018 | 04-06-2018 | EUR      |
++----+----------------+------------+------------+----------+
+`,
+		},
+	} {
+		t.Run(string(test.Format), func(t *testing.T) {
+			var buf bytes.Buffer
+			common.FatalIfError(t, Accounts(as, &buf, test.Format), "writing accounts")
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+
+	t.Run("empty json", func(t *testing.T) {
+		var buf bytes.Buffer
+		common.FatalIfError(t, Accounts(nil, &buf, JSON), "writing accounts")
+		assert.Equal(t, "[]\n", buf.String())
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.Error(t, Accounts(as, &bytes.Buffer{}, Format("xml")))
+	})
+}
+
+func TestBasic(t *testing.T) {
+	var buf bytes.Buffer
+	common.FatalIfError(t, Basic([][]string{{"Currency", "Total Amount"}, {"GBP", "100"}}, &buf, JSON), "writing basic")
+	assert.Equal(t, `[
+  {
+    "currency": "GBP",
+    "total_amount": "100"
+  }
+]
+`, buf.String())
+
+	assert.Error(t, Basic([][]string{{"Currency"}}, &buf, JSON))
+}
@@ -0,0 +1,141 @@
+package table
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/glynternet/mon/internal/accountbalance"
+	"github.com/glynternet/mon/pkg/amount"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// Row is the data that an output template is executed with. ID is the ID of
+// the Account, and Balance is nil when the output does not include Balances.
+type Row struct {
+	ID      uint
+	Account RowAccount
+	Balance *RowBalance
+}
+
+// RowAccount holds the details of the Account of a Row. Closed is nil when
+// the Account is open.
+type RowAccount struct {
+	Name     string
+	Currency string
+	Opened   time.Time
+	Closed   *time.Time
+}
+
+// RowBalance holds the details of the Balance of a Row. ID is zero when the
+// Balance is not a stored Balance, such as the Balance of an Account at a
+// given date.
+type RowBalance struct {
+	ID     uint
+	Date   time.Time
+	Amount int
+}
+
+// TemplateFuncs returns the functions that are available to output templates,
+// in addition to the predefined functions of text/template:
+//
+//	date LAYOUT TIME        formats a time with a layout of the time package
+//	major PLACES AMOUNT     formats an amount of minor units in major units
+//	padLeft WIDTH VALUE     pads a value with spaces on the left to the width
+//	padRight WIDTH VALUE    pads a value with spaces on the right to the width
+//
+// The value is the last argument of each function, so that they can be used
+// at the end of a pipeline, such as {{.Balance.Amount | major 2}}.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"date":     formatDate,
+		"major":    func(places, minor int) string { return amount.Format(minor, places) },
+		"padLeft":  func(width int, v interface{}) string { return fmt.Sprintf("%*v", width, v) },
+		"padRight": func(width int, v interface{}) string { return fmt.Sprintf("%-*v", width, v) },
+	}
+}
+
+// formatDate formats a time.Time, or a *time.Time that is empty when nil.
+func formatDate(layout string, t interface{}) (string, error) {
+	switch t := t.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	}
+	return "", fmt.Errorf("date: unsupported value of type %T", t)
+}
+
+// NewTemplate parses an output template that can use the TemplateFuncs
+func NewTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(TemplateFuncs()).Parse(text)
+	return t, errors.Wrap(err, "parsing template")
+}
+
+// AccountRows returns a Row for each of the given Accounts
+func AccountRows(as storage.Accounts) []Row {
+	rs := make([]Row, 0, len(as))
+	for _, a := range as {
+		rs = append(rs, accountRow(a))
+	}
+	return rs
+}
+
+// AccountsWithBalanceRows returns a Row for each of the given Accounts with
+// its corresponding Balance.
+func AccountsWithBalanceRows(abs []accountbalance.AccountBalance) []Row {
+	rs := make([]Row, 0, len(abs))
+	for _, ab := range abs {
+		r := accountRow(ab.Account)
+		r.Balance = &RowBalance{Date: ab.Date, Amount: ab.Amount}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+// BalanceRows returns a Row for each of the Balances of the given Account
+func BalanceRows(a storage.Account, bs storage.Balances) []Row {
+	rs := make([]Row, 0, len(bs))
+	for _, b := range bs {
+		r := accountRow(a)
+		r.Balance = &RowBalance{ID: b.ID, Date: b.Date, Amount: b.Amount}
+		rs = append(rs, r)
+	}
+	return rs
+}
+
+func accountRow(a storage.Account) Row {
+	r := Row{
+		ID: a.ID,
+		Account: RowAccount{
+			Name:     a.Account.Name(),
+			Currency: a.Account.CurrencyCode().String(),
+			Opened:   a.Account.Opened(),
+		},
+	}
+	if closed := a.Account.Closed(); closed.Valid {
+		r.Account.Closed = &closed.Time
+	}
+	return r
+}
+
+// Template executes a template for each of the given Rows, writing each result
+// on its own line to the given io.Writer.
+func Template(t *template.Template, rs []Row, w io.Writer) error {
+	for _, r := range rs {
+		var b strings.Builder
+		if err := t.Execute(&b, r); err != nil {
+			return errors.Wrapf(err, "executing template for account with id %d", r.ID)
+		}
+		if _, err := fmt.Fprintln(w, b.String()); err != nil {
+			return errors.Wrap(err, "writing template output")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package table
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/glynternet/go-accounting/account"
+	"github.com/glynternet/go-accounting/accountingtest"
+	"github.com/glynternet/go-accounting/balance"
+	"github.com/glynternet/go-money/common"
+	"github.com/glynternet/mon/internal/accountbalance"
+	"github.com/glynternet/mon/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	as := storage.Accounts{
+		{ID: 1, Account: *accountingtest.NewAccount(t, "current", accountingtest.NewCurrencyCode(t, "GBP"), opened)},
+		{ID: 12, Account: *accountingtest.NewAccount(t, "savings", accountingtest.NewCurrencyCode(t, "EUR"), opened,
+			account.CloseTime(opened.AddDate(0, 1, 0)))},
+	}
+
+	for _, test := range []struct {
+		name, text string
+		rows       []Row
+		expected   string
+	}{
+		{
+			name: "accounts",
+			text: `{{.ID | padLeft 3}} {{.Account.Name | padRight 8}}|{{.Account.Currency}} {{.Account.Opened | date "02 Jan 06"}} {{.Account.Closed | date "2006-01-02"}}`,
+			rows: AccountRows(as),
+			expected: "  1 current |GBP 04 May 18 \n" +
+				" 12 savings |EUR 04 May 18 2018-06-04\n",
+		},
+		{
+			name: "accounts with balance",
+			text: `{{.ID}} {{.Account.Name}} {{.Balance.Amount}} {{major 2 .Balance.Amount}}`,
+			rows: AccountsWithBalanceRows([]accountbalance.AccountBalance{
+				{Account: as[0], Balance: balance.Balance{Date: opened, Amount: 12345}},
+				{Account: as[1], Balance: balance.Balance{Date: opened, Amount: -5}},
+			}),
+			expected: "1 current 12345 123.45\n" +
+				"12 savings -5 -0.05\n",
+		},
+		{
+			name: "balances",
+			text: `{{.Balance.ID}} {{.Balance.Date | date "2006-01-02"}} {{.Balance.Amount | major 3}} {{.Account.Currency}}`,
+			rows: BalanceRows(as[0], storage.Balances{
+				{ID: 7, Balance: balance.Balance{Date: opened, Amount: 1000}},
+				{ID: 8, Balance: balance.Balance{Date: opened.AddDate(0, 0, 1), Amount: 7}},
+			}),
+			expected: "7 2018-05-04 1.000 GBP\n" +
+				"8 2018-05-05 0.007 GBP\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := NewTemplate(test.text)
+			common.FatalIfError(t, err, "parsing template")
+			var buf bytes.Buffer
+			common.FatalIfError(t, Template(tmpl, test.rows, &buf), "executing template")
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestTemplate_Error(t *testing.T) {
+	_, err := NewTemplate(`{{.ID`)
+	assert.Error(t, err)
+
+	opened := time.Date(2018, time.May, 4, 0, 0, 0, 0, time.UTC)
+	rows := AccountRows(storage.Accounts{
+		{ID: 1, Account: *accountingtest.NewAccount(t, "current", accountingtest.NewCurrencyCode(t, "GBP"), opened)},
+	})
+	for _, text := range []string{
+		`{{.Balance.Amount}}`,
+		`{{.Account.Name | date "2006"}}`,
+	} {
+		tmpl, err := NewTemplate(text)
+		common.FatalIfError(t, err, "parsing template")
+		assert.Error(t, Template(tmpl, rows, &bytes.Buffer{}), text)
+	}
+}
@@ -58,9 +58,13 @@ func setup() {
 
 func TestSuite(t *testing.T) {
 	host := viper.GetString(keyServerHost)
-	store := client.Client(host)
-	if !store.Available() {
-		t.Fatalf("store at %q is unavailable", host)
+	store := client.Client{Host: host}
+	const retries = 10
+	for i := 0; !store.Available(); i++ {
+		if i == retries {
+			t.Fatalf("store at %q is unavailable", host)
+		}
+		time.Sleep(time.Second)
 	}
 	storagetest.Test(t, store)
 }